@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bloggo/internal/infrastructure/auditchain"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: bloggo [command]
+
+Starts the server when no command is given.
+
+Commands:
+  audit verify    Walk the audit log hash chain and report the first broken link
+`
+
+// runCommand runs a maintenance command against the configured database
+// and returns the exit code
+func runCommand(args []string) int {
+	switch strings.Join(args, " ") {
+	case "audit verify":
+		return verifyAuditLog()
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", strings.Join(args, " "), usage)
+	return 2
+}
+
+func verifyAuditLog() int {
+	result, err := auditchain.Get().Verify()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to verify the audit log: %v\n", err)
+		return 1
+	}
+
+	if !result.Valid {
+		fmt.Printf(
+			"Audit log is broken at entry %d: %s\n",
+			result.BrokenLink.EntryID,
+			result.BrokenLink.Reason,
+		)
+		return 1
+	}
+
+	if result.HeadID == nil {
+		fmt.Println("Audit log is empty")
+		return 0
+	}
+	fmt.Printf("Audit log is intact: %d entries up to entry %d (%s)\n", result.Entries, *result.HeadID, *result.HeadHash)
+	if !result.CheckpointsEnabled {
+		fmt.Println("AUDIT_CHECKPOINT_SECRET is not set, checkpoints were not checked and entries deleted from the end cannot be detected")
+	} else if result.LatestCheckpoint == nil {
+		fmt.Println("No signed checkpoint yet, entries deleted from the end cannot be detected")
+	} else {
+		fmt.Printf(
+			"Signed checkpoints matching: %d, the latest covers entry %d and was taken at %s\n",
+			result.Checkpoints,
+			result.LatestCheckpoint.EntryID,
+			result.LatestCheckpoint.CreatedAt,
+		)
+	}
+	return 0
+}
@@ -5,9 +5,17 @@ import (
 	"bloggo/internal/app"
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 	"bloggo/internal/module"
+	"bloggo/internal/module/accesstoken"
+	"bloggo/internal/module/account"
 	"bloggo/internal/module/api"
+	"bloggo/internal/module/apikey"
 	"bloggo/internal/module/audit"
 	"bloggo/internal/module/category"
 	"bloggo/internal/module/dashboard"
@@ -15,19 +23,24 @@ import (
 	"bloggo/internal/module/keyvalue"
 	"bloggo/internal/module/post"
 	"bloggo/internal/module/removal_request"
+	"bloggo/internal/module/role"
 	"bloggo/internal/module/search"
 	"bloggo/internal/module/session"
+	"bloggo/internal/module/signingkey"
 	"bloggo/internal/module/static"
 	"bloggo/internal/module/statistics"
 	"bloggo/internal/module/storage"
 	"bloggo/internal/module/tag"
+	"bloggo/internal/module/twofactor"
 	"bloggo/internal/module/user"
 	"bloggo/internal/module/webhook"
+	"bloggo/internal/utils/clientip"
 	"bloggo/internal/utils/validate"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi"
 )
@@ -51,9 +64,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Run a maintenance command instead of the server when one is given
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Get singleton application
 	application := app.Get()
 
+	// Audit entries and webhooks follow the domain events services record
+	// in the outbox, the in-memory stores reload once a change commits
+	bus := events.Get()
+	audit.SubscribeEvents(bus)
+	webhook.SubscribeEvents(bus)
+	permissions.ListenEvents(bus, permissions.Get(), db.Get())
+	apikeys.ListenEvents(bus, apikeys.Get(), db.Get())
+
+	// Resolve the client address first and keep it for the audit log, then
+	// answer CORS preflights before routing since chi has no OPTIONS routes
+	cfg := config.Get()
+	application.RegisterGlobalMiddlewares([]func(http.Handler) http.Handler{
+		middleware.ClientIP(clientip.NewResolver(cfg.TrustedProxies, cfg.TrustedProxyHeader)),
+		middleware.AuditContext,
+		middleware.CORS("/api", cfg.APICORS),
+		middleware.CORS("/internal", cfg.InternalCORS),
+	})
+	if config.IsTLSEnabled() && cfg.HSTSMaxAge > 0 {
+		application.RegisterGlobalMiddlewares([]func(http.Handler) http.Handler{
+			middleware.HSTS(cfg.HSTSMaxAge, cfg.HSTSIncludeSubdomain),
+		})
+	}
+
 	// Load embedded frontend
 	distFS, err := frontend.GetDistFS()
 	if err != nil {
@@ -70,11 +111,11 @@ func main() {
 		log.Println("Static assets registered")
 	}
 
-	// Create middlewares to apply to API/internal routes only
+	// Create middlewares to apply to API/internal routes only. Rate limit
+	// policies are declared per route group, API routes are limited per key
+	// once the key is authenticated.
 	middlewares := []func(http.Handler) http.Handler{
 		middleware.ResponseJSON,
-		middleware.AllowSpecificOrigin,
-		middleware.GlobalRateLimiter(),
 	}
 
 	// Register public API module with middlewares
@@ -103,6 +144,7 @@ func main() {
 		for _, mw := range middlewares {
 			r.Use(mw)
 		}
+		r.Use(middleware.RateLimit(middleware.PolicyInternal))
 
 		internalRouter := chi.NewRouter()
 		internalModules := []module.Module{
@@ -111,6 +153,8 @@ func main() {
 			post.NewModule(),
 			user.NewModule(),
 			session.NewModule(),
+			account.NewModule(),
+			twofactor.NewModule(),
 			removal_request.NewModule(),
 			statistics.NewModule(),
 			audit.NewModule(),
@@ -119,6 +163,10 @@ func main() {
 			health.NewModule(),
 			keyvalue.NewModule(),
 			webhook.NewModule(),
+			apikey.NewModule(),
+			accesstoken.NewModule(),
+			signingkey.NewModule(),
+			role.NewModule(),
 		}
 
 		for _, mod := range internalModules {
@@ -136,6 +184,24 @@ func main() {
 		log.Println("SPA routing configured")
 	}
 
+	// Resume queued webhook deliveries
+	if err := webhook.StartDeliveryQueue(cfg.WebhookWorkers); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start webhook delivery queue: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Chain the audit entries written before the hash chain existed, before
+	// any new entry links to them, and sign the head of the chain regularly
+	auditChain := auditchain.Get()
+	if err := auditChain.Seal(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to seal the audit log: %v\n", err)
+		os.Exit(1)
+	}
+	auditChain.StartCheckpoints(time.Duration(cfg.AuditCheckpointInterval) * time.Second)
+
+	// Deliver the events committed since the last run and the ones to come
+	outbox.StartRelay()
+
 	// Start app
 	if err := application.Bootstrap(); err != nil {
 		fmt.Fprintf(os.Stderr, "Server failed to start: %v\n", err)
@@ -1,13 +1,21 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
+	"net"
 	"net/http"
+	"os/signal"
 	"strconv"
 	"sync"
+	"syscall"
+	"time"
 
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/background"
+	"bloggo/internal/infrastructure/certificates"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module"
 	"bloggo/internal/utils/audit"
@@ -57,14 +65,121 @@ func (app *Application) RegisterGlobalMiddlewares(
 	}
 }
 
+// Bootstrap serves until SIGINT or SIGTERM, then shuts down gracefully. With
+// a certificate configured it serves HTTPS, optionally redirecting plain HTTP
+// from a second port.
 func (app *Application) Bootstrap() error {
 	config := config.Get()
 	portString := strconv.Itoa(config.Port)
 	// Start the server
 	server := &http.Server{
-		Addr:    ":" + portString,
-		Handler: app.Router,
+		Addr:         ":" + portString,
+		Handler:      app.Router,
+		ReadTimeout:  time.Duration(config.ServerReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.ServerWriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(config.ServerIdleTimeout) * time.Second,
 	}
-	log.Printf("Starting server on http://localhost:%s", portString)
-	return server.ListenAndServe()
+
+	servers := []*http.Server{server}
+	tlsEnabled := config.TLSCertFile != ""
+	if tlsEnabled {
+		reloader, err := certificates.NewReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = &tls.Config{
+			GetCertificate: reloader.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+
+		if config.HTTPRedirectPort != 0 {
+			servers = append(servers, &http.Server{
+				Addr:         ":" + strconv.Itoa(config.HTTPRedirectPort),
+				Handler:      redirectToHTTPS(config.Port),
+				ReadTimeout:  time.Duration(config.ServerReadTimeout) * time.Second,
+				WriteTimeout: time.Duration(config.ServerWriteTimeout) * time.Second,
+				IdleTimeout:  time.Duration(config.ServerIdleTimeout) * time.Second,
+			})
+		}
+	}
+
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, len(servers))
+	go func() {
+		if tlsEnabled {
+			log.Printf("Starting server on https://localhost:%s", portString)
+			serveErr <- server.ListenAndServeTLS("", "")
+			return
+		}
+		log.Printf("Starting server on http://localhost:%s", portString)
+		serveErr <- server.ListenAndServe()
+	}()
+	for _, redirect := range servers[1:] {
+		go func() {
+			log.Printf("Redirecting http://localhost%s to HTTPS", redirect.Addr)
+			serveErr <- redirect.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		// Stop whichever listener is still running
+		for _, other := range servers {
+			other.Close()
+		}
+		return err
+	case <-signals.Done():
+	}
+	// A second signal kills the process right away
+	stop()
+
+	return app.shutdown(servers, time.Duration(config.ShutdownTimeout)*time.Second)
+}
+
+// Sends plain HTTP requests to the same host and path over HTTPS
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host := request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+
+		target := "https://" + host + request.URL.RequestURI()
+		http.Redirect(writer, request, target, http.StatusPermanentRedirect)
+	})
+}
+
+// Stops accepting connections, drains in-flight requests, waits for
+// background work such as webhook deliveries and closes the database. All
+// steps share the timeout.
+func (app *Application) shutdown(servers []*http.Server, timeout time.Duration) error {
+	log.Printf("Shutting down, waiting up to %s for pending work", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var shutdownErr error
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server did not drain: %v", err)
+			shutdownErr = err
+		}
+	}
+
+	if err := background.Get().Shutdown(ctx); err != nil {
+		log.Printf("Background work did not finish: %v", err)
+		shutdownErr = err
+	}
+
+	if err := db.Close(); err != nil {
+		log.Printf("Database did not close cleanly: %v", err)
+		shutdownErr = err
+	}
+
+	log.Println("Shutdown complete")
+	return shutdownErr
 }
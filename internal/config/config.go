@@ -1,10 +1,12 @@
 package config
 
 import (
+	"bloggo/internal/utils/clientip"
 	"bloggo/internal/utils/validate"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +20,24 @@ const (
 	// Default token durations
 	DefaultAccessTokenDuration  = 15 * time.Minute      // 15 minutes
 	DefaultRefreshTokenDuration = 7 * 24 * time.Hour    // 7 days
+
+	// Default SMTP submission port
+	DefaultSMTPPort = 587
+
+	// Default server timeouts
+	DefaultServerReadTimeout  = 30 * time.Second
+	DefaultServerWriteTimeout = 60 * time.Second
+	DefaultServerIdleTimeout  = 120 * time.Second
+	DefaultShutdownTimeout    = 30 * time.Second
+
+	// Default HSTS max age when serving TLS, one year
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
+
+	// Default number of concurrent webhook deliveries
+	DefaultWebhookWorkers = 4
+
+	// Default time between signed audit log checkpoints
+	DefaultAuditCheckpointInterval = time.Hour
 )
 
 type Config struct {
@@ -26,7 +46,31 @@ type Config struct {
 	AccessTokenDuration  int    `validate:"required"`
 	RefreshTokenDuration int    `validate:"required"`
 	GeminiAPIKey         string
-	TrustedFrontendKey   string `validate:"required,min=32"`
+	TrustedFrontendKey   string `validate:"omitempty,min=32"`
+	PublicURL            string `validate:"omitempty,url"`
+	SMTPHost             string
+	SMTPPort             int `validate:"min=1,max=65535"`
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string `validate:"required_with=SMTPHost"`
+	APICORS              CORSConfig
+	InternalCORS         CORSConfig
+	TrustedProxies       []string `validate:"dive,cidr"`
+	TrustedProxyHeader   string   `validate:"oneof=Forwarded X-Forwarded-For X-Real-IP"`
+	ServerReadTimeout    int      `validate:"min=1"`
+	ServerWriteTimeout   int      `validate:"min=1"`
+	ServerIdleTimeout    int      `validate:"min=1"`
+	ShutdownTimeout      int      `validate:"min=1"`
+	TLSCertFile          string   `validate:"required_with=TLSKeyFile"`
+	TLSKeyFile           string   `validate:"required_with=TLSCertFile"`
+	HTTPRedirectPort     int      `validate:"omitempty,min=1,max=65535,nefield=Port"`
+	HSTSMaxAge           int      `validate:"min=0"`
+	HSTSIncludeSubdomain bool
+	WebhookWorkers       int `validate:"min=1,max=64"`
+	// Signs audit log checkpoints, none are taken without it. It must not
+	// be JWTSecret, the keyring stores that one in the database.
+	AuditCheckpointSecret   string `validate:"omitempty,min=32,nefield=JWTSecret"`
+	AuditCheckpointInterval int    `validate:"min=60"`
 }
 
 var (
@@ -52,6 +96,18 @@ func IsGeminiEnabled() bool {
 	return Get().GeminiAPIKey != ""
 }
 
+func IsSMTPEnabled() bool {
+	return Get().SMTPHost != ""
+}
+
+func IsTLSEnabled() bool {
+	return Get().TLSCertFile != ""
+}
+
+func IsAuditCheckpointEnabled() bool {
+	return Get().AuditCheckpointSecret != ""
+}
+
 func load() (Config, error) {
 	// Load .env file if it exists (optional - for local development)
 	_ = godotenv.Load()
@@ -83,10 +139,104 @@ func load() (Config, error) {
 	// Get Gemini API key - optional
 	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
 
-	// Get trusted frontend key - REQUIRED
+	// Get trusted frontend key - optional bootstrap key for the public API,
+	// scoped API keys are managed from the panel
 	trustedFrontendKey := os.Getenv("TRUSTED_FRONTEND_KEY")
-	if trustedFrontendKey == "" {
-		return Config{}, fmt.Errorf("TRUSTED_FRONTEND_KEY environment variable is required")
+
+	// Get TLS certificate and key - optional, the server speaks plain HTTP
+	// without them. The files are reloaded when they change on disk.
+	tlsCertFile := os.Getenv("TLS_CERT_FILE")
+	tlsKeyFile := os.Getenv("TLS_KEY_FILE")
+
+	// Get the port redirecting plain HTTP to HTTPS - optional, TLS only
+	httpRedirectPort, err := getEnvAsInt("HTTP_REDIRECT_PORT", 0)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get HSTS settings, only sent over TLS. A max age of 0 disables it.
+	hstsMaxAge, err := getEnvAsInt("HSTS_MAX_AGE", int(DefaultHSTSMaxAge.Seconds()))
+	if err != nil {
+		return Config{}, err
+	}
+
+	hstsIncludeSubdomains := false
+	if value := os.Getenv("HSTS_INCLUDE_SUBDOMAINS"); value != "" {
+		hstsIncludeSubdomains, err = strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid value for HSTS_INCLUDE_SUBDOMAINS: %s", value)
+		}
+	}
+
+	// Get public URL used in emailed links - optional
+	publicURL := strings.TrimRight(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		scheme := "http"
+		if tlsCertFile != "" {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://localhost:%d", scheme, port)
+	}
+
+	// Get SMTP settings - optional, emails are logged when host is empty
+	smtpPort, err := getEnvAsInt("SMTP_PORT", DefaultSMTPPort)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get CORS policies of the public API and the panel
+	apiCORS, err := loadCORS("API_CORS", DefaultAPICORS)
+	if err != nil {
+		return Config{}, err
+	}
+
+	internalCORS, err := loadCORS("INTERNAL_CORS", DefaultInternalCORS)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get trusted reverse proxies - optional, forwarding headers are
+	// ignored unless the request comes from one of them
+	trustedProxies := getEnvAsList("TRUSTED_PROXIES", nil)
+	for i, proxy := range trustedProxies {
+		trustedProxies[i] = normalizeCIDR(proxy)
+	}
+	// The one header the proxies set, the others may come from the client
+	trustedProxyHeader := normalizeProxyHeader(os.Getenv("TRUSTED_PROXY_HEADER"))
+
+	// Get server timeouts in seconds
+	serverReadTimeout, err := getEnvAsInt("SERVER_READ_TIMEOUT", int(DefaultServerReadTimeout.Seconds()))
+	if err != nil {
+		return Config{}, err
+	}
+
+	serverWriteTimeout, err := getEnvAsInt("SERVER_WRITE_TIMEOUT", int(DefaultServerWriteTimeout.Seconds()))
+	if err != nil {
+		return Config{}, err
+	}
+
+	serverIdleTimeout, err := getEnvAsInt("SERVER_IDLE_TIMEOUT", int(DefaultServerIdleTimeout.Seconds()))
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get how long shutdown waits for requests and background work
+	shutdownTimeout, err := getEnvAsInt("SHUTDOWN_TIMEOUT", int(DefaultShutdownTimeout.Seconds()))
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get how many webhook deliveries run at once
+	webhookWorkers, err := getEnvAsInt("WEBHOOK_WORKERS", DefaultWebhookWorkers)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Get how audit log checkpoints are signed and how often they are taken
+	auditCheckpointSecret := os.Getenv("AUDIT_CHECKPOINT_SECRET")
+	auditCheckpointInterval, err := getEnvAsInt("AUDIT_CHECKPOINT_INTERVAL", int(DefaultAuditCheckpointInterval.Seconds()))
+	if err != nil {
+		return Config{}, err
 	}
 
 	result := Config{
@@ -96,6 +246,29 @@ func load() (Config, error) {
 		RefreshTokenDuration: refreshTokenDuration,
 		GeminiAPIKey:         geminiAPIKey,
 		TrustedFrontendKey:   trustedFrontendKey,
+		PublicURL:            publicURL,
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPPort:             smtpPort,
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:             os.Getenv("SMTP_FROM"),
+		APICORS:              apiCORS,
+		InternalCORS:         internalCORS,
+		TrustedProxies:       trustedProxies,
+		TrustedProxyHeader:   trustedProxyHeader,
+		ServerReadTimeout:    serverReadTimeout,
+		ServerWriteTimeout:   serverWriteTimeout,
+		ServerIdleTimeout:    serverIdleTimeout,
+		ShutdownTimeout:      shutdownTimeout,
+		TLSCertFile:          tlsCertFile,
+		TLSKeyFile:           tlsKeyFile,
+		HTTPRedirectPort:     httpRedirectPort,
+		HSTSMaxAge:           hstsMaxAge,
+		HSTSIncludeSubdomain: hstsIncludeSubdomains,
+		WebhookWorkers:       webhookWorkers,
+
+		AuditCheckpointSecret:   auditCheckpointSecret,
+		AuditCheckpointInterval: auditCheckpointInterval,
 	}
 
 	// Validate configuration
@@ -120,3 +293,30 @@ func getEnvAsInt(key string, defaultValue int) (int, error) {
 
 	return value, nil
 }
+
+// normalizeProxyHeader spells the forwarding header as the resolver expects,
+// X-Forwarded-For when none is given
+func normalizeProxyHeader(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return clientip.HeaderXForwardedFor
+	case strings.ToLower(clientip.HeaderForwarded):
+		return clientip.HeaderForwarded
+	case strings.ToLower(clientip.HeaderXForwardedFor):
+		return clientip.HeaderXForwardedFor
+	case strings.ToLower(clientip.HeaderXRealIP):
+		return clientip.HeaderXRealIP
+	}
+	return value
+}
+
+// normalizeCIDR turns a single address into a CIDR that only contains it
+func normalizeCIDR(value string) string {
+	if strings.Contains(value, "/") {
+		return value
+	}
+	if strings.Contains(value, ":") {
+		return value + "/128"
+	}
+	return value + "/32"
+}
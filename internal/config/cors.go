@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Default CORS policies. The panel is served with credentials from the
+// local frontend during development, the public API is read with API keys.
+var (
+	DefaultInternalCORS = CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	DefaultAPICORS = CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "X-API-Key", "X-Trusted-Frontend"},
+		AllowCredentials: false,
+		MaxAge:           600,
+	}
+)
+
+// CORSConfig is the cross-origin policy of a group of routes. Origins are
+// either "*", an exact origin such as "https://example.com" or a wildcard
+// subdomain such as "https://*.example.com". "*" is only allowed without
+// credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int `validate:"min=0"`
+}
+
+// loadCORS reads the policy from variables with the given prefix, such as
+// INTERNAL_CORS_ALLOWED_ORIGINS, falling back to the defaults.
+func loadCORS(prefix string, defaults CORSConfig) (CORSConfig, error) {
+	result := CORSConfig{
+		AllowedOrigins:   getEnvAsList(prefix+"_ALLOWED_ORIGINS", defaults.AllowedOrigins),
+		AllowedMethods:   getEnvAsList(prefix+"_ALLOWED_METHODS", defaults.AllowedMethods),
+		AllowedHeaders:   getEnvAsList(prefix+"_ALLOWED_HEADERS", defaults.AllowedHeaders),
+		AllowCredentials: defaults.AllowCredentials,
+		MaxAge:           defaults.MaxAge,
+	}
+
+	if value := os.Getenv(prefix + "_ALLOW_CREDENTIALS"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return CORSConfig{}, fmt.Errorf("invalid value for %s_ALLOW_CREDENTIALS: %s", prefix, value)
+		}
+		result.AllowCredentials = allow
+	}
+
+	maxAge, err := getEnvAsInt(prefix+"_MAX_AGE", defaults.MaxAge)
+	if err != nil {
+		return CORSConfig{}, err
+	}
+	result.MaxAge = maxAge
+
+	for i, method := range result.AllowedMethods {
+		result.AllowedMethods[i] = strings.ToUpper(method)
+	}
+
+	for _, origin := range result.AllowedOrigins {
+		if err := validateOriginPattern(origin); err != nil {
+			return CORSConfig{}, fmt.Errorf("invalid origin in %s_ALLOWED_ORIGINS: %w", prefix, err)
+		}
+		// Any site could make credentialed calls otherwise
+		if origin == "*" && result.AllowCredentials {
+			return CORSConfig{}, fmt.Errorf("%s_ALLOWED_ORIGINS cannot be * while %s_ALLOW_CREDENTIALS is true", prefix, prefix)
+		}
+	}
+
+	return result, nil
+}
+
+func validateOriginPattern(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+
+	parsed, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") {
+		return fmt.Errorf("%s is not an origin", origin)
+	}
+	if strings.Contains(origin, "*") && !strings.HasPrefix(parsed.Host, "wildcard.") {
+		return fmt.Errorf("%s can only use a wildcard for subdomains", origin)
+	}
+	return nil
+}
+
+// getEnvAsList reads a comma separated environment variable with a default fallback
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return append([]string(nil), defaultValue...)
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -26,7 +26,9 @@ var (
 func MustConnect() error {
 	once.Do(func() {
 		var err error
-		db, err = sql.Open("sqlite3", "bloggo.sqlite")
+		// Transactions take the write lock when they begin, so two that read
+		// before writing wait for each other instead of failing as deadlocked
+		db, err = sql.Open("sqlite3", "bloggo.sqlite?_txlock=immediate")
 		if err != nil {
 			initErr = fmt.Errorf("cannot open the database: %w", err)
 			return
@@ -61,3 +63,11 @@ func MustConnect() error {
 func Get() *sql.DB {
 	return db
 }
+
+// Close waits for running queries and closes the database
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	return db.Close()
+}
@@ -12,5 +12,39 @@ func InitializeTables(database *sql.DB) error {
 			return fmt.Errorf("database cannot be initialized: %w", err)
 		}
 	}
+
+	for _, column := range AddedColumns {
+		if err := addColumn(database, column); err != nil {
+			return fmt.Errorf("database cannot be initialized: %w", err)
+		}
+	}
+
+	for _, query := range BackfillQueries {
+		if _, err := database.Exec(query); err != nil {
+			return fmt.Errorf("database cannot be initialized: %w", err)
+		}
+	}
 	return nil
 }
+
+// Adds the column unless an earlier start already did. SQLite has no
+// ADD COLUMN IF NOT EXISTS, so the table info is checked first.
+func addColumn(database *sql.DB, column Column) error {
+	var count int
+	err := database.QueryRow(
+		QueryCountTableColumn,
+		column.Table,
+		column.Name,
+	).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = database.Exec(fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s %s;",
+		column.Table,
+		column.Name,
+		column.Definition,
+	))
+	return err
+}
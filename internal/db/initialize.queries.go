@@ -20,6 +20,105 @@ const (
 	CREATE UNIQUE INDEX IF NOT EXISTS unique_active_email
 	ON users(email)
 	WHERE deleted_at IS NULL;`
+	QueryCreateTableUserTokenVersions = `
+	CREATE TABLE IF NOT EXISTS user_token_versions (
+		user_id INTEGER PRIMARY KEY,
+		version INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+		ON DELETE CASCADE
+	);`
+	// TWO FACTOR AUTHENTICATION
+	QueryCreateTableUserTwoFactor = `
+	CREATE TABLE IF NOT EXISTS user_two_factor (
+		user_id INTEGER PRIMARY KEY,
+		secret VARCHAR(64) NOT NULL,
+		last_used_step INTEGER NOT NULL DEFAULT 0,
+		enabled_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+		ON DELETE CASCADE
+	);`
+	QueryCreateTableUserRecoveryCodes = `
+	CREATE TABLE IF NOT EXISTS user_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		code_hash VARCHAR(64) NOT NULL,
+		used_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id
+	ON user_recovery_codes(user_id);`
+	QueryCreateTableRoleTwoFactorRequirements = `
+	CREATE TABLE IF NOT EXISTS role_two_factor_requirements (
+		role_id INTEGER PRIMARY KEY,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (role_id) REFERENCES roles(id)
+		ON DELETE CASCADE
+	);`
+	// PASSPHRASE RESET AND INVITATION TOKENS
+	QueryCreateTableUserActionTokens = `
+	CREATE TABLE IF NOT EXISTS user_action_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		purpose VARCHAR(20) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		used_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_action_tokens_user_id
+	ON user_action_tokens(user_id, purpose);`
+	// PUBLIC API KEYS
+	QueryCreateTableAPIKeys = `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(100) NOT NULL,
+		key_prefix VARCHAR(16) NOT NULL,
+		key_hash VARCHAR(64) NOT NULL UNIQUE,
+		scopes TEXT NOT NULL DEFAULT '',
+		rate_limit INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP WITH TIME ZONE,
+		last_used_at TIMESTAMP WITH TIME ZONE,
+		revoked_at TIMESTAMP WITH TIME ZONE,
+		created_by INTEGER,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP WITH TIME ZONE,
+		FOREIGN KEY (created_by) REFERENCES users(id)
+		ON DELETE SET NULL
+	);`
+	// PERSONAL ACCESS TOKENS
+	QueryCreateTablePersonalAccessTokens = `
+	CREATE TABLE IF NOT EXISTS personal_access_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		token_prefix VARCHAR(16) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		permissions TEXT NOT NULL DEFAULT '',
+		expires_at TIMESTAMP WITH TIME ZONE,
+		last_used_at TIMESTAMP WITH TIME ZONE,
+		revoked_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id
+	ON personal_access_tokens(user_id);`
+	// JWT SIGNING KEYS
+	QueryCreateTableJWTSigningKeys = `
+	CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+		id VARCHAR(16) PRIMARY KEY,
+		secret VARCHAR(64) NOT NULL,
+		promotion INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		promoted_at TIMESTAMP WITH TIME ZONE,
+		retired_at TIMESTAMP WITH TIME ZONE
+	);`
 	// ROLE BASED ACCESS CONTROL
 	QueryCreateTableRoles = `
 	CREATE TABLE IF NOT EXISTS roles (
@@ -39,6 +138,12 @@ const (
 		FOREIGN KEY (role_id) REFERENCES roles(id),
 		FOREIGN KEY (permission_id) REFERENCES permissions(id)
 	);`
+	QueryCreateTableRolePermissionSeeds = `
+	CREATE TABLE IF NOT EXISTS role_permission_seeds (
+		role_id INTEGER NOT NULL,
+		permission_id INTEGER NOT NULL,
+		PRIMARY KEY (role_id, permission_id)
+	);`
 	// CATEGORIES
 	QueryCreateTableCategories = `
 	CREATE TABLE IF NOT EXISTS categories (
@@ -54,6 +159,19 @@ const (
 	CREATE UNIQUE INDEX IF NOT EXISTS unique_active_slug_category
 	ON categories(slug)
 	WHERE deleted_at IS NULL;`
+	// Narrows a permission of the user's role to the listed categories.
+	// Users without rows for a permission keep it for every category.
+	QueryCreateTableUserCategoryPermissions = `
+	CREATE TABLE IF NOT EXISTS user_category_permissions (
+		user_id INTEGER NOT NULL,
+		permission_id INTEGER NOT NULL,
+		category_id INTEGER NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, permission_id, category_id),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (permission_id) REFERENCES permissions(id),
+		FOREIGN KEY (category_id) REFERENCES categories(id)
+	);`
 	// POSTS
 	QueryCreateTablePosts = `
 	CREATE TABLE IF NOT EXISTS posts (
@@ -226,14 +344,87 @@ const (
 	ON webhook_requests(entity, entity_id);
 	CREATE INDEX IF NOT EXISTS idx_webhook_requests_created_at
 	ON webhook_requests(created_at);`
+	QueryCreateTableWebhookEndpoints = `
+	CREATE TABLE IF NOT EXISTS webhook_endpoints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(100) NOT NULL,
+		url TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	QueryCreateTableWebhookEndpointHeaders = `
+	CREATE TABLE IF NOT EXISTS webhook_endpoint_headers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint_id INTEGER NOT NULL,
+		key VARCHAR(255) NOT NULL,
+		value TEXT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (endpoint_id, key),
+		FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE
+	);`
+	QueryCreateTableWebhookEndpointEvents = `
+	CREATE TABLE IF NOT EXISTS webhook_endpoint_events (
+		endpoint_id INTEGER NOT NULL,
+		pattern VARCHAR(100) NOT NULL,
+		PRIMARY KEY (endpoint_id, pattern),
+		FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE
+	);`
+	// OUTBOX
+	// Events are written here in the transaction of the change they
+	// describe, one row per subscriber, and the relay delivers them
+	QueryCreateTableOutboxEvents = `
+	CREATE TABLE IF NOT EXISTS outbox_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_type VARCHAR(100) NOT NULL,
+		subscriber VARCHAR(100) NOT NULL,
+		payload TEXT NOT NULL,
+		attempt_count INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		dispatched_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+	ON outbox_events(dispatched_at, next_attempt_at);`
+	// Moves the single URL and global headers of older versions to an
+	// endpoint subscribed to every event. The old rows are removed so this
+	// runs once.
+	QueryMigrateWebhookConfig = `
+	INSERT INTO webhook_endpoints (name, url, enabled, created_at, updated_at)
+	SELECT 'Default', url, 1, updated_at, updated_at
+	FROM webhook_config
+	WHERE url != '';
+	INSERT INTO webhook_endpoint_events (endpoint_id, pattern)
+	SELECT id, '*'
+	FROM webhook_endpoints
+	WHERE id = (SELECT MAX(id) FROM webhook_endpoints)
+	AND EXISTS (SELECT 1 FROM webhook_config WHERE url != '');
+	INSERT INTO webhook_endpoint_headers (endpoint_id, key, value, created_at, updated_at)
+	SELECT (SELECT MAX(id) FROM webhook_endpoints), key, value, created_at, updated_at
+	FROM webhook_headers
+	WHERE EXISTS (SELECT 1 FROM webhook_config WHERE url != '');
+	DELETE FROM webhook_headers;
+	DELETE FROM webhook_config;`
 )
 
 var InitializeQueries = []string{
 	QueryCreateTableUsersTable,
+	QueryCreateTableUserTokenVersions,
 	QueryCreateTableRoles,
 	QueryCreateTablePermission,
 	QueryCreateTableRolePermissions,
+	QueryCreateTableRolePermissionSeeds,
+	QueryCreateTableUserTwoFactor,
+	QueryCreateTableUserRecoveryCodes,
+	QueryCreateTableRoleTwoFactorRequirements,
+	QueryCreateTableUserActionTokens,
+	QueryCreateTableAPIKeys,
+	QueryCreateTablePersonalAccessTokens,
+	QueryCreateTableJWTSigningKeys,
 	QueryCreateTableCategories,
+	QueryCreateTableUserCategoryPermissions,
 	QueryCreateTablePosts,
 	QueryCreateTablePostVersions,
 	QueryCreateTableTags,
@@ -245,4 +436,69 @@ var InitializeQueries = []string{
 	QueryCreateTableWebhookConfig,
 	QueryCreateTableWebhookHeaders,
 	QueryCreateTableWebhookRequests,
+	QueryCreateTableWebhookEndpoints,
+	QueryCreateTableWebhookEndpointHeaders,
+	QueryCreateTableWebhookEndpointEvents,
+	QueryMigrateWebhookConfig,
+	QueryCreateTableOutboxEvents,
+}
+
+// Column is added to a table created by an earlier version.
+type Column struct {
+	Table      string
+	Name       string
+	Definition string
+}
+
+const QueryCountTableColumn = `
+	SELECT COUNT(*)
+	FROM pragma_table_info(?)
+	WHERE name = ?;`
+
+// Columns added after their table was first released. Tables created by
+// InitializeQueries don't declare these, so new and old databases end up
+// with the same schema.
+var AddedColumns = []Column{
+	{Table: "post_views", Name: "ip_address", Definition: "TEXT NULL"},
+	{Table: "webhook_requests", Name: "endpoint_id", Definition: "INTEGER NULL"},
+	{Table: "webhook_endpoints", Name: "secret", Definition: "TEXT NULL"},
+	{Table: "webhook_endpoints", Name: "previous_secret", Definition: "TEXT NULL"},
+	{Table: "webhook_endpoints", Name: "previous_secret_expires_at", Definition: "TIMESTAMP WITH TIME ZONE NULL"},
+	{Table: "webhook_requests", Name: "status", Definition: "VARCHAR(20) NULL"},
+	{Table: "webhook_requests", Name: "next_attempt_at", Definition: "TIMESTAMP WITH TIME ZONE NULL"},
+	{Table: "webhook_requests", Name: "redelivery_of", Definition: "INTEGER NULL"},
+	{Table: "webhook_endpoints", Name: "format", Definition: "VARCHAR(20) NOT NULL DEFAULT 'json'"},
+	{Table: "webhook_endpoints", Name: "template", Definition: "TEXT NULL"},
+	{Table: "audit_logs", Name: "ip_address", Definition: "TEXT NULL"},
+	{Table: "audit_logs", Name: "user_agent", Definition: "TEXT NULL"},
+	{Table: "audit_logs", Name: "request_id", Definition: "VARCHAR(64) NULL"},
+	{Table: "audit_logs", Name: "prev_hash", Definition: "CHAR(64) NULL"},
+	{Table: "audit_logs", Name: "hash", Definition: "CHAR(64) NULL"},
+	{Table: "audit_logs", Name: "outbox_id", Definition: "INTEGER NULL"},
+	{Table: "personal_access_tokens", Name: "token_version", Definition: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// Fills added columns of existing rows and indexes them, run after
+// AddedColumns on every start so they must be idempotent.
+var BackfillQueries = []string{
+	// Endpoints created before signing get a secret of the same format
+	// the service generates
+	`UPDATE webhook_endpoints
+	SET secret = 'whsec_' || lower(hex(randomblob(32)))
+	WHERE secret IS NULL OR secret = '';`,
+	// Requests sent before the delivery queue either succeeded or were
+	// given up on
+	`UPDATE webhook_requests
+	SET status = CASE
+		WHEN response_status BETWEEN 200 AND 299 THEN 'delivered'
+		ELSE 'dead'
+	END
+	WHERE status IS NULL;`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_requests_queue
+	ON webhook_requests(status, next_attempt_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_requests_redelivery_of
+	ON webhook_requests(redelivery_of);`,
+	// One entry per outbox event, a redelivered event is not logged twice
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_outbox_id
+	ON audit_logs(outbox_id);`,
 }
@@ -69,6 +69,15 @@ func SeedDatabase(database *sql.DB) error {
 			)
 			if err != nil {
 				log.Printf("Failed to insert role_permission: %s-%s", role, permission)
+				continue
+			}
+			_, err = database.Exec(
+				InsertRolePermissionSeedSQL,
+				roleID,
+				permissionID,
+			)
+			if err != nil {
+				log.Printf("Failed to record role_permission seed: %s-%s", role, permission)
 			}
 		}
 	}
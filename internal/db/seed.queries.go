@@ -35,17 +35,33 @@ const (
     ('user:delete'),
     ('user:change_passphrase'),
     ('user:assign_role'),
+    ('session:manage'),
+    ('twofactor:manage'),
     ('statistics:view-total'),
     ('statistics:view-others'),
     ('statistics:view-self'),
     ('auditlog:view'),
     ('keyvalue:manage'),
     ('webhook:manage'),
+    ('apikey:manage'),
+    ('signingkey:manage'),
+    ('role:manage'),
     ('apidoc:view')
 	ON CONFLICT(name)
   DO NOTHING;`
+	// Grants are only seeded once, so permissions revoked from built-in
+	// roles through the roles API are not granted again on restart
 	InsertPermissionToRoleSQL = `
   INSERT INTO role_permissions (role_id, permission_id)
+  SELECT ?1, ?2
+  WHERE NOT EXISTS (
+    SELECT 1 FROM role_permission_seeds
+    WHERE role_id = ?1 AND permission_id = ?2
+  )
+  ON CONFLICT(role_id, permission_id)
+  DO NOTHING;`
+	InsertRolePermissionSeedSQL = `
+  INSERT INTO role_permission_seeds (role_id, permission_id)
   VALUES (?, ?)
   ON CONFLICT(role_id, permission_id)
   DO NOTHING;`
@@ -86,8 +102,9 @@ var (
 			"tag:list", "tag:view", "tag:create", "tag:update", "tag:delete", "tag:assign",
 			"category:list", "category:view", "category:create", "category:update", "category:delete",
 			"user:list", "user:view", "user:register", "user:update", "user:delete", "user:change_passphrase", "user:assign_role",
+			"session:manage", "twofactor:manage",
 			"statistics:view-self", "statistics:view-others", "statistics:view-total",
-			"keyvalue:manage", "auditlog:view", "webhook:manage", "apidoc:view",
+			"keyvalue:manage", "auditlog:view", "webhook:manage", "apikey:manage", "signingkey:manage", "role:manage", "apidoc:view",
 		},
 	}
 	SeedQueries = []string{
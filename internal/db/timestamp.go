@@ -0,0 +1,19 @@
+package db
+
+import "time"
+
+// Layout of timestamps written by SQLite's CURRENT_TIMESTAMP
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// Parses a stored timestamp, accepting RFC 3339 as well
+func ParseTimestamp(value string) (time.Time, error) {
+	if parsed, err := time.Parse(TimestampLayout, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Formats a time so it compares correctly with CURRENT_TIMESTAMP
+func FormatTimestamp(moment time.Time) string {
+	return moment.UTC().Format(TimestampLayout)
+}
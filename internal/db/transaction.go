@@ -0,0 +1,47 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Executor runs statements on the database or inside a transaction, so
+// repositories can take part in a transaction started by their caller
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Tx is a transaction started with Begin
+type Tx interface {
+	Executor
+	Commit() error
+	Rollback() error
+}
+
+// Begin starts a transaction on the executor. When the executor already is
+// a transaction the statements join it, and committing or rolling back is
+// left to whoever started it.
+func Begin(executor Executor) (Tx, error) {
+	switch executor := executor.(type) {
+	case *sql.DB:
+		return executor.Begin()
+	case *sql.Tx:
+		return joinedTx{executor}, nil
+	}
+	return nil, fmt.Errorf("cannot begin a transaction on %T", executor)
+}
+
+type joinedTx struct {
+	*sql.Tx
+}
+
+func (joinedTx) Commit() error {
+	return nil
+}
+
+func (joinedTx) Rollback() error {
+	return nil
+}
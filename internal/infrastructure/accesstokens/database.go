@@ -0,0 +1,108 @@
+package accesstokens
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/utils/cryptography"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Last use is written to the database at most this often per token
+const touchInterval = time.Minute
+
+// Tokens are read from the database on every request so revocation and
+// role changes apply immediately. Only last-use times are kept in memory.
+type databaseStore struct {
+	database  *sql.DB
+	lastTouch map[int64]time.Time
+	lock      sync.Mutex
+}
+
+var (
+	once     sync.Once
+	instance Store
+)
+
+func Get() Store {
+	once.Do(func() {
+		instance = newDatabaseStore(db.Get())
+	})
+	return instance
+}
+
+func newDatabaseStore(database *sql.DB) *databaseStore {
+	return &databaseStore{
+		database:  database,
+		lastTouch: make(map[int64]time.Time),
+	}
+}
+
+func (store *databaseStore) Authenticate(rawToken string) (*Token, bool, error) {
+	var token Token
+	var permissions string
+	var expiresAt sql.NullString
+	var tokenVersion, currentVersion int64
+
+	err := store.database.QueryRow(
+		QueryGetTokenByHash,
+		cryptography.HashString(rawToken),
+	).Scan(
+		&token.Id,
+		&token.Name,
+		&token.UserId,
+		&token.RoleId,
+		&permissions,
+		&expiresAt,
+		&tokenVersion,
+		&currentVersion,
+	)
+	if err == sql.ErrNoRows {
+		return nil, false, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Passphrase changes, role changes and revoking the owner's sessions
+	// bump the version, like for session tokens
+	if tokenVersion != currentVersion {
+		return nil, false, ErrRevokedToken
+	}
+
+	if permissions != "" {
+		token.Permissions = strings.Split(permissions, ",")
+	}
+	if expiresAt.Valid {
+		parsed, err := db.ParseTimestamp(expiresAt.String)
+		if err != nil {
+			return nil, false, err
+		}
+		if time.Now().After(parsed) {
+			return nil, false, ErrExpiredToken
+		}
+		token.ExpiresAt = &parsed
+	}
+
+	return &token, store.touch(token.Id), nil
+}
+
+func (store *databaseStore) touch(tokenId int64) bool {
+	store.lock.Lock()
+	now := time.Now()
+	if now.Sub(store.lastTouch[tokenId]) < touchInterval {
+		store.lock.Unlock()
+		return false
+	}
+	store.lastTouch[tokenId] = now
+	store.lock.Unlock()
+
+	go func() {
+		if _, err := store.database.Exec(QueryTouchToken, tokenId); err != nil {
+			log.Printf("Failed to update last use of access token %d: %v", tokenId, err)
+		}
+	}()
+	return true
+}
@@ -0,0 +1,17 @@
+package accesstokens
+
+const (
+	QueryGetTokenByHash = `
+	SELECT t.id, t.name, t.user_id, u.role_id, t.permissions, t.expires_at,
+		t.token_version, COALESCE(v.version, 0)
+	FROM personal_access_tokens t
+	JOIN users u ON u.id = t.user_id
+	LEFT JOIN user_token_versions v ON v.user_id = t.user_id
+	WHERE t.token_hash = ?
+		AND t.revoked_at IS NULL
+		AND u.deleted_at IS NULL;`
+	QueryTouchToken = `
+	UPDATE personal_access_tokens
+	SET last_used_at = CURRENT_TIMESTAMP
+	WHERE id = ?;`
+)
@@ -0,0 +1,35 @@
+package accesstokens
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Prefix of personal access tokens, tells them apart from JWTs
+	TokenPrefix = "bgp_"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid personal access token")
+	ErrExpiredToken = errors.New("personal access token has expired")
+	ErrRevokedToken = errors.New("personal access token was issued before the owner's tokens were revoked")
+)
+
+// Token is an active personal access token together with the current
+// role of its owner
+type Token struct {
+	Id          int64
+	Name        string
+	UserId      int64
+	RoleId      int64
+	Permissions []string
+	ExpiresAt   *time.Time
+}
+
+// Store resolves personal access tokens presented as Bearer credentials.
+type Store interface {
+	// Returns the token and whether its use should be recorded, which is
+	// at most once per minute per token.
+	Authenticate(rawToken string) (token *Token, recordUse bool, err error)
+}
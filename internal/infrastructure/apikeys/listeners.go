@@ -0,0 +1,21 @@
+package apikeys
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"database/sql"
+	"log"
+)
+
+// ListenEvents reloads the store once keys change, so a revoked key stops
+// working before the request that revoked it answers
+func ListenEvents(bus *events.Bus, store Store, database *sql.DB) {
+	reload := func() {
+		if err := store.Load(database); err != nil {
+			log.Printf("Failed to reload API keys: %v", err)
+		}
+	}
+
+	events.Listen(bus, "apikeys", events.Sync, func(events.APIKeyCreated) { reload() })
+	events.Listen(bus, "apikeys", events.Sync, func(events.APIKeyUpdated) { reload() })
+	events.Listen(bus, "apikeys", events.Sync, func(events.APIKeyRevoked) { reload() })
+}
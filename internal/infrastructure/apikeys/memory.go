@@ -0,0 +1,151 @@
+package apikeys
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/utils/cryptography"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Last use is written to the database at most this often per key
+const touchInterval = time.Minute
+
+type memoryStore struct {
+	database  *sql.DB
+	keys      keyStore
+	limiters  map[int64]*rate.Limiter
+	lastTouch map[int64]time.Time
+	lock      sync.RWMutex
+	touchLock sync.Mutex
+}
+
+var (
+	once     sync.Once
+	instance Store
+)
+
+func Get() Store {
+	once.Do(func() {
+		database := db.Get()
+		instance = newMemoryStore(database)
+		if err := instance.Load(database); err != nil {
+			log.Printf("Failed to load API keys: %v", err)
+		}
+	})
+	return instance
+}
+
+func newMemoryStore(database *sql.DB) *memoryStore {
+	return &memoryStore{
+		database:  database,
+		keys:      make(keyStore),
+		limiters:  make(map[int64]*rate.Limiter),
+		lastTouch: make(map[int64]time.Time),
+	}
+}
+
+// Loads all non-revoked keys from the database into memory.
+func (store *memoryStore) Load(database *sql.DB) error {
+	rows, err := database.Query(QueryGetActiveKeys)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := make(keyStore)
+	for rows.Next() {
+		var key Key
+		var hash, scopes string
+		var expiresAt sql.NullString
+
+		if err := rows.Scan(
+			&key.Id,
+			&key.Name,
+			&hash,
+			&scopes,
+			&key.RateLimit,
+			&expiresAt,
+		); err != nil {
+			return err
+		}
+
+		if scopes != "" {
+			key.Scopes = strings.Split(scopes, ",")
+		}
+		if expiresAt.Valid {
+			parsed, err := db.ParseTimestamp(expiresAt.String)
+			if err != nil {
+				return err
+			}
+			key.ExpiresAt = &parsed
+		}
+		keys[hash] = &key
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.keys = keys
+	// Limiters are rebuilt lazily so changed limits take effect
+	store.limiters = make(map[int64]*rate.Limiter)
+	return nil
+}
+
+// Finds the key and records its use. Unknown and revoked keys are invalid.
+func (store *memoryStore) Authenticate(rawKey string) (*Key, error) {
+	store.lock.RLock()
+	key, ok := store.keys[cryptography.HashString(rawKey)]
+	store.lock.RUnlock()
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, ErrExpiredKey
+	}
+
+	store.touch(key.Id)
+	return key, nil
+}
+
+// Reports whether the key is still within its requests per minute.
+func (store *memoryStore) Allow(key *Key) bool {
+	store.lock.Lock()
+	limiter, ok := store.limiters[key.Id]
+	if !ok {
+		perMinute := key.RateLimit
+		if perMinute <= 0 {
+			perMinute = DefaultRateLimit
+		}
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+		store.limiters[key.Id] = limiter
+	}
+	store.lock.Unlock()
+
+	return limiter.Allow()
+}
+
+func (store *memoryStore) touch(keyId int64) {
+	store.touchLock.Lock()
+	now := time.Now()
+	if now.Sub(store.lastTouch[keyId]) < touchInterval {
+		store.touchLock.Unlock()
+		return
+	}
+	store.lastTouch[keyId] = now
+	store.touchLock.Unlock()
+
+	go func() {
+		if _, err := store.database.Exec(QueryTouchKey, keyId); err != nil {
+			log.Printf("Failed to update last use of API key %d: %v", keyId, err)
+		}
+	}()
+}
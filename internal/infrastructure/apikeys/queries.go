@@ -0,0 +1,12 @@
+package apikeys
+
+const (
+	QueryGetActiveKeys = `
+	SELECT id, name, key_hash, scopes, rate_limit, expires_at
+	FROM api_keys
+	WHERE revoked_at IS NULL;`
+	QueryTouchKey = `
+	UPDATE api_keys
+	SET last_used_at = CURRENT_TIMESTAMP
+	WHERE id = ?;`
+)
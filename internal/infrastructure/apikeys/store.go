@@ -0,0 +1,61 @@
+package apikeys
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+// Scopes that can be granted to an API key
+const (
+	ScopePostsRead      = "posts:read"
+	ScopeViewsWrite     = "views:write"
+	ScopeCategoriesRead = "categories:read"
+	ScopeTagsRead       = "tags:read"
+	ScopeAuthorsRead    = "authors:read"
+	ScopeKeyValuesRead  = "keyvalues:read"
+)
+
+var AllScopes = []string{
+	ScopePostsRead,
+	ScopeViewsWrite,
+	ScopeCategoriesRead,
+	ScopeTagsRead,
+	ScopeAuthorsRead,
+	ScopeKeyValuesRead,
+}
+
+const (
+	// Prefix of generated keys, makes leaked keys easy to recognize
+	KeyPrefix = "bgk_"
+	// Requests per minute when a key has no own limit
+	DefaultRateLimit = 600
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid api key")
+	ErrExpiredKey = errors.New("api key has expired")
+)
+
+// Key is an active API key as seen by the middleware
+type Key struct {
+	Id        int64
+	Name      string
+	Scopes    []string
+	RateLimit int
+	ExpiresAt *time.Time
+}
+
+func (key *Key) HasScope(scope string) bool {
+	return slices.Contains(key.Scopes, scope)
+}
+
+// Store keeps active API keys in memory, indexed by the hash of the key.
+type Store interface {
+	Load(db *sql.DB) error
+	Authenticate(rawKey string) (*Key, error)
+	Allow(key *Key) bool
+}
+
+type keyStore = map[string]*Key
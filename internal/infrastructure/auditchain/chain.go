@@ -0,0 +1,268 @@
+// Package auditchain writes audit log entries as a hash chain. Every entry
+// stores the hash of the one before it and its own hash over its canonical
+// fields, so an entry edited or deleted directly in the database breaks the
+// link to the next one. Signed checkpoints in the key-value store pin the
+// head of the chain, so rewriting the chain from some entry on is caught
+// too.
+package auditchain
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Record is an audit entry to append, metadata is stored as given. OutboxID
+// is the outbox event the entry is written for, zero when there is none.
+type Record struct {
+	UserID     *int64
+	EntityType string
+	EntityID   int64
+	Action     string
+	Metadata   *string
+	IPAddress  *string
+	UserAgent  *string
+	RequestID  *string
+	OutboxID   int64
+}
+
+type Chain struct {
+	database *sql.DB
+	secret   []byte
+}
+
+var (
+	once     sync.Once
+	instance *Chain
+)
+
+func Get() *Chain {
+	once.Do(func() {
+		instance = New(db.Get(), config.Get().AuditCheckpointSecret)
+	})
+	return instance
+}
+
+func New(database *sql.DB, secret string) *Chain {
+	return &Chain{
+		database: database,
+		secret:   []byte(secret),
+	}
+}
+
+// An entry as stored, the fields its hash is computed over
+type entry struct {
+	id         int64
+	userID     *int64
+	entityType string
+	entityID   int64
+	action     string
+	metadata   *string
+	ipAddress  *string
+	userAgent  *string
+	requestID  *string
+	createdAt  string
+	prevHash   *string
+	hash       *string
+}
+
+// Hashes the fields as a JSON array so no value can run into the next one
+func (entry *entry) computeHash(prevHash string) string {
+	canonical, _ := json.Marshal([]interface{}{
+		entry.id,
+		entry.createdAt,
+		entry.userID,
+		entry.entityType,
+		entry.entityID,
+		entry.action,
+		entry.metadata,
+		entry.ipAddress,
+		entry.userAgent,
+		entry.requestID,
+		prevHash,
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Append writes the entry after the current head of the chain. The
+// transaction holds the write lock from the start, so two entries never
+// link to the same head. An entry for an outbox event that already has one
+// is skipped, the event was delivered again. The outbox id is not hashed, it
+// only guards against those duplicates.
+func (chain *Chain) Append(record Record) error {
+	tx, err := chain.database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if record.OutboxID != 0 {
+		var written int
+		if err := tx.QueryRow(QueryCountByOutboxID, record.OutboxID).Scan(&written); err != nil {
+			return err
+		}
+		if written > 0 {
+			return nil
+		}
+	}
+
+	prevHash, err := headHash(tx)
+	if err != nil {
+		return err
+	}
+
+	created := entry{
+		userID:     record.UserID,
+		entityType: record.EntityType,
+		entityID:   record.EntityID,
+		action:     record.Action,
+		metadata:   record.Metadata,
+		ipAddress:  record.IPAddress,
+		userAgent:  record.UserAgent,
+		requestID:  record.RequestID,
+		createdAt:  db.FormatTimestamp(time.Now()),
+	}
+
+	result, err := tx.Exec(
+		QueryInsertEntry,
+		created.userID,
+		created.entityType,
+		created.entityID,
+		created.action,
+		created.metadata,
+		created.ipAddress,
+		created.userAgent,
+		created.requestID,
+		created.createdAt,
+		nullIfZero(record.OutboxID),
+	)
+	if err != nil {
+		return err
+	}
+	if created.id, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	// The hash covers the id, which is only known after the insert
+	if _, err := tx.Exec(
+		QuerySetHash,
+		nullIfEmpty(prevHash),
+		created.computeHash(prevHash),
+		created.id,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Seal chains the entries written before the chain existed. It only runs
+// while no entry has a hash, afterwards an entry without one is reported
+// by Verify instead of being sealed over.
+func (chain *Chain) Seal() error {
+	tx, err := chain.database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hashed int
+	if err := tx.QueryRow(QueryCountHashed).Scan(&hashed); err != nil {
+		return err
+	}
+	if hashed > 0 {
+		return nil
+	}
+
+	entries, err := readEntries(tx)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	prevHash := ""
+	for _, sealed := range entries {
+		hash := sealed.computeHash(prevHash)
+		if _, err := tx.Exec(QuerySetHash, nullIfEmpty(prevHash), hash, sealed.id); err != nil {
+			return err
+		}
+		prevHash = hash
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Sealed %d existing audit log entries into the hash chain", len(entries))
+	return nil
+}
+
+// Hash of the newest entry, empty when the log is empty
+func headHash(executor db.Executor) (string, error) {
+	var id int64
+	var hash sql.NullString
+	err := executor.QueryRow(QueryGetHead).Scan(&id, &hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash.String, err
+}
+
+func readEntries(executor db.Executor) ([]entry, error) {
+	rows, err := executor.Query(QueryGetEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []entry{}
+	for rows.Next() {
+		var read entry
+		if err := scanEntry(rows, &read); err != nil {
+			return nil, err
+		}
+		entries = append(entries, read)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanEntry(rows *sql.Rows, read *entry) error {
+	return rows.Scan(
+		&read.id,
+		&read.userID,
+		&read.entityType,
+		&read.entityID,
+		&read.action,
+		&read.metadata,
+		&read.ipAddress,
+		&read.userAgent,
+		&read.requestID,
+		&read.createdAt,
+		&read.prevHash,
+		&read.hash,
+	)
+}
+
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func nullIfZero(value int64) *int64 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
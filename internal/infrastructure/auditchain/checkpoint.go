@@ -0,0 +1,179 @@
+package auditchain
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/background"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Checkpoints are stored in the key-value store under this prefix, the
+// key-value module keeps its hands off them
+const CheckpointKeyPrefix = "audit.checkpoint."
+
+// The newest checkpoint covers every entry before it, the older ones only
+// let Verify notice checkpoints deleted between them
+const checkpointsKept = 24
+
+// Checkpoint pins the head of the chain at the time it was taken and names
+// the checkpoint before it, so one deleted from between the others shows
+// as a gap. The signature is made with AUDIT_CHECKPOINT_SECRET, which is
+// only kept in the environment, so it cannot be forged with access to the
+// database alone. Deleting the newest checkpoints together with the entries
+// after the newest one left cannot be told apart from a log that ended
+// there, the head hash must be kept outside the database to catch that.
+type Checkpoint struct {
+	EntryID         int64  `json:"entryId"`
+	PreviousEntryID int64  `json:"previousEntryId"`
+	Hash            string `json:"hash"`
+	Entries         int    `json:"entries"`
+	CreatedAt       string `json:"createdAt"`
+	Signature       string `json:"signature"`
+}
+
+// Zero padded so the keys sort by entry
+func checkpointKey(entryID int64) string {
+	return fmt.Sprintf("%s%020d", CheckpointKeyPrefix, entryID)
+}
+
+func (chain *Chain) sign(checkpoint Checkpoint) string {
+	canonical, _ := json.Marshal([]interface{}{
+		checkpoint.EntryID,
+		checkpoint.PreviousEntryID,
+		checkpoint.Hash,
+		checkpoint.Entries,
+		checkpoint.CreatedAt,
+	})
+	mac := hmac.New(sha256.New, chain.secret)
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckpointsEnabled tells whether a secret to sign checkpoints is set
+func (chain *Chain) CheckpointsEnabled() bool {
+	return len(chain.secret) > 0
+}
+
+// Returns the checkpoints with a valid signature, and the entries of the
+// ones whose signature or key does not match. Without a secret nothing can
+// be checked, so none are returned.
+func (chain *Chain) readCheckpoints() ([]Checkpoint, []int64, error) {
+	if !chain.CheckpointsEnabled() {
+		return []Checkpoint{}, []int64{}, nil
+	}
+
+	rows, err := chain.database.Query(QueryGetCheckpoints)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := []Checkpoint{}
+	forged := []int64{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, nil, err
+		}
+
+		var checkpoint Checkpoint
+		if err := json.Unmarshal([]byte(value), &checkpoint); err != nil {
+			forged = append(forged, entryOfKey(key))
+			continue
+		}
+		valid := hmac.Equal(
+			[]byte(checkpoint.Signature),
+			[]byte(chain.sign(checkpoint)),
+		)
+		if !valid || key != checkpointKey(checkpoint.EntryID) {
+			forged = append(forged, checkpoint.EntryID)
+			continue
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, forged, rows.Err()
+}
+
+func entryOfKey(key string) int64 {
+	var entryID int64
+	fmt.Sscanf(strings.TrimPrefix(key, CheckpointKeyPrefix), "%d", &entryID)
+	return entryID
+}
+
+// Checkpoint verifies the chain and signs its head when it moved since the
+// last checkpoint. A broken chain is never checkpointed.
+func (chain *Chain) Checkpoint() error {
+	result, err := chain.Verify()
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return fmt.Errorf(
+			"audit log chain is broken at entry %d: %s",
+			result.BrokenLink.EntryID,
+			result.BrokenLink.Reason,
+		)
+	}
+	if result.HeadID == nil {
+		return nil
+	}
+	if result.LatestCheckpoint != nil && result.LatestCheckpoint.EntryID == *result.HeadID {
+		return nil
+	}
+
+	checkpoint := Checkpoint{
+		EntryID:   *result.HeadID,
+		Hash:      *result.HeadHash,
+		Entries:   result.Entries,
+		CreatedAt: db.FormatTimestamp(time.Now()),
+	}
+	if result.LatestCheckpoint != nil {
+		checkpoint.PreviousEntryID = result.LatestCheckpoint.EntryID
+	}
+	checkpoint.Signature = chain.sign(checkpoint)
+
+	value, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if _, err := chain.database.Exec(QueryInsertCheckpoint, checkpointKey(checkpoint.EntryID), string(value)); err != nil {
+		return err
+	}
+
+	_, err = chain.database.Exec(QueryPruneCheckpoints, checkpointsKept)
+	return err
+}
+
+// StartCheckpoints takes a checkpoint now and then once every interval
+// until shutdown begins
+func (chain *Chain) StartCheckpoints(interval time.Duration) {
+	if !chain.CheckpointsEnabled() {
+		log.Println("Warning: AUDIT_CHECKPOINT_SECRET is not set, audit log checkpoints are disabled and entries deleted from the end of the log go unnoticed")
+		return
+	}
+
+	background.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := chain.Checkpoint(); err != nil {
+				log.Printf("Failed to checkpoint the audit log: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-background.Done():
+				return
+			}
+		}
+	})
+}
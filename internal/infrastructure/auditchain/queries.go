@@ -0,0 +1,54 @@
+package auditchain
+
+const (
+	QueryGetHead = `
+	SELECT id, hash
+	FROM audit_logs
+	ORDER BY id DESC
+	LIMIT 1;`
+	QueryCountByOutboxID = `
+	SELECT COUNT(*)
+	FROM audit_logs
+	WHERE outbox_id = ?;`
+	QueryInsertEntry = `
+	INSERT INTO audit_logs (
+		user_id, entity_type, entity_id, action, metadata,
+		ip_address, user_agent, request_id, created_at, outbox_id
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	QuerySetHash = `
+	UPDATE audit_logs
+	SET prev_hash = ?, hash = ?
+	WHERE id = ?;`
+	QueryCountHashed = `
+	SELECT COUNT(*)
+	FROM audit_logs
+	WHERE hash IS NOT NULL;`
+	// created_at is read as written, the driver would reformat a timestamp
+	QueryGetEntries = `
+	SELECT
+		id, user_id, entity_type, entity_id, action, metadata,
+		ip_address, user_agent, request_id, CAST(created_at AS TEXT),
+		prev_hash, hash
+	FROM audit_logs
+	ORDER BY id ASC;`
+
+	QueryGetCheckpoints = `
+	SELECT key, value
+	FROM key_value_store
+	WHERE key GLOB 'audit.checkpoint.*'
+	ORDER BY key ASC;`
+	QueryInsertCheckpoint = `
+	INSERT INTO key_value_store (key, value, created_at, updated_at)
+	VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT(key) DO NOTHING;`
+	QueryPruneCheckpoints = `
+	DELETE FROM key_value_store
+	WHERE key GLOB 'audit.checkpoint.*'
+	AND key NOT IN (
+		SELECT key
+		FROM key_value_store
+		WHERE key GLOB 'audit.checkpoint.*'
+		ORDER BY key DESC
+		LIMIT ?
+	);`
+)
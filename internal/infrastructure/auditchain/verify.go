@@ -0,0 +1,147 @@
+package auditchain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BrokenLink is the first entry the chain does not hold together at
+type BrokenLink struct {
+	EntryID int64  `json:"entryId"`
+	Reason  string `json:"reason"`
+}
+
+// Result describes a walk over the whole chain. Checkpoints are neither
+// taken nor checked while no secret is set.
+type Result struct {
+	Valid              bool        `json:"valid"`
+	Entries            int         `json:"entries"`
+	HeadID             *int64      `json:"headId"`
+	HeadHash           *string     `json:"headHash"`
+	Checkpoints        int         `json:"checkpoints"`
+	CheckpointsEnabled bool        `json:"checkpointsEnabled"`
+	LatestCheckpoint   *Checkpoint `json:"latestCheckpoint"`
+	BrokenLink         *BrokenLink `json:"brokenLink"`
+}
+
+// Verify walks the chain from the first entry and stops at the first link
+// that does not hold: an entry without a hash, one that does not point at
+// the entry before it, one whose fields no longer match its hash, or one
+// that differs from a signed checkpoint. Checkpointed entries that are gone,
+// checkpoints deleted between others and checkpoints with a wrong signature
+// are reported after the walk.
+func (chain *Chain) Verify() (Result, error) {
+	result := Result{CheckpointsEnabled: chain.CheckpointsEnabled()}
+
+	checkpoints, forged, err := chain.readCheckpoints()
+	if err != nil {
+		return result, err
+	}
+	pending := make(map[int64]Checkpoint, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		pending[checkpoint.EntryID] = checkpoint
+	}
+
+	rows, err := chain.database.Query(QueryGetEntries)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	prevHash := ""
+	var prevID int64
+	for rows.Next() {
+		var read entry
+		if err := scanEntry(rows, &read); err != nil {
+			return result, err
+		}
+		result.Entries++
+
+		if reason := read.check(prevHash, prevID); reason != "" {
+			result.BrokenLink = &BrokenLink{EntryID: read.id, Reason: reason}
+			return result, nil
+		}
+
+		if checkpoint, ok := pending[read.id]; ok {
+			if checkpoint.Hash != *read.hash || checkpoint.Entries != result.Entries {
+				result.BrokenLink = &BrokenLink{
+					EntryID: read.id,
+					Reason:  fmt.Sprintf("entry does not match the checkpoint taken at %s", checkpoint.CreatedAt),
+				}
+				return result, nil
+			}
+			delete(pending, read.id)
+			result.Checkpoints++
+			verified := checkpoint
+			result.LatestCheckpoint = &verified
+		}
+
+		id, hash := read.id, *read.hash
+		result.HeadID, result.HeadHash = &id, &hash
+		prevHash, prevID = hash, read.id
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	// A checkpoint past the head means the newest entries were deleted
+	if len(pending) > 0 {
+		missing := make([]int64, 0, len(pending))
+		for entryID := range pending {
+			missing = append(missing, entryID)
+		}
+		sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+		result.BrokenLink = &BrokenLink{
+			EntryID: missing[0],
+			Reason:  "entry is covered by a checkpoint but missing, entries were deleted",
+		}
+		return result, nil
+	}
+
+	if len(forged) > 0 {
+		result.BrokenLink = &BrokenLink{
+			EntryID: forged[0],
+			Reason:  "checkpoint of entry has an invalid signature",
+		}
+		return result, nil
+	}
+
+	// The oldest checkpoint kept may name a pruned one, the others must
+	// name the checkpoint before them
+	for i := 1; i < len(checkpoints); i++ {
+		if checkpoints[i].PreviousEntryID != checkpoints[i-1].EntryID {
+			result.BrokenLink = &BrokenLink{
+				EntryID: checkpoints[i].PreviousEntryID,
+				Reason:  fmt.Sprintf("checkpoint of entry was deleted, the checkpoint of entry %d follows it", checkpoints[i].EntryID),
+			}
+			return result, nil
+		}
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// Tells why the entry does not continue the chain, empty when it does
+func (entry *entry) check(prevHash string, prevID int64) string {
+	if entry.hash == nil {
+		return "entry has no hash, it was written around the chain"
+	}
+
+	linked := ""
+	if entry.prevHash != nil {
+		linked = *entry.prevHash
+	}
+	if linked != prevHash {
+		if prevID == 0 {
+			return "entry does not start the chain, the entries before it were deleted"
+		}
+		return fmt.Sprintf("entry does not link to entry %d, entries between them were deleted or edited", prevID)
+	}
+
+	if entry.computeHash(prevHash) != *entry.hash {
+		return "entry was modified after it was written"
+	}
+
+	return ""
+}
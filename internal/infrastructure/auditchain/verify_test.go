@@ -0,0 +1,264 @@
+package auditchain
+
+import (
+	"bloggo/internal/db"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSecret = "checkpoint-secret-for-the-tests-only"
+
+// Opens an empty database with the application's schema
+func newTestChain(t *testing.T, secret string) (*Chain, *sql.DB) {
+	t.Helper()
+
+	database, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "audit.sqlite")+"?_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if err := db.InitializeTables(database); err != nil {
+		t.Fatal(err)
+	}
+	return New(database, secret), database
+}
+
+func appendEntries(t *testing.T, chain *Chain, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		if err := chain.Append(Record{EntityType: "post", EntityID: int64(i + 1), Action: "created"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkpoint(t *testing.T, chain *Chain) {
+	t.Helper()
+
+	if err := chain.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exec(t *testing.T, database *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+
+	if _, err := database.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// Writes the log and tampers with it
+		setup       func(t *testing.T, chain *Chain, database *sql.DB)
+		valid       bool
+		brokenEntry int64
+		reason      string
+	}{
+		{
+			name: "intact",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				checkpoint(t, chain)
+				appendEntries(t, chain, 2)
+			},
+			valid: true,
+		},
+		{
+			name: "edited entry",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 5)
+				exec(t, database, "UPDATE audit_logs SET action = 'deleted' WHERE id = 3")
+			},
+			brokenEntry: 3,
+			reason:      "modified",
+		},
+		{
+			name: "edited entry with its hash recomputed",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 5)
+				exec(t, database, "UPDATE audit_logs SET action = 'deleted' WHERE id = 3")
+				entries, err := readEntries(database)
+				if err != nil {
+					t.Fatal(err)
+				}
+				edited := entries[2]
+				exec(t, database, "UPDATE audit_logs SET hash = ? WHERE id = 3", edited.computeHash(*edited.prevHash))
+			},
+			brokenEntry: 4,
+			reason:      "does not link to entry 3",
+		},
+		{
+			name: "deleted middle entry",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 5)
+				exec(t, database, "DELETE FROM audit_logs WHERE id = 3")
+			},
+			brokenEntry: 4,
+			reason:      "does not link to entry 2",
+		},
+		{
+			name: "deleted first entry",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				exec(t, database, "DELETE FROM audit_logs WHERE id = 1")
+			},
+			brokenEntry: 2,
+			reason:      "does not start the chain",
+		},
+		{
+			name: "entry written around the chain",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 2)
+				exec(t, database, "INSERT INTO audit_logs (entity_type, entity_id, action) VALUES ('post', 9, 'deleted')")
+			},
+			brokenEntry: 3,
+			reason:      "no hash",
+		},
+		{
+			name: "deleted tail covered by a checkpoint",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 5)
+				checkpoint(t, chain)
+				exec(t, database, "DELETE FROM audit_logs WHERE id >= 4")
+			},
+			brokenEntry: 5,
+			reason:      "missing",
+		},
+		{
+			name: "deleted tail after the last checkpoint goes unnoticed",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				checkpoint(t, chain)
+				appendEntries(t, chain, 2)
+				exec(t, database, "DELETE FROM audit_logs WHERE id >= 4")
+			},
+			valid: true,
+		},
+		{
+			name: "rewritten chain after a checkpoint",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				checkpoint(t, chain)
+				// The entries are rewritten and chained again from scratch,
+				// so every link holds but the checkpoint no longer matches
+				exec(t, database, "UPDATE audit_logs SET action = 'deleted', prev_hash = NULL, hash = NULL")
+				entries, err := readEntries(database)
+				if err != nil {
+					t.Fatal(err)
+				}
+				prevHash := ""
+				for _, rewritten := range entries {
+					hash := rewritten.computeHash(prevHash)
+					exec(t, database, "UPDATE audit_logs SET prev_hash = ?, hash = ? WHERE id = ?", nullIfEmpty(prevHash), hash, rewritten.id)
+					prevHash = hash
+				}
+			},
+			brokenEntry: 3,
+			reason:      "does not match the checkpoint",
+		},
+		{
+			name: "forged checkpoint",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				checkpoint(t, chain)
+				exec(t, database, "UPDATE key_value_store SET value = replace(value, '\"entries\":3', '\"entries\":2') WHERE key = ?", checkpointKey(3))
+			},
+			brokenEntry: 3,
+			reason:      "invalid signature",
+		},
+		{
+			name: "checkpoint signed with another secret",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				appendEntries(t, chain, 3)
+				if err := New(database, "an-attacker-guessing-the-secret!").Checkpoint(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			brokenEntry: 3,
+			reason:      "invalid signature",
+		},
+		{
+			name: "deleted checkpoint between others",
+			setup: func(t *testing.T, chain *Chain, database *sql.DB) {
+				for i := 0; i < 3; i++ {
+					appendEntries(t, chain, 2)
+					checkpoint(t, chain)
+				}
+				exec(t, database, "DELETE FROM key_value_store WHERE key = ?", checkpointKey(4))
+			},
+			brokenEntry: 4,
+			reason:      "checkpoint of entry was deleted",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, database := newTestChain(t, testSecret)
+			test.setup(t, chain, database)
+
+			result, err := chain.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Valid != test.valid {
+				t.Fatalf("valid = %v, want %v (broken link %+v)", result.Valid, test.valid, result.BrokenLink)
+			}
+			if test.valid {
+				if result.BrokenLink != nil {
+					t.Errorf("broken link %+v on a valid chain", result.BrokenLink)
+				}
+				return
+			}
+			if result.BrokenLink == nil {
+				t.Fatal("no broken link reported")
+			}
+			if result.BrokenLink.EntryID != test.brokenEntry {
+				t.Errorf("broken at entry %d, want %d (%s)", result.BrokenLink.EntryID, test.brokenEntry, result.BrokenLink.Reason)
+			}
+			if !strings.Contains(result.BrokenLink.Reason, test.reason) {
+				t.Errorf("reason %q does not mention %q", result.BrokenLink.Reason, test.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutSecretSkipsCheckpoints(t *testing.T) {
+	chain, database := newTestChain(t, testSecret)
+	appendEntries(t, chain, 3)
+	checkpoint(t, chain)
+	exec(t, database, "DELETE FROM audit_logs WHERE id = 3")
+
+	result, err := New(database, "").Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.CheckpointsEnabled || result.Checkpoints != 0 {
+		t.Errorf("result = %+v, want a valid chain with checkpoints disabled", result)
+	}
+}
+
+func TestAppendSkipsRedeliveredOutboxEvent(t *testing.T) {
+	chain, database := newTestChain(t, testSecret)
+	record := Record{EntityType: "post", EntityID: 1, Action: "created", OutboxID: 42}
+	for i := 0; i < 2; i++ {
+		if err := chain.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	if err := database.QueryRow("SELECT COUNT(*) FROM audit_logs").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("entries = %d, want 1", count)
+	}
+}
@@ -0,0 +1,68 @@
+package background
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracker keeps count of work started outside of a request, such as webhook
+// deliveries, so shutdown can wait for it instead of cutting it off.
+type Tracker struct {
+	group  sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var (
+	once     sync.Once
+	instance *Tracker
+)
+
+func Get() *Tracker {
+	once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		instance = &Tracker{
+			ctx:    ctx,
+			cancel: cancel,
+		}
+	})
+	return instance
+}
+
+// Go runs the task in a tracked goroutine
+func Go(task func()) {
+	Get().Go(task)
+}
+
+// Done is closed once shutdown begins. Tasks that wait, such as retry
+// backoffs, should stop waiting and record where they stopped.
+func Done() <-chan struct{} {
+	return Get().ctx.Done()
+}
+
+func (tracker *Tracker) Go(task func()) {
+	tracker.group.Add(1)
+	go func() {
+		defer tracker.group.Done()
+		task()
+	}()
+}
+
+// Shutdown signals the tasks to wrap up and waits for them until the
+// context ends.
+func (tracker *Tracker) Shutdown(ctx context.Context) error {
+	tracker.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		tracker.group.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
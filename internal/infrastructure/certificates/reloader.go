@@ -0,0 +1,115 @@
+package certificates
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// How often the files are checked for changes, at most once per handshake
+const checkInterval = 10 * time.Second
+
+// Reloader serves a certificate pair from disk and picks up renewed files
+// without a restart. A pair that fails to load is logged and the previous
+// one keeps being served.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	modTime     time.Time
+	checkedAt   time.Time
+}
+
+// NewReloader loads the pair once so bad files fail at startup
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	reloader := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := reloader.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(modTime); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// GetCertificate is used as tls.Config.GetCertificate
+func (reloader *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.RLock()
+	due := time.Since(reloader.checkedAt) >= checkInterval
+	certificate := reloader.certificate
+	reloader.mutex.RUnlock()
+
+	if due {
+		reloader.reloadIfChanged()
+		reloader.mutex.RLock()
+		certificate = reloader.certificate
+		reloader.mutex.RUnlock()
+	}
+	return certificate, nil
+}
+
+func (reloader *Reloader) reloadIfChanged() {
+	reloader.mutex.Lock()
+	// Another handshake may have checked in the meantime
+	if time.Since(reloader.checkedAt) < checkInterval {
+		reloader.mutex.Unlock()
+		return
+	}
+	reloader.checkedAt = time.Now()
+	previous := reloader.modTime
+	reloader.mutex.Unlock()
+
+	modTime, err := reloader.latestModTime()
+	if err != nil {
+		log.Printf("Failed to check TLS certificate files: %v", err)
+		return
+	}
+	if !modTime.After(previous) {
+		return
+	}
+
+	if err := reloader.load(modTime); err != nil {
+		log.Printf("Failed to reload TLS certificate, keeping the previous one: %v", err)
+		return
+	}
+	log.Printf("Reloaded TLS certificate from %s", reloader.certFile)
+}
+
+func (reloader *Reloader) load(modTime time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.certificate = &certificate
+	reloader.modTime = modTime
+	reloader.checkedAt = time.Now()
+	return nil
+}
+
+// Renewals usually replace both files, the later change wins
+func (reloader *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{reloader.certFile, reloader.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,92 @@
+// Login challenge store implemented to use in-memory
+
+package challenges
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	challenges challengeStore
+	lock       sync.RWMutex
+}
+
+var (
+	once     sync.Once
+	instance Store
+)
+
+func GetStore() Store {
+	once.Do(func() {
+		instance = newMemoryStore()
+	})
+	return instance
+}
+
+func newMemoryStore() Store {
+	return &memoryStore{
+		challenges: make(challengeStore),
+	}
+}
+
+func (store *memoryStore) Set(
+	token string,
+	userId int64,
+	duration int,
+) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	// Drop expired challenges while we hold the lock
+	now := time.Now()
+	for key, data := range store.challenges {
+		if now.After(data.expiresAt) {
+			delete(store.challenges, key)
+		}
+	}
+
+	store.challenges[token] = challengeData{
+		userId:    userId,
+		expiresAt: now.Add(time.Duration(duration) * time.Second),
+	}
+}
+
+func (store *memoryStore) Get(token string) (int64, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	data, exists := store.challenges[token]
+	if !exists || time.Now().After(data.expiresAt) {
+		return 0, false
+	}
+
+	return data.userId, true
+}
+
+// Records a wrong code and discards the challenge when attempts run out.
+func (store *memoryStore) Fail(token string) int {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	data, exists := store.challenges[token]
+	if !exists {
+		return 0
+	}
+
+	data.attempts++
+	if data.attempts >= MaxAttempts {
+		delete(store.challenges, token)
+		return 0
+	}
+
+	store.challenges[token] = data
+	return MaxAttempts - data.attempts
+}
+
+func (store *memoryStore) Delete(token string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	delete(store.challenges, token)
+}
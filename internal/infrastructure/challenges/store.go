@@ -0,0 +1,24 @@
+package challenges
+
+import (
+	"time"
+)
+
+// Store pending login challenges that wait for a second factor
+type Store interface {
+	Set(token string, userId int64, duration int)
+	Get(token string) (userId int64, found bool)
+	Fail(token string) (remaining int)
+	Delete(token string)
+}
+
+// Wrong codes accepted before a challenge is discarded
+const MaxAttempts = 5
+
+type challengeData struct {
+	userId    int64
+	attempts  int
+	expiresAt time.Time
+}
+
+type challengeStore = map[string]challengeData
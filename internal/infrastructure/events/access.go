@@ -0,0 +1,134 @@
+package events
+
+import "bloggo/internal/utils/auditcontext"
+
+// Events of roles, credentials and signing keys
+
+type RoleCreated struct {
+	ActorID     int64
+	Context     auditcontext.Context
+	RoleID      int64
+	Name        string
+	Permissions []string
+}
+
+// Before and After hold the changed fields of the role, its name or
+// whether it requires two-factor
+type RoleUpdated struct {
+	ActorID int64
+	Context auditcontext.Context
+	RoleID  int64
+	Before  map[string]interface{}
+	After   map[string]interface{}
+}
+
+type RolePermissionsGranted struct {
+	ActorID     int64
+	Context     auditcontext.Context
+	RoleID      int64
+	Permissions []string
+}
+
+type RolePermissionRevoked struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	RoleID     int64
+	Permission string
+}
+
+type RoleDeleted struct {
+	ActorID int64
+	Context auditcontext.Context
+	RoleID  int64
+	Before  map[string]interface{}
+}
+
+type APIKeyCreated struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   int64
+}
+
+type APIKeyUpdated struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   int64
+	Before  map[string]interface{}
+	After   map[string]interface{}
+}
+
+type APIKeyRevoked struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   int64
+	Before  map[string]interface{}
+	After   map[string]interface{}
+}
+
+type AccessTokenCreated struct {
+	ActorID int64
+	Context auditcontext.Context
+	TokenID int64
+}
+
+type AccessTokenRevoked struct {
+	ActorID int64
+	Context auditcontext.Context
+	TokenID int64
+}
+
+// Recorded at most once a minute per token
+type AccessTokenUsed struct {
+	UserID  int64
+	Context auditcontext.Context
+	TokenID int64
+}
+
+type TwoFactorEnabled struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// A code that did not verify, no change comes with it
+type TwoFactorFailed struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// The user turned two-factor off with a valid code
+type TwoFactorDisabled struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// The user replaced their remaining recovery codes with new ones
+type TwoFactorRecoveryCodesRegenerated struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// Someone else removed the user's two-factor, e.g. after a lost device
+type TwoFactorReset struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+}
+
+// Key ids of the keyring are not numeric
+type SigningKeyGenerated struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   string
+}
+
+type SigningKeyPromoted struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   string
+}
+
+type SigningKeyRetired struct {
+	ActorID int64
+	Context auditcontext.Context
+	KeyID   string
+}
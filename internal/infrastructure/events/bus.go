@@ -0,0 +1,225 @@
+// Package events holds the domain events and the subscribers they are
+// delivered to. Services record what happened through the outbox, and side
+// effects such as audit entries and webhooks subscribe to the events they
+// care about. Side effects that can be rebuilt at any time, such as caches,
+// listen in process instead.
+package events
+
+import (
+	"bloggo/internal/infrastructure/background"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Mode decides where a listener runs
+type Mode int
+
+const (
+	// Sync listeners run in order before Publish returns, for side effects
+	// that must be done by the time the request ends
+	Sync Mode = iota
+	// Async listeners run in tracked background goroutines, so shutdown
+	// waits for them
+	Async
+)
+
+type subscriber struct {
+	name   string
+	handle func(delivery Delivery, event interface{}) error
+}
+
+type listener struct {
+	name   string
+	mode   Mode
+	handle func(event interface{})
+}
+
+// Delivery is the outbox row an event is delivered from. A delivery retried
+// after a crash has the same ID, so a subscriber can tell it already handled
+// the event.
+type Delivery struct {
+	ID int64
+}
+
+// Bus knows the subscribers of each event type and the types by name, so
+// events stored in the outbox can be read back and delivered. Listeners are
+// handed the event in process once it is committed.
+type Bus struct {
+	mutex       sync.RWMutex
+	subscribers map[reflect.Type][]subscriber
+	listeners   map[reflect.Type][]listener
+	types       map[string]reflect.Type
+	runAsync    func(task func())
+}
+
+var (
+	once     sync.Once
+	instance *Bus
+)
+
+func Get() *Bus {
+	once.Do(func() {
+		instance = NewBus(background.Go)
+	})
+	return instance
+}
+
+// NewBus runs async listeners with runAsync. Tests can pass a function that
+// runs the task right away.
+func NewBus(runAsync func(task func())) *Bus {
+	return &Bus{
+		subscribers: map[reflect.Type][]subscriber{},
+		listeners:   map[reflect.Type][]listener{},
+		types:       map[string]reflect.Type{},
+		runAsync:    runAsync,
+	}
+}
+
+// Subscribe registers the handler for events of type T. The name identifies
+// the handler in the outbox and must be unique per event type. A handler
+// returning an error gets the event again later.
+func Subscribe[T any](bus *Bus, name string, handler func(event T) error) {
+	SubscribeDelivery(bus, name, func(delivery Delivery, event T) error {
+		return handler(event)
+	})
+}
+
+// SubscribeDelivery is Subscribe for handlers that need the delivery, to
+// skip an event they already handled
+func SubscribeDelivery[T any](bus *Bus, name string, handler func(delivery Delivery, event T) error) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	eventType := reflect.TypeFor[T]()
+	bus.types[TypeName(eventType)] = eventType
+	bus.subscribers[eventType] = append(bus.subscribers[eventType], subscriber{
+		name: name,
+		handle: func(delivery Delivery, event interface{}) error {
+			return handler(delivery, event.(T))
+		},
+	})
+}
+
+// Listen registers an in-process handler for events of type T. Listeners
+// are not recorded in the outbox, an event is lost to them when the process
+// stops, so they suit side effects such as cache reloads.
+func Listen[T any](bus *Bus, name string, mode Mode, handler func(event T)) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	eventType := reflect.TypeFor[T]()
+	bus.listeners[eventType] = append(bus.listeners[eventType], listener{
+		name: name,
+		mode: mode,
+		handle: func(event interface{}) {
+			handler(event.(T))
+		},
+	})
+}
+
+// TypeName is the name events of the type are stored under
+func TypeName(eventType reflect.Type) string {
+	return eventType.Name()
+}
+
+// Occurred returns the event with its OccurredAt field set to at. Events
+// without the field, or with it set already, are returned as they are.
+func Occurred(event interface{}, at time.Time) interface{} {
+	value := reflect.ValueOf(event)
+	if value.Kind() != reflect.Struct {
+		return event
+	}
+	field := value.FieldByName("OccurredAt")
+	if !field.IsValid() || field.Type() != reflect.TypeOf(at) || !field.IsZero() {
+		return event
+	}
+
+	stamped := reflect.New(value.Type()).Elem()
+	stamped.Set(value)
+	stamped.FieldByName("OccurredAt").Set(reflect.ValueOf(at))
+	return stamped.Interface()
+}
+
+// Subscribers returns the names of the handlers subscribed to the event
+func (bus *Bus) Subscribers(event interface{}) []string {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	names := []string{}
+	for _, subscriber := range bus.subscribers[reflect.TypeOf(event)] {
+		names = append(names, subscriber.name)
+	}
+	return names
+}
+
+// Decode reads back an event stored under the type name
+func (bus *Bus) Decode(typeName string, payload []byte) (interface{}, error) {
+	bus.mutex.RLock()
+	eventType, ok := bus.types[typeName]
+	bus.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %s", typeName)
+	}
+
+	event := reflect.New(eventType)
+	if err := json.Unmarshal(payload, event.Interface()); err != nil {
+		return nil, err
+	}
+	return event.Elem().Interface(), nil
+}
+
+// Deliver hands the event to the named subscriber. A panicking subscriber is
+// logged and reported as an error.
+func (bus *Bus) Deliver(name string, delivery Delivery, event interface{}) (err error) {
+	bus.mutex.RLock()
+	subscribers := bus.subscribers[reflect.TypeOf(event)]
+	bus.mutex.RUnlock()
+
+	for _, subscriber := range subscribers {
+		if subscriber.name != name {
+			continue
+		}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("Event subscriber %s panicked on %T: %v\n%s", name, event, recovered, debug.Stack())
+				err = fmt.Errorf("subscriber panicked: %v", recovered)
+			}
+		}()
+		return subscriber.handle(delivery, event)
+	}
+	return fmt.Errorf("no subscriber %s for %T", name, event)
+}
+
+// Publish hands a committed event to its listeners, sync ones in the order
+// they registered. A panicking listener is logged and reaches neither the
+// publisher nor the other listeners.
+func (bus *Bus) Publish(event interface{}) {
+	bus.mutex.RLock()
+	listeners := bus.listeners[reflect.TypeOf(event)]
+	bus.mutex.RUnlock()
+
+	for _, listener := range listeners {
+		if listener.mode == Async {
+			bus.runAsync(func() {
+				listener.deliver(event)
+			})
+			continue
+		}
+		listener.deliver(event)
+	}
+}
+
+func (listener listener) deliver(event interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Event listener %s panicked on %T: %v\n%s", listener.name, event, recovered, debug.Stack())
+		}
+	}()
+	listener.handle(event)
+}
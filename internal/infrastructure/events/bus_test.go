@@ -0,0 +1,156 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	ID   int64
+	Note *string
+}
+
+func runNow(task func()) {
+	task()
+}
+
+func TestDeliver(t *testing.T) {
+	bus := NewBus(runNow)
+	var delivered []int64
+	SubscribeDelivery(bus, "audit", func(delivery Delivery, event testEvent) error {
+		delivered = append(delivered, delivery.ID, event.ID)
+		return nil
+	})
+	failure := errors.New("sink unavailable")
+	Subscribe(bus, "webhook", func(event testEvent) error {
+		return failure
+	})
+
+	if got := bus.Subscribers(testEvent{}); !slices.Equal(got, []string{"audit", "webhook"}) {
+		t.Errorf("Subscribers() = %v", got)
+	}
+
+	if err := bus.Deliver("audit", Delivery{ID: 7}, testEvent{ID: 3}); err != nil {
+		t.Fatalf("Deliver() = %v", err)
+	}
+	if !slices.Equal(delivered, []int64{7, 3}) {
+		t.Errorf("delivered %v, want the delivery 7 of event 3", delivered)
+	}
+
+	if err := bus.Deliver("webhook", Delivery{ID: 8}, testEvent{ID: 3}); !errors.Is(err, failure) {
+		t.Errorf("Deliver() = %v, want the subscriber's error", err)
+	}
+	if err := bus.Deliver("search", Delivery{ID: 9}, testEvent{ID: 3}); err == nil {
+		t.Error("Deliver() to an unknown subscriber succeeded")
+	}
+	if len(delivered) != 2 {
+		t.Errorf("audit got the event %d times, want once", len(delivered)/2)
+	}
+}
+
+func TestDeliverRecoversPanics(t *testing.T) {
+	bus := NewBus(runNow)
+	Subscribe(bus, "audit", func(event testEvent) error {
+		panic("nil map")
+	})
+
+	if err := bus.Deliver("audit", Delivery{ID: 1}, testEvent{ID: 1}); err == nil {
+		t.Error("Deliver() of a panicking subscriber succeeded, the event would not be retried")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	bus := NewBus(runNow)
+	Subscribe(bus, "audit", func(event testEvent) error { return nil })
+
+	note := "looks good"
+	payload, err := json.Marshal(testEvent{ID: 5, Note: &note})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := bus.Decode(TypeName(reflect.TypeOf(testEvent{})), payload)
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	decoded, ok := event.(testEvent)
+	if !ok {
+		t.Fatalf("Decode() returned a %T, want a testEvent value", event)
+	}
+	if decoded.ID != 5 || decoded.Note == nil || *decoded.Note != note {
+		t.Errorf("Decode() = %+v", decoded)
+	}
+
+	if _, err := bus.Decode("Unknown", payload); err == nil {
+		t.Error("Decode() of an unknown type succeeded")
+	}
+	if _, err := bus.Decode(TypeName(reflect.TypeOf(testEvent{})), []byte("{")); err == nil {
+		t.Error("Decode() of a broken payload succeeded")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var queued []func()
+	bus := NewBus(func(task func()) {
+		queued = append(queued, task)
+	})
+
+	var calls []string
+	Listen(bus, "first", Sync, func(event testEvent) {
+		calls = append(calls, "first")
+	})
+	Listen(bus, "panicking", Sync, func(event testEvent) {
+		panic("nil map")
+	})
+	Listen(bus, "background", Async, func(event testEvent) {
+		calls = append(calls, "background")
+	})
+	Listen(bus, "last", Sync, func(event testEvent) {
+		calls = append(calls, "last")
+	})
+
+	bus.Publish(testEvent{ID: 1})
+
+	if !slices.Equal(calls, []string{"first", "last"}) {
+		t.Errorf("sync listeners ran as %v, want first and last in order", calls)
+	}
+	if len(queued) != 1 {
+		t.Fatalf("%d async tasks queued, want 1", len(queued))
+	}
+	queued[0]()
+	if calls[len(calls)-1] != "background" {
+		t.Errorf("async listener did not run, calls %v", calls)
+	}
+
+	// Listeners are not durable subscribers, the outbox writes no rows for them
+	if got := bus.Subscribers(testEvent{}); len(got) != 0 {
+		t.Errorf("Subscribers() = %v, want none", got)
+	}
+}
+
+func TestOccurred(t *testing.T) {
+	type stampedEvent struct {
+		ID         int64
+		OccurredAt time.Time
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	event := Occurred(stampedEvent{ID: 1}, at).(stampedEvent)
+	if event.ID != 1 || !event.OccurredAt.Equal(at) {
+		t.Errorf("Occurred() = %+v, want the event at %v", event, at)
+	}
+
+	// A redelivered event keeps the time it was recorded at
+	again := Occurred(event, at.Add(time.Hour)).(stampedEvent)
+	if !again.OccurredAt.Equal(at) {
+		t.Errorf("Occurred() moved the time to %v", again.OccurredAt)
+	}
+
+	if unstamped := Occurred(testEvent{ID: 2}, at).(testEvent); unstamped.ID != 2 {
+		t.Errorf("Occurred() = %+v", unstamped)
+	}
+}
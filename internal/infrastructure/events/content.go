@@ -0,0 +1,199 @@
+package events
+
+import (
+	"bloggo/internal/utils/auditcontext"
+	"time"
+)
+
+// Events of posts, categories, tags and settings. ActorID is the user who
+// made the change, Context where the request came from. Before and After
+// hold the audited fields of the entity around an update or delete.
+// OccurredAt is set by the outbox when the event is recorded.
+
+type PostCreated struct {
+	ActorID int64
+	Context auditcontext.Context
+	PostID  int64
+}
+
+// Slug is the published slug, nil when the post was never published
+type PostDeleted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	PostID     int64
+	Slug       *string
+	Before     map[string]interface{}
+	OccurredAt time.Time
+}
+
+// Categories are only set when the published category changed
+type PostPublished struct {
+	ActorID     int64
+	Context     auditcontext.Context
+	PostID      int64
+	VersionID   int64
+	Slug        string
+	OldSlug     *string
+	OldCategory *string
+	NewCategory *string
+	OccurredAt  time.Time
+}
+
+// Tags are given by slug. PublishedSlug is nil for unpublished posts.
+type PostTagsChanged struct {
+	PostID        int64
+	PublishedSlug *string
+	AddedTags     []string
+	RemovedTags   []string
+	OccurredAt    time.Time
+}
+
+// Before and After hold the audited fields of the version
+type VersionUpdated struct {
+	ActorID   int64
+	Context   auditcontext.Context
+	PostID    int64
+	VersionID int64
+	Before    map[string]interface{}
+	After     map[string]interface{}
+}
+
+type VersionDeleted struct {
+	ActorID   int64
+	Context   auditcontext.Context
+	VersionID int64
+	Before    map[string]interface{}
+}
+
+type VersionSubmitted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	PostID     int64
+	VersionID  int64
+	Slug       *string
+	OccurredAt time.Time
+}
+
+type VersionApproved struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	PostID     int64
+	VersionID  int64
+	Slug       *string
+	Note       *string
+	OccurredAt time.Time
+}
+
+type VersionRejected struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	PostID     int64
+	VersionID  int64
+	Slug       *string
+	Note       *string
+	OccurredAt time.Time
+}
+
+type CategoryCreated struct {
+	ActorID     int64
+	Context     auditcontext.Context
+	CategoryID  int64
+	Name        string
+	Slug        string
+	Spot        string
+	Description string
+	OccurredAt  time.Time
+}
+
+// Fields left empty were not changed. OldSlug is set when the slug changed.
+type CategoryUpdated struct {
+	ActorID     int64
+	Context     auditcontext.Context
+	CategoryID  int64
+	Slug        string
+	OldSlug     *string
+	Name        string
+	Spot        string
+	Description string
+	Before      map[string]interface{}
+	After       map[string]interface{}
+	OccurredAt  time.Time
+}
+
+type CategoryDeleted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	CategoryID int64
+	Slug       string
+	Before     map[string]interface{}
+	OccurredAt time.Time
+}
+
+type TagCreated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	TagID      int64
+	Name       string
+	Slug       string
+	OccurredAt time.Time
+}
+
+// Name is empty when it was not changed. OldSlug is set when the slug
+// changed.
+type TagUpdated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	TagID      int64
+	Slug       string
+	OldSlug    *string
+	Name       string
+	Before     map[string]interface{}
+	After      map[string]interface{}
+	OccurredAt time.Time
+}
+
+type TagDeleted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	TagID      int64
+	Slug       string
+	Before     map[string]interface{}
+	OccurredAt time.Time
+}
+
+type RemovalRequestCreated struct {
+	ActorID       int64
+	Context       auditcontext.Context
+	RequestID     int64
+	PostVersionID int64
+	Note          *string
+	OccurredAt    time.Time
+}
+
+// The post is deleted along with the approval, PostDeleted follows
+type RemovalRequestApproved struct {
+	ActorID       int64
+	Context       auditcontext.Context
+	RequestID     int64
+	PostVersionID int64
+	PostID        int64
+	Note          *string
+	OccurredAt    time.Time
+}
+
+type RemovalRequestRejected struct {
+	ActorID       int64
+	Context       auditcontext.Context
+	RequestID     int64
+	PostVersionID int64
+	Note          *string
+	OccurredAt    time.Time
+}
+
+type KeyValuesUpdated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	Values     map[string]interface{}
+	Before     map[string]interface{}
+	OccurredAt time.Time
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"bloggo/internal/utils/auditcontext"
+	"time"
+)
+
+// Events of users, who appear as authors to webhook receivers. OccurredAt
+// is set by the outbox when the event is recorded.
+
+// Invited users were created through an invitation and set their
+// passphrase later
+type UserCreated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	UserID     int64
+	Name       string
+	Email      string
+	Invited    bool
+	OccurredAt time.Time
+}
+
+// Changes holds the changed profile fields by their JSON name, a nil value
+// means the field was cleared. Before and After hold the values of the
+// fields the request set.
+type UserUpdated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	UserID     int64
+	Changes    map[string]interface{}
+	Before     map[string]interface{}
+	After      map[string]interface{}
+	OccurredAt time.Time
+}
+
+type UserDeleted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	UserID     int64
+	Before     map[string]interface{}
+	OccurredAt time.Time
+}
+
+type UserLoggedIn struct {
+	UserID     int64
+	Name       string
+	Role       string
+	Context    auditcontext.Context
+	OccurredAt time.Time
+}
+
+// UserID is nil when the email belongs to no user
+type UserLoginFailed struct {
+	UserID  *int64
+	Context auditcontext.Context
+	Email   string
+}
+
+// Account tells whether the account or the client's IP was locked, for
+// Duration seconds
+type UserLockedOut struct {
+	UserID   *int64
+	Context  auditcontext.Context
+	Email    string
+	Account  bool
+	Duration int
+}
+
+type UserLoggedOut struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// The user revoked one or more of their own sessions
+type UserSessionsRevoked struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// An admin revoked every session of the user
+type UserSessionsRevokedByAdmin struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+}
+
+type PasswordResetRequested struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+type PasswordReset struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+type InvitationResent struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+}
+
+type InvitationAccepted struct {
+	UserID  int64
+	Context auditcontext.Context
+}
+
+// Before and After hold the role id
+type UserRoleAssigned struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+	Before  map[string]interface{}
+	After   map[string]interface{}
+}
+
+type UserPassphraseChanged struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+}
+
+// The user's failed logins were cleared, lifting a lockout
+type UserUnlocked struct {
+	ActorID int64
+	Context auditcontext.Context
+	UserID  int64
+}
+
+type UserCategoryPermissionGranted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	UserID     int64
+	Permission string
+	CategoryID int64
+}
+
+type UserCategoryPermissionRevoked struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	UserID     int64
+	Permission string
+	CategoryID int64
+}
@@ -0,0 +1,51 @@
+package events
+
+import "bloggo/internal/utils/auditcontext"
+
+// Events of webhook endpoints and deliveries, receivers are not sent these
+
+type WebhookEndpointCreated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	EndpointID int64
+}
+
+// Before and After leave out secrets and header values
+type WebhookEndpointUpdated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	EndpointID int64
+	Before     map[string]interface{}
+	After      map[string]interface{}
+}
+
+type WebhookSecretRotated struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	EndpointID int64
+}
+
+type WebhookEndpointDeleted struct {
+	ActorID    int64
+	Context    auditcontext.Context
+	EndpointID int64
+	Before     map[string]interface{}
+}
+
+// A cms.sync event fired by hand
+type WebhookFired struct {
+	ActorID int64
+	Context auditcontext.Context
+}
+
+// RequestID is the new request queued for the redelivery
+type WebhookRequestRedelivered struct {
+	ActorID   int64
+	Context   auditcontext.Context
+	RequestID int64
+}
+
+type WebhookRequestsReplayed struct {
+	ActorID int64
+	Context auditcontext.Context
+}
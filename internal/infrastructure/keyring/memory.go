@@ -0,0 +1,218 @@
+package keyring
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/utils/cryptography"
+	"database/sql"
+	"encoding/base64"
+	"log"
+	"sync"
+)
+
+type storedKey struct {
+	info   Key
+	secret []byte
+}
+
+type memoryStore struct {
+	database  *sql.DB
+	envSecret string
+	keys      map[string]*storedKey
+	order     []string
+	signingId string
+	lock      sync.RWMutex
+}
+
+var (
+	once     sync.Once
+	instance Store
+)
+
+func Get() Store {
+	once.Do(func() {
+		instance = newMemoryStore(db.Get(), config.Get().JWTSecret)
+		if err := instance.Load(); err != nil {
+			log.Printf("Failed to load JWT signing keys: %v", err)
+		}
+	})
+	return instance
+}
+
+func newMemoryStore(database *sql.DB, envSecret string) *memoryStore {
+	return &memoryStore{
+		database:  database,
+		envSecret: envSecret,
+		keys:      make(map[string]*storedKey),
+	}
+}
+
+// Key ids are derived from the secret, so JWT_SECRET always maps to the
+// same id and tokens issued before the keyring existed stay verifiable.
+func keyIdOf(secret string) string {
+	return cryptography.HashString(secret)[:16]
+}
+
+// Loads the keys from the database. On first start JWT_SECRET becomes
+// the signing key.
+func (store *memoryStore) Load() error {
+	var count int64
+	if err := store.database.QueryRow(QueryCountKeys).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		if _, err := store.database.Exec(
+			QueryInsertPromotedKey,
+			keyIdOf(store.envSecret),
+			store.envSecret,
+		); err != nil {
+			return err
+		}
+	}
+
+	rows, err := store.database.Query(QueryGetKeys)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*storedKey)
+	order := []string{}
+	signingId := ""
+	latestPromotion := int64(0)
+	for rows.Next() {
+		var key storedKey
+		var secret string
+		var promotion int64
+		if err := rows.Scan(
+			&key.info.Id,
+			&secret,
+			&promotion,
+			&key.info.CreatedAt,
+			&key.info.PromotedAt,
+			&key.info.RetiredAt,
+		); err != nil {
+			return err
+		}
+
+		key.secret, err = base64.RawURLEncoding.DecodeString(secret)
+		if err != nil {
+			return err
+		}
+
+		// The most recently promoted key that is not retired signs
+		if key.info.RetiredAt == nil && promotion > latestPromotion {
+			latestPromotion = promotion
+			signingId = key.info.Id
+		}
+		keys[key.info.Id] = &key
+		order = append(order, key.info.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, key := range keys {
+		key.info.Signing = id == signingId
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.keys = keys
+	store.order = order
+	store.signingId = signingId
+	return nil
+}
+
+func (store *memoryStore) SigningKey() (string, []byte, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	key, ok := store.keys[store.signingId]
+	if !ok {
+		return "", nil, ErrUnknownKey
+	}
+	return key.info.Id, key.secret, nil
+}
+
+// Returns the key for the kid header. Tokens without a kid were signed
+// with JWT_SECRET before the keyring existed.
+func (store *memoryStore) VerificationKey(keyId string) ([]byte, error) {
+	if keyId == "" {
+		keyId = keyIdOf(store.envSecret)
+	}
+
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	key, ok := store.keys[keyId]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.info.RetiredAt != nil {
+		return nil, ErrKeyIsRetired
+	}
+	return key.secret, nil
+}
+
+func (store *memoryStore) List() ([]Key, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	keys := make([]Key, 0, len(store.order))
+	for _, id := range store.order {
+		keys = append(keys, store.keys[id].info)
+	}
+	return keys, nil
+}
+
+func (store *memoryStore) Generate() (string, error) {
+	secret, err := cryptography.GenerateRandomHS256Secret()
+	if err != nil {
+		return "", err
+	}
+
+	keyId := keyIdOf(secret)
+	if _, err := store.database.Exec(QueryInsertKey, keyId, secret); err != nil {
+		return "", err
+	}
+
+	return keyId, store.Load()
+}
+
+func (store *memoryStore) Promote(keyId string) error {
+	result, err := store.database.Exec(QueryPromoteKey, keyId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUnknownKey
+	}
+
+	return store.Load()
+}
+
+// Retires a key. Tokens signed with it are rejected from now on.
+func (store *memoryStore) Retire(keyId string) error {
+	store.lock.RLock()
+	signing := keyId == store.signingId
+	store.lock.RUnlock()
+	if signing {
+		return ErrKeyIsSigning
+	}
+
+	result, err := store.database.Exec(QueryRetireKey, keyId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUnknownKey
+	}
+
+	return store.Load()
+}
@@ -0,0 +1,29 @@
+package keyring
+
+const (
+	QueryCountKeys = `
+	SELECT COUNT(*)
+	FROM jwt_signing_keys;`
+	QueryInsertKey = `
+	INSERT INTO jwt_signing_keys (id, secret)
+	VALUES (?, ?)
+	ON CONFLICT(id) DO NOTHING;`
+	QueryInsertPromotedKey = `
+	INSERT INTO jwt_signing_keys (id, secret, promotion, promoted_at)
+	VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+	ON CONFLICT(id) DO NOTHING;`
+	QueryGetKeys = `
+	SELECT id, secret, promotion, created_at, promoted_at, retired_at
+	FROM jwt_signing_keys
+	ORDER BY rowid ASC;`
+	QueryPromoteKey = `
+	UPDATE jwt_signing_keys
+	SET
+		promotion = (SELECT COALESCE(MAX(promotion), 0) + 1 FROM jwt_signing_keys),
+		promoted_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND retired_at IS NULL;`
+	QueryRetireKey = `
+	UPDATE jwt_signing_keys
+	SET retired_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND retired_at IS NULL;`
+)
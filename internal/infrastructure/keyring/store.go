@@ -0,0 +1,32 @@
+package keyring
+
+import "errors"
+
+var (
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrKeyIsSigning = errors.New("the current signing key cannot be retired")
+	ErrKeyIsRetired = errors.New("signing key is retired")
+)
+
+// Key describes a signing key without its secret
+type Key struct {
+	Id         string  `json:"id"`
+	Signing    bool    `json:"signing"`
+	CreatedAt  string  `json:"createdAt"`
+	PromotedAt *string `json:"promotedAt,omitempty"`
+	RetiredAt  *string `json:"retiredAt,omitempty"`
+}
+
+// Store holds the HS256 keys used for access tokens. Tokens are signed with
+// the most recently promoted key and verified with any key not retired, so
+// a new key can be promoted without logging anybody out.
+type Store interface {
+	Load() error
+	SigningKey() (keyId string, key []byte, err error)
+	VerificationKey(keyId string) ([]byte, error)
+	List() ([]Key, error)
+	// Adds a key that verifies tokens but does not sign them yet
+	Generate() (keyId string, err error)
+	Promote(keyId string) error
+	Retire(keyId string) error
+}
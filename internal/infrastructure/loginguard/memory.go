@@ -0,0 +1,115 @@
+// Failed login attempt store implemented to use in-memory
+
+package loginguard
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	policy   Policy
+	attempts attemptStore
+	lock     sync.Mutex
+}
+
+var (
+	accountOnce     sync.Once
+	accountInstance Store
+	ipOnce          sync.Once
+	ipInstance      Store
+)
+
+// Tracks failures per account (normalized email)
+func GetAccountStore() Store {
+	accountOnce.Do(func() {
+		accountInstance = newMemoryStore(AccountPolicy)
+	})
+	return accountInstance
+}
+
+// Tracks failures per client IP
+func GetIPStore() Store {
+	ipOnce.Do(func() {
+		ipInstance = newMemoryStore(IPPolicy)
+	})
+	return ipInstance
+}
+
+func newMemoryStore(policy Policy) Store {
+	store := &memoryStore{
+		policy:   policy,
+		attempts: make(attemptStore),
+	}
+
+	go func() {
+		ticker := time.NewTicker(policy.TimeToLive)
+		for range ticker.C {
+			store.cleanup()
+		}
+	}()
+
+	return store
+}
+
+func (store *memoryStore) Check(key string) time.Duration {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	data, exists := store.attempts[key]
+	if !exists {
+		return 0
+	}
+	return max(time.Until(data.blockedTill), 0)
+}
+
+// Records a failure and returns the wait before the next attempt.
+// lockedOut is true only for the failure that triggered the lockout.
+func (store *memoryStore) Fail(key string) (time.Duration, bool) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	now := time.Now()
+	data := store.attempts[key]
+	if now.Sub(data.lastFailure) > store.policy.TimeToLive {
+		data = attemptData{}
+	}
+
+	data.failures++
+	data.lastFailure = now
+
+	lockedOut := false
+	switch {
+	case data.failures >= store.policy.LockoutAfter:
+		lockedOut = data.failures == store.policy.LockoutAfter
+		data.blockedTill = now.Add(store.policy.LockoutDuration)
+	case data.failures > store.policy.FreeAttempts:
+		// Double the delay for each failure after the free ones
+		exponent := min(data.failures-store.policy.FreeAttempts-1, 16)
+		delay := store.policy.BaseDelay << exponent
+		data.blockedTill = now.Add(min(delay, store.policy.MaxDelay))
+	}
+
+	store.attempts[key] = data
+	return max(data.blockedTill.Sub(now), 0), lockedOut
+}
+
+func (store *memoryStore) Reset(key string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	delete(store.attempts, key)
+}
+
+func (store *memoryStore) cleanup() {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	now := time.Now()
+	for key, data := range store.attempts {
+		if now.Sub(data.lastFailure) > store.policy.TimeToLive &&
+			now.After(data.blockedTill) {
+			delete(store.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package loginguard
+
+import (
+	"bloggo/internal/utils/apierrors"
+	"fmt"
+	"time"
+)
+
+// Store tracks failed login attempts per key (account or IP) and tells
+// how long the next attempt has to wait.
+type Store interface {
+	Check(key string) (wait time.Duration)
+	Fail(key string) (wait time.Duration, lockedOut bool)
+	Reset(key string)
+}
+
+// Policy describes how fast the delays grow and when a key is locked out
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// Failures are forgotten after this much time without a new one
+	TimeToLive time.Duration
+}
+
+var (
+	AccountPolicy = Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		TimeToLive:      time.Hour,
+	}
+	// IPs are shared by offices and proxies, be more tolerant
+	IPPolicy = Policy{
+		FreeAttempts:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutAfter:    50,
+		LockoutDuration: 15 * time.Minute,
+		TimeToLive:      time.Hour,
+	}
+)
+
+// LockedError is returned while a key has to wait before the next attempt
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LockedError) Error() string {
+	return fmt.Sprintf(
+		"too many failed login attempts, retry after %d seconds",
+		err.RetryAfterSeconds(),
+	)
+}
+
+// Lets apierrors.MapErrors map it as a too many requests error
+func (err *LockedError) Is(target error) bool {
+	return target == apierrors.ErrTooManyRequests
+}
+
+func (err *LockedError) RetryAfterSeconds() int {
+	seconds := int(err.RetryAfter.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+type attemptData struct {
+	failures    int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+type attemptStore = map[string]attemptData
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"bloggo/internal/config"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidHeader = errors.New("email header contains line breaks")
+
+// A plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+var (
+	once     sync.Once
+	instance Mailer
+)
+
+// Returns the SMTP mailer when SMTP is configured, otherwise a mailer that
+// writes emails to the server log.
+func Get() Mailer {
+	once.Do(func() {
+		cfg := config.Get()
+		if config.IsSMTPEnabled() {
+			instance = NewSMTPMailer(
+				cfg.SMTPHost,
+				cfg.SMTPPort,
+				cfg.SMTPUsername,
+				cfg.SMTPPassword,
+				cfg.SMTPFrom,
+			)
+		} else {
+			instance = NewLogMailer()
+		}
+	})
+	return instance
+}
+
+// Rejects header values that could inject additional headers
+func validateHeaders(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return ErrInvalidHeader
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type smtpMailer struct {
+	address  string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(
+	host string,
+	port int,
+	username string,
+	password string,
+	from string,
+) Mailer {
+	return &smtpMailer{
+		address:  net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Sends the message, upgrading to TLS when the server offers STARTTLS.
+// Authentication is skipped without a username, e.g. for local SMTP sinks.
+func (mailer *smtpMailer) Send(message Message) error {
+	if err := validateHeaders(message.To, message.Subject); err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(mailer.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	recipient, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if mailer.username != "" {
+		auth = smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", sender.String())
+	fmt.Fprintf(&body, "To: %s\r\n", recipient.String())
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(message.Body)
+
+	return smtp.SendMail(
+		mailer.address,
+		auth,
+		sender.Address,
+		[]string{recipient.Address},
+		body.Bytes(),
+	)
+}
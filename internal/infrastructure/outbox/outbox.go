@@ -0,0 +1,115 @@
+// Package outbox records domain events in the transaction of the change they
+// describe. The relay delivers them to their subscribers afterwards, so a
+// subscriber sees every committed change and nothing that was rolled back.
+package outbox
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/events"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type Outbox struct {
+	database *sql.DB
+	bus      *events.Bus
+}
+
+// Tx is a transaction that events can be published in
+type Tx struct {
+	*sql.Tx
+	outbox    *Outbox
+	published []interface{}
+}
+
+var (
+	once     sync.Once
+	instance *Outbox
+)
+
+func Get() *Outbox {
+	once.Do(func() {
+		instance = New(db.Get(), events.Get())
+	})
+	return instance
+}
+
+func New(database *sql.DB, bus *events.Bus) *Outbox {
+	return &Outbox{
+		database: database,
+		bus:      bus,
+	}
+}
+
+// Transaction runs fn in a transaction and commits it when fn returns nil.
+// Events published in it reach their subscribers once it commits, sync
+// listeners before Transaction returns.
+func (outbox *Outbox) Transaction(fn func(tx *Tx) error) error {
+	sqlTx, err := outbox.database.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	tx := &Tx{Tx: sqlTx, outbox: outbox}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+
+	wakeRelay()
+	for _, event := range tx.published {
+		outbox.bus.Publish(event)
+	}
+	return nil
+}
+
+// Publish records the event in the transaction
+func (tx *Tx) Publish(event interface{}) error {
+	event = events.Occurred(event, time.Now().UTC())
+	if err := tx.outbox.insert(tx.Tx, event); err != nil {
+		return err
+	}
+
+	tx.published = append(tx.published, event)
+	return nil
+}
+
+// Publish records an event that has no change of its own to commit with
+func (outbox *Outbox) Publish(event interface{}) error {
+	event = events.Occurred(event, time.Now().UTC())
+	if err := outbox.insert(outbox.database, event); err != nil {
+		return err
+	}
+
+	wakeRelay()
+	outbox.bus.Publish(event)
+	return nil
+}
+
+// Writes a row per subscriber, so each one is retried on its own
+func (outbox *Outbox) insert(executor db.Executor, event interface{}) error {
+	subscribers := outbox.bus.Subscribers(event)
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	eventType := events.TypeName(reflect.TypeOf(event))
+	now := db.FormatTimestamp(time.Now())
+	for _, subscriber := range subscribers {
+		if _, err := executor.Exec(QueryInsertEvent, eventType, subscriber, string(payload), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
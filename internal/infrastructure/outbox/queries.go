@@ -0,0 +1,26 @@
+package outbox
+
+const (
+	QueryInsertEvent = `
+	INSERT INTO outbox_events (event_type, subscriber, payload, next_attempt_at)
+	VALUES (?, ?, ?, ?);`
+	QueryGetDueEvents = `
+	SELECT id, event_type, subscriber, payload, attempt_count
+	FROM outbox_events
+	WHERE dispatched_at IS NULL
+		AND next_attempt_at <= ?
+	ORDER BY id
+	LIMIT ?;`
+	QueryMarkDispatched = `
+	UPDATE outbox_events
+	SET dispatched_at = CURRENT_TIMESTAMP, attempt_count = attempt_count + 1, last_error = NULL
+	WHERE id = ?;`
+	QueryScheduleRetry = `
+	UPDATE outbox_events
+	SET attempt_count = attempt_count + 1, last_error = ?, next_attempt_at = ?
+	WHERE id = ?;`
+	QueryPruneDispatched = `
+	DELETE FROM outbox_events
+	WHERE dispatched_at IS NOT NULL
+		AND dispatched_at < ?;`
+)
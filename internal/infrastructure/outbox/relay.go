@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/background"
+	"bloggo/internal/infrastructure/events"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	// How often the outbox is checked when no commit wakes the relay, this
+	// picks up retries as they become due
+	relayPollInterval = time.Second
+	relayBatchSize    = 100
+
+	// Failed deliveries are retried with exponential backoff until they
+	// succeed, subscribers must never miss an event
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = 5 * time.Minute
+
+	// Dispatched events are kept this long for inspection
+	dispatchedRetention = 7 * 24 * time.Hour
+	pruneInterval       = time.Hour
+)
+
+// Signals the relay that events were committed
+var relayWake = make(chan struct{}, 1)
+
+func wakeRelay() {
+	select {
+	case relayWake <- struct{}{}:
+	default:
+	}
+}
+
+type pendingEvent struct {
+	id           int64
+	eventType    string
+	subscriber   string
+	payload      string
+	attemptCount int
+}
+
+// StartRelay delivers committed events to their subscribers in the order
+// they were recorded, a failed delivery is retried later without holding up
+// the others. An event is marked dispatched only after its subscriber
+// handled it, so a crash in between delivers it again with the same
+// Delivery. The relay stops once shutdown begins and the rest waits in the
+// outbox.
+func StartRelay() {
+	outbox := Get()
+	background.Go(func() {
+		ticker := time.NewTicker(relayPollInterval)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+
+		for {
+			outbox.dispatchDueEvents()
+
+			if time.Since(lastPrune) > pruneInterval {
+				outbox.pruneDispatched()
+				lastPrune = time.Now()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-relayWake:
+			case <-background.Done():
+				return
+			}
+		}
+	})
+}
+
+func (outbox *Outbox) dispatchDueEvents() {
+	for {
+		pending, err := outbox.getDueEvents()
+		if err != nil {
+			log.Printf("Failed to read the outbox: %v", err)
+			return
+		}
+
+		for _, event := range pending {
+			select {
+			case <-background.Done():
+				return
+			default:
+			}
+			outbox.dispatch(event)
+		}
+
+		if len(pending) < relayBatchSize {
+			return
+		}
+	}
+}
+
+func (outbox *Outbox) getDueEvents() ([]pendingEvent, error) {
+	rows, err := outbox.database.Query(QueryGetDueEvents, db.FormatTimestamp(time.Now()), relayBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []pendingEvent{}
+	for rows.Next() {
+		var event pendingEvent
+		if err := rows.Scan(
+			&event.id,
+			&event.eventType,
+			&event.subscriber,
+			&event.payload,
+			&event.attemptCount,
+		); err != nil {
+			return nil, err
+		}
+		pending = append(pending, event)
+	}
+	return pending, rows.Err()
+}
+
+// Delivers the event to its subscriber and marks it dispatched, or schedules
+// the next attempt
+func (outbox *Outbox) dispatch(pending pendingEvent) {
+	event, err := outbox.bus.Decode(pending.eventType, []byte(pending.payload))
+	if err == nil {
+		err = outbox.bus.Deliver(pending.subscriber, events.Delivery{ID: pending.id}, event)
+	}
+
+	if err == nil {
+		if _, err := outbox.database.Exec(QueryMarkDispatched, pending.id); err != nil {
+			log.Printf("Failed to mark outbox event %d dispatched: %v", pending.id, err)
+		}
+		return
+	}
+
+	message := err.Error()
+	log.Printf("Outbox event %d (%s to %s) failed: %s", pending.id, pending.eventType, pending.subscriber, message)
+	delay := time.Duration(math.Min(
+		float64(retryBaseDelay)*math.Pow(2, float64(pending.attemptCount)),
+		float64(retryMaxDelay),
+	))
+	nextAttemptAt := db.FormatTimestamp(time.Now().Add(delay))
+	if _, err := outbox.database.Exec(QueryScheduleRetry, message, nextAttemptAt, pending.id); err != nil {
+		log.Printf("Failed to schedule outbox event %d: %v", pending.id, err)
+	}
+}
+
+func (outbox *Outbox) pruneDispatched() {
+	before := db.FormatTimestamp(time.Now().Add(-dispatchedRetention))
+	if _, err := outbox.database.Exec(QueryPruneDispatched, before); err != nil {
+		log.Printf("Failed to prune the outbox: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package permissions
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"database/sql"
+	"log"
+)
+
+// ListenEvents reloads the store once roles or category grants change, before
+// the request that changed them answers
+func ListenEvents(bus *events.Bus, store Store, database *sql.DB) {
+	reload := func() {
+		if err := store.Load(database); err != nil {
+			log.Printf("Failed to reload permissions: %v", err)
+		}
+	}
+
+	events.Listen(bus, "permissions", events.Sync, func(events.RoleCreated) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.RoleUpdated) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.RolePermissionsGranted) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.RolePermissionRevoked) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.RoleDeleted) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.UserCategoryPermissionGranted) { reload() })
+	events.Listen(bus, "permissions", events.Sync, func(events.UserCategoryPermissionRevoked) { reload() })
+}
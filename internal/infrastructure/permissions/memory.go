@@ -3,11 +3,13 @@ package permissions
 import (
 	"bloggo/internal/db"
 	"database/sql"
+	"slices"
 	"sync"
 )
 
 type memoryStore struct {
 	permissions permissionStore
+	categories  categoryScopes
 	lock        sync.RWMutex
 }
 
@@ -27,10 +29,12 @@ func Get() Store {
 func newMemoryStore() *memoryStore {
 	return &memoryStore{
 		permissions: make(permissionStore),
+		categories:  make(categoryScopes),
 	}
 }
 
-// Loads the role-permission mapping from the database into memory.
+// Loads the role-permission mapping and the per-user category scopes from
+// the database into memory.
 func (store *memoryStore) Load(db *sql.DB) error {
 	store.lock.Lock()
 	defer store.lock.Unlock()
@@ -55,7 +59,38 @@ func (store *memoryStore) Load(db *sql.DB) error {
 		}
 		store.permissions[role][permission] = true
 	}
-	return nil
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return store.loadCategoryScopes(db)
+}
+
+func (store *memoryStore) loadCategoryScopes(db *sql.DB) error {
+	rows, err := db.Query(QueryGetUserCategoryPermissions)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	store.categories = make(categoryScopes)
+
+	for rows.Next() {
+		var userId, categoryId int64
+		var permission string
+
+		if err := rows.Scan(&userId, &permission, &categoryId); err != nil {
+			return err
+		}
+		if _, ok := store.categories[userId]; !ok {
+			store.categories[userId] = make(map[string]map[int64]bool)
+		}
+		if _, ok := store.categories[userId][permission]; !ok {
+			store.categories[userId][permission] = make(map[int64]bool)
+		}
+		store.categories[userId][permission][categoryId] = true
+	}
+	return rows.Err()
 }
 
 // Checks if the given role has the specified permission.
@@ -71,3 +106,48 @@ func (store *memoryStore) HasPermission(
 	}
 	return perms[permission]
 }
+
+// Checks the role permission and, when the user's permission is narrowed to
+// some categories, that the category is one of them. Versions without a
+// category only pass for users whose permission isn't narrowed.
+func (store *memoryStore) HasCategoryPermission(
+	role int64,
+	userId int64,
+	permission string,
+	categoryId *int64,
+) bool {
+	if !store.HasPermission(role, permission) {
+		return false
+	}
+
+	categories, scoped := store.CategoryScope(userId, permission)
+	if !scoped {
+		return true
+	}
+	if categoryId == nil {
+		return false
+	}
+	return slices.Contains(categories, *categoryId)
+}
+
+// Returns the categories the user's permission is narrowed to. The second
+// value is false when the permission applies to every category.
+func (store *memoryStore) CategoryScope(
+	userId int64,
+	permission string,
+) ([]int64, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	cell, ok := store.categories[userId][permission]
+	if !ok {
+		return nil, false
+	}
+
+	categories := make([]int64, 0, len(cell))
+	for categoryId := range cell {
+		categories = append(categories, categoryId)
+	}
+	slices.Sort(categories)
+	return categories, true
+}
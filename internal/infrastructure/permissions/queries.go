@@ -6,4 +6,8 @@ const (
 	FROM role_permissions rp
 	JOIN permissions p ON rp.permission_id = p.id
 	JOIN roles r ON rp.role_id = r.id;`
+	QueryGetUserCategoryPermissions = `
+	SELECT ucp.user_id, p.name AS permission_name, ucp.category_id
+	FROM user_category_permissions ucp
+	JOIN permissions p ON ucp.permission_id = p.id;`
 )
@@ -7,7 +7,13 @@ import (
 type Store interface {
 	Load(db *sql.DB) error
 	HasPermission(role int64, permission string) bool
+	HasCategoryPermission(role int64, userId int64, permission string, categoryId *int64) bool
+	CategoryScope(userId int64, permission string) ([]int64, bool)
 }
 
 type permissionCell = map[string]bool
 type permissionStore = map[int64]permissionCell
+
+// Categories a user's permissions are narrowed to, keyed by user and then
+// by permission.
+type categoryScopes = map[int64]map[string]map[int64]bool
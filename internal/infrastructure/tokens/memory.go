@@ -3,6 +3,8 @@
 package tokens
 
 import (
+	"bloggo/internal/utils/cryptography"
+	"sort"
 	"sync"
 	"time"
 )
@@ -34,13 +36,19 @@ func (store *memoryStore) Set(
 	token string,
 	userId int64,
 	duration int,
+	client ClientInfo,
 ) {
 	store.lock.Lock()
 	defer store.lock.Unlock()
 
+	now := time.Now()
 	store.tokens[token] = tokenData{
-		userId:    userId,
-		expiresAt: time.Now().Add(time.Duration(duration) * time.Second),
+		sessionId:  cryptography.GenerateUniqueId(),
+		userId:     userId,
+		client:     client,
+		createdAt:  now,
+		lastUsedAt: now,
+		expiresAt:  now.Add(time.Duration(duration) * time.Second),
 	}
 }
 
@@ -56,9 +64,109 @@ func (store *memoryStore) Get(token string) (int64, bool) {
 	return data.userId, true
 }
 
+// Replaces the old token with a new one while keeping the same session
+func (store *memoryStore) Rotate(
+	oldToken string,
+	newToken string,
+	duration int,
+	client ClientInfo,
+) (int64, bool) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	data, exists := store.tokens[oldToken]
+	if !exists || time.Now().After(data.expiresAt) {
+		return 0, false
+	}
+	delete(store.tokens, oldToken)
+
+	now := time.Now()
+	data.client = client
+	data.lastUsedAt = now
+	data.expiresAt = now.Add(time.Duration(duration) * time.Second)
+	store.tokens[newToken] = data
+
+	return data.userId, true
+}
+
 func (store *memoryStore) Delete(token string) {
 	store.lock.Lock()
 	defer store.lock.Unlock()
 
 	delete(store.tokens, token)
 }
+
+func (store *memoryStore) GetSession(token string) (Session, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	data, exists := store.tokens[token]
+	if !exists || time.Now().After(data.expiresAt) {
+		return Session{}, false
+	}
+
+	return data.toSession(), true
+}
+
+func (store *memoryStore) ListByUser(userId int64) []Session {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	now := time.Now()
+	sessions := []Session{}
+	for _, data := range store.tokens {
+		if data.userId != userId || now.After(data.expiresAt) {
+			continue
+		}
+		sessions = append(sessions, data.toSession())
+	}
+
+	// Most recently used sessions first
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions
+}
+
+func (store *memoryStore) DeleteById(userId int64, sessionId string) bool {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	for token, data := range store.tokens {
+		if data.userId == userId && data.sessionId == sessionId {
+			delete(store.tokens, token)
+			return true
+		}
+	}
+	return false
+}
+
+// Deletes every session of the user except the one owning exceptToken.
+// Pass an empty exceptToken to delete all of them.
+func (store *memoryStore) DeleteByUser(userId int64, exceptToken string) int {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	deleted := 0
+	for token, data := range store.tokens {
+		if data.userId != userId || token == exceptToken {
+			continue
+		}
+		delete(store.tokens, token)
+		deleted++
+	}
+	return deleted
+}
+
+func (data tokenData) toSession() Session {
+	return Session{
+		Id:         data.sessionId,
+		UserId:     data.userId,
+		IP:         data.client.IP,
+		UserAgent:  data.client.UserAgent,
+		CreatedAt:  data.createdAt,
+		LastUsedAt: data.lastUsedAt,
+		ExpiresAt:  data.expiresAt,
+	}
+}
@@ -6,14 +6,40 @@ import (
 
 // Store refresh token to create, track and revoke sessions
 type Store interface {
-	Set(token string, userId int64, duration int)
+	Set(token string, userId int64, duration int, client ClientInfo)
 	Get(token string) (userId int64, found bool)
+	Rotate(oldToken string, newToken string, duration int, client ClientInfo) (userId int64, found bool)
 	Delete(token string)
+	GetSession(token string) (session Session, found bool)
+	ListByUser(userId int64) []Session
+	DeleteById(userId int64, sessionId string) bool
+	DeleteByUser(userId int64, exceptToken string) int
+}
+
+// Details of the client that owns a refresh token
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Public representation of a refresh token, never exposes the token itself
+type Session struct {
+	Id         string
+	UserId     int64
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
 }
 
 type tokenData struct {
-	userId    int64
-	expiresAt time.Time
+	sessionId  string
+	userId     int64
+	client     ClientInfo
+	createdAt  time.Time
+	lastUsedAt time.Time
+	expiresAt  time.Time
 }
 
 type tokenStore = map[string]tokenData
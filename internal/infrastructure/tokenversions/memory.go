@@ -0,0 +1,75 @@
+package tokenversions
+
+import (
+	"bloggo/internal/db"
+	"database/sql"
+	"sync"
+)
+
+type memoryStore struct {
+	database *sql.DB
+	versions versionStore
+	lock     sync.RWMutex
+}
+
+var (
+	once     sync.Once
+	instance Store
+)
+
+func Get() Store {
+	once.Do(func() {
+		instance = newMemoryStore(db.Get())
+	})
+	return instance
+}
+
+func newMemoryStore(database *sql.DB) *memoryStore {
+	return &memoryStore{
+		database: database,
+		versions: make(versionStore),
+	}
+}
+
+// Returns the current token version of the user, reading through the cache.
+func (store *memoryStore) Current(userId int64) (int64, error) {
+	store.lock.RLock()
+	version, ok := store.versions[userId]
+	store.lock.RUnlock()
+	if ok {
+		return version, nil
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	err := store.database.QueryRow(QueryGetTokenVersion, userId).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	store.versions[userId] = version
+	return version, nil
+}
+
+// Increments the token version of the user, invalidating issued access tokens.
+func (store *memoryStore) Bump(userId int64) (int64, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if _, err := store.database.Exec(QueryBumpTokenVersion, userId); err != nil {
+		// Drop the cached value so the next check reads the database again
+		delete(store.versions, userId)
+		return 0, err
+	}
+
+	var version int64
+	err := store.database.QueryRow(QueryGetTokenVersion, userId).Scan(&version)
+	if err != nil {
+		delete(store.versions, userId)
+		return 0, err
+	}
+
+	store.versions[userId] = version
+	return version, nil
+}
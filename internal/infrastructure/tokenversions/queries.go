@@ -0,0 +1,14 @@
+package tokenversions
+
+const (
+	QueryGetTokenVersion = `
+	SELECT version
+	FROM user_token_versions
+	WHERE user_id = ?;`
+	QueryBumpTokenVersion = `
+	INSERT INTO user_token_versions (user_id, version, updated_at)
+	VALUES (?, 1, CURRENT_TIMESTAMP)
+	ON CONFLICT(user_id) DO UPDATE SET
+		version = version + 1,
+		updated_at = CURRENT_TIMESTAMP;`
+)
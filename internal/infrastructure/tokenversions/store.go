@@ -0,0 +1,10 @@
+package tokenversions
+
+// Store tracks a generation counter per user. Access tokens carry the
+// counter they were issued with and become invalid once it is bumped.
+type Store interface {
+	Current(userId int64) (int64, error)
+	Bump(userId int64) (int64, error)
+}
+
+type versionStore = map[int64]int64
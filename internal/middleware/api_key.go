@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/apikeys"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/handlers"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// Validates the x-api-key header and checks that the key grants the scope.
+// The legacy x-trusted-frontend header is still read, and the configured
+// TRUSTED_FRONTEND_KEY works as a bootstrap key with every scope.
+func APIKeyMiddleware(
+	config *config.Config,
+	scope string,
+) func(http.Handler) http.Handler {
+	store := apikeys.Get()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(
+			writer http.ResponseWriter,
+			request *http.Request,
+		) {
+			rawKey := request.Header.Get("x-api-key")
+			if rawKey == "" {
+				rawKey = request.Header.Get("x-trusted-frontend")
+			}
+
+			if rawKey == "" {
+				handlers.WriteError(writer, apierrors.NewAPIError(
+					"Missing x-api-key header",
+					apierrors.ErrUnauthorized,
+				), http.StatusUnauthorized)
+				return
+			}
+
+			// Bootstrap key from the environment
+			if config.TrustedFrontendKey != "" && subtle.ConstantTimeCompare(
+				[]byte(rawKey),
+				[]byte(config.TrustedFrontendKey),
+			) == 1 {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			key, err := store.Authenticate(rawKey)
+			if err != nil {
+				message := "Invalid API key"
+				if errors.Is(err, apikeys.ErrExpiredKey) {
+					message = "API key has expired"
+				}
+				handlers.WriteError(writer, apierrors.NewAPIError(
+					message,
+					apierrors.ErrUnauthorized,
+				), http.StatusUnauthorized)
+				return
+			}
+
+			if !key.HasScope(scope) {
+				handlers.WriteError(writer, apierrors.NewAPIError(
+					"API key is missing the \""+scope+"\" scope",
+					apierrors.ErrForbidden,
+				), http.StatusForbidden)
+				return
+			}
+
+			if !store.Allow(key) {
+				handlers.WriteError(writer, apierrors.NewAPIError(
+					"API key rate limit exceeded",
+					apierrors.ErrTooManyRequests,
+				), http.StatusTooManyRequests)
+				return
+			}
+
+			newContext := context.WithValue(request.Context(), handlers.APIKeyId, key.Id)
+			next.ServeHTTP(writer, request.WithContext(newContext))
+		})
+	}
+}
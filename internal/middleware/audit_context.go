@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/cryptography"
+	"bloggo/internal/utils/handlers"
+	"net/http"
+	"regexp"
+)
+
+// Longer user agents are cut, they only identify the client in the log
+const maxAuditUserAgentLength = 512
+
+// Request ids set by a proxy in front of the server are kept when they look
+// like one, so its logs and the audit log can be matched
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Stores the audit context of the request, and returns its id in the
+// X-Request-Id header. Runs after ClientIP so the resolved address is used.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(
+		writer http.ResponseWriter,
+		request *http.Request,
+	) {
+		requestID := request.Header.Get("X-Request-Id")
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = cryptography.GenerateUniqueId()
+		}
+		writer.Header().Set("X-Request-Id", requestID)
+
+		userAgent := request.UserAgent()
+		if len(userAgent) > maxAuditUserAgentLength {
+			userAgent = userAgent[:maxAuditUserAgentLength]
+		}
+
+		ctx := auditcontext.With(request.Context(), auditcontext.Context{
+			IP:        handlers.GetClientIP(request),
+			UserAgent: userAgent,
+			RequestID: requestID,
+		})
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
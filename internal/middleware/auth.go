@@ -2,20 +2,38 @@ package middleware
 
 import (
 	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/accesstokens"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/keyring"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/infrastructure/tokenversions"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/handlers"
 	"context"
-	"encoding/base64"
+	"errors"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // Reads the JWT access token from the Authorization header, validates it, and sets userRole in the context.
+// Personal access tokens are only accepted on routes that name the permissions they need, see
+// authenticateAccessToken.
 func AuthMiddleware(
 	configuration *config.Config,
+	accessTokenScopes ...string,
 ) func(http.Handler) http.Handler {
+	tokenVersions := tokenversions.Get()
+	signingKeys := keyring.Get()
+	accessTokens := accesstokens.Get()
+	permissionStore := permissions.Get()
+	eventOutbox := outbox.Get()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(
 			writer http.ResponseWriter,
@@ -39,27 +57,31 @@ func AuthMiddleware(
 
 			// Remove "Bearer " prefix to get the token string
 			tokenString := strings.TrimPrefix(header, "Bearer ")
-			// Decode the JWT secret from base64
-			key, err := base64.RawURLEncoding.DecodeString(configuration.JWTSecret)
-			if err != nil {
-				// If the secret can't be decoded, return 500 Internal Server Error
-				handlers.WriteError(
+
+			if strings.HasPrefix(tokenString, accesstokens.TokenPrefix) {
+				authenticateAccessToken(
 					writer,
-					apierrors.NewAPIError(
-						"Server misconfiguration",
-						err,
-					),
-					http.StatusInternalServerError,
+					request,
+					next,
+					tokenString,
+					accessTokenScopes,
+					accessTokens,
+					permissionStore,
+					eventOutbox,
 				)
 				return
 			}
 
-			// Parse and validate the JWT token
+			// Parse and validate the JWT token with the key named by its kid header
 			claims := jwt.MapClaims{}
-			_, err = jwt.ParseWithClaims(
+			_, err := jwt.ParseWithClaims(
 				tokenString,
 				claims,
-				func(token *jwt.Token) (any, error) { return key, nil },
+				func(token *jwt.Token) (any, error) {
+					keyId, _ := token.Header["kid"].(string)
+					return signingKeys.VerificationKey(keyId)
+				},
+				jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
 			)
 			if err != nil {
 				// If the token is invalid or expired, return 401 Unauthorized
@@ -102,6 +124,33 @@ func AuthMiddleware(
 				return
 			}
 
+			// Tokens issued before a role change, deletion or passphrase
+			// change carry an outdated version. Missing version means 0.
+			ver, _ := claims["ver"].(float64)
+			currentVersion, err := tokenVersions.Current(int64(uid))
+			if err != nil {
+				handlers.WriteError(
+					writer,
+					apierrors.NewAPIError(
+						"Cannot verify token version",
+						err,
+					),
+					http.StatusInternalServerError,
+				)
+				return
+			}
+			if int64(ver) != currentVersion {
+				handlers.WriteError(
+					writer,
+					apierrors.NewAPIError(
+						"Token has been revoked",
+						apierrors.ErrUnauthorized,
+					),
+					http.StatusUnauthorized,
+				)
+				return
+			}
+
 			// Set userRole in the request context as int64
 			newContext := context.WithValue(
 				request.Context(),
@@ -115,3 +164,97 @@ func AuthMiddleware(
 		})
 	}
 }
+
+// Authenticates a personal access token. The token must have been granted
+// every permission of the route, and the owner's role must still have them.
+// Services then check the owner's role as for a session, which can't grant
+// more than the token since the route names every permission they check.
+func authenticateAccessToken(
+	writer http.ResponseWriter,
+	request *http.Request,
+	next http.Handler,
+	tokenString string,
+	scopes []string,
+	accessTokens accesstokens.Store,
+	permissionStore permissions.Store,
+	eventOutbox *outbox.Outbox,
+) {
+	token, recordUse, err := accessTokens.Authenticate(tokenString)
+	if err != nil {
+		if errors.Is(err, accesstokens.ErrInvalidToken) ||
+			errors.Is(err, accesstokens.ErrExpiredToken) {
+			handlers.WriteError(
+				writer,
+				apierrors.NewAPIError(
+					"Invalid or expired token",
+					apierrors.ErrUnauthorized,
+				),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+		if errors.Is(err, accesstokens.ErrRevokedToken) {
+			handlers.WriteError(
+				writer,
+				apierrors.NewAPIError(
+					"Token has been revoked",
+					apierrors.ErrUnauthorized,
+				),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+		handlers.WriteError(
+			writer,
+			apierrors.NewAPIError(
+				"Cannot verify personal access token",
+				err,
+			),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	// Routes that name no permission, such as passphrase and session
+	// management, are for sessions only
+	if len(scopes) == 0 {
+		handlers.WriteError(
+			writer,
+			apierrors.NewAPIError(
+				"Personal access tokens cannot be used for this endpoint",
+				apierrors.ErrForbidden,
+			),
+			http.StatusForbidden,
+		)
+		return
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(token.Permissions, scope) || !permissionStore.HasPermission(token.RoleId, scope) {
+			handlers.WriteError(
+				writer,
+				apierrors.NewAPIError(
+					"Personal access token lacks the "+scope+" permission",
+					apierrors.ErrForbidden,
+				),
+				http.StatusForbidden,
+			)
+			return
+		}
+	}
+
+	if recordUse {
+		err := eventOutbox.Publish(events.AccessTokenUsed{
+			UserID:  token.UserId,
+			Context: auditcontext.FromRequest(request),
+			TokenID: token.Id,
+		})
+		if err != nil {
+			log.Printf("Failed to record use of access token %d: %v", token.Id, err)
+		}
+	}
+
+	newContext := context.WithValue(request.Context(), handlers.TokenRoleId, token.RoleId)
+	newContext = context.WithValue(newContext, handlers.TokenUserId, token.UserId)
+	newContext = context.WithValue(newContext, handlers.TokenPermissions, token.Permissions)
+	next.ServeHTTP(writer, request.WithContext(newContext))
+}
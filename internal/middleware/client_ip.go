@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"bloggo/internal/utils/clientip"
+	"bloggo/internal/utils/handlers"
+	"context"
+	"net/http"
+)
+
+// Resolves the client address behind trusted proxies once per request and
+// stores it in the context for rate limiting, sessions, audit and views.
+func ClientIP(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(
+			writer http.ResponseWriter,
+			request *http.Request,
+		) {
+			ctx := context.WithValue(
+				request.Context(),
+				handlers.ClientIP,
+				resolver.Resolve(request),
+			)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
@@ -1,34 +1,116 @@
 package middleware
 
-import "net/http"
-
-func AllowSpecificOrigin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(
-		writer http.ResponseWriter,
-		request *http.Request,
-	) {
-		writer.Header().Set(
-			"Access-Control-Allow-Origin",
-			"http://localhost:3000",
-		)
-		writer.Header().Set(
-			"Access-Control-Allow-Credentials",
-			"true",
-		)
-		writer.Header().Set(
-			"Access-Control-Allow-Methods",
-			"GET, POST, PATCH, PUT, DELETE, OPTIONS",
-		)
-		writer.Header().Set(
-			"Access-Control-Allow-Headers",
-			"Content-Type, Authorization",
-		)
-
-		if request.Method == http.MethodOptions {
-			writer.WriteHeader(http.StatusNoContent)
-			return
+import (
+	"bloggo/internal/config"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type originPattern struct {
+	any    bool
+	exact  string
+	scheme string
+	suffix string
+}
+
+func newOriginPattern(origin string) originPattern {
+	if origin == "*" {
+		return originPattern{any: true}
+	}
+
+	origin = strings.ToLower(strings.TrimRight(origin, "/"))
+	scheme, host, _ := strings.Cut(origin, "://")
+	if strings.HasPrefix(host, "*.") {
+		return originPattern{scheme: scheme, suffix: host[1:]}
+	}
+	return originPattern{exact: origin}
+}
+
+// Wildcard subdomain patterns match any depth of subdomain but not the
+// parent domain itself.
+func (pattern originPattern) matches(origin string) bool {
+	if pattern.any {
+		return true
+	}
+	if pattern.exact != "" {
+		return pattern.exact == origin
+	}
+
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme != pattern.scheme {
+		return false
+	}
+	return strings.HasSuffix(host, pattern.suffix) && len(host) > len(pattern.suffix)
+}
+
+// Applies the CORS policy to requests under the path prefix and answers
+// preflight requests for them. It must be installed on the root router so
+// preflights are handled before chi matches methods, since no OPTIONS
+// routes are registered. Only matching origins are echoed back.
+func CORS(prefix string, policy config.CORSConfig) func(http.Handler) http.Handler {
+	patterns := make([]originPattern, 0, len(policy.AllowedOrigins))
+	for _, origin := range policy.AllowedOrigins {
+		patterns = append(patterns, newOriginPattern(origin))
+	}
+
+	allowedMethods := strings.Join(policy.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(policy.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(policy.MaxAge)
+
+	isAllowed := func(origin string) bool {
+		origin = strings.ToLower(origin)
+		for _, pattern := range patterns {
+			if pattern.matches(origin) {
+				return true
+			}
 		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(
+			writer http.ResponseWriter,
+			request *http.Request,
+		) {
+			path := request.URL.Path
+			if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			origin := request.Header.Get("Origin")
+			preflight := request.Method == http.MethodOptions &&
+				request.Header.Get("Access-Control-Request-Method") != ""
+
+			header := writer.Header()
+			header.Add("Vary", "Origin")
 
-		next.ServeHTTP(writer, request)
-	})
+			allowed := origin != "" && isAllowed(origin)
+			if allowed {
+				header.Set("Access-Control-Allow-Origin", origin)
+				if policy.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+
+			method := strings.ToUpper(request.Header.Get("Access-Control-Request-Method"))
+			if allowed && slices.Contains(policy.AllowedMethods, method) {
+				header.Set("Access-Control-Allow-Methods", allowedMethods)
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+
+			writer.WriteHeader(http.StatusNoContent)
+		})
+	}
 }
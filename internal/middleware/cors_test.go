@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bloggo/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"*", "https://anything.example", true},
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com/", "https://example.com", true},
+		{"HTTPS://Example.com", "https://example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com", "https://example.com:8443", false},
+		{"https://example.com", "https://evil-example.com", false},
+		{"https://*.example.com", "https://blog.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "http://blog.example.com", false},
+		{"https://*.example.com", "https://blogexample.com", false},
+		{"https://*.example.com", "https://example.com.evil.test", false},
+		{"https://*.example.com", "blog.example.com", false},
+	}
+
+	for _, test := range tests {
+		if got := newOriginPattern(test.pattern).matches(test.origin); got != test.want {
+			t.Errorf("%q matches %q = %v, want %v", test.pattern, test.origin, got, test.want)
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	policy := config.CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	handler := CORS("/internal", policy)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		method      string
+		status      int
+		allowOrigin string
+		allowMethod bool
+	}{
+		{"allowed origin", "/internal/posts", "https://Blog.Example.com", "POST", http.StatusNoContent, "https://Blog.Example.com", true},
+		{"method not allowed", "/internal/posts", "https://blog.example.com", "DELETE", http.StatusNoContent, "https://blog.example.com", false},
+		{"origin not allowed", "/internal/posts", "https://evil.test", "POST", http.StatusNoContent, "", false},
+		{"outside the prefix", "/api/posts", "https://blog.example.com", "POST", http.StatusTeapot, "", false},
+		{"prefix of another path", "/internalx", "https://blog.example.com", "POST", http.StatusTeapot, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodOptions, test.path, nil)
+			request.Header.Set("Origin", test.origin)
+			request.Header.Set("Access-Control-Request-Method", test.method)
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+			header := recorder.Header()
+			if got := header.Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, test.allowOrigin)
+			}
+			if got := header.Get("Access-Control-Allow-Methods") != ""; got != test.allowMethod {
+				t.Errorf("Access-Control-Allow-Methods set = %v, want %v", got, test.allowMethod)
+			}
+			if test.allowOrigin != "" && header.Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Access-Control-Allow-Credentials is not set")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Tells browsers to use HTTPS only for max age seconds. The header is only
+// sent on TLS connections, browsers ignore it over plain HTTP.
+func HSTS(maxAge int, includeSubdomains bool) func(http.Handler) http.Handler {
+	value := "max-age=" + strconv.Itoa(maxAge)
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(
+			writer http.ResponseWriter,
+			request *http.Request,
+		) {
+			if request.TLS != nil {
+				writer.Header().Set("Strict-Transport-Security", value)
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -3,15 +3,79 @@ package middleware
 import (
 	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/handlers"
-	"net"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
-type ipLimiter struct {
+// RateLimitKey selects whose requests share a bucket
+type RateLimitKey int
+
+const (
+	// One bucket per client IP
+	KeyByIP RateLimitKey = iota
+	// One bucket per authenticated user, falls back to the IP
+	KeyByUser
+	// One bucket per API key, falls back to the IP for the bootstrap key
+	KeyByAPIKey
+)
+
+// RateLimitPolicy allows Limit requests per Window for each key. Requests
+// may come in bursts of up to Limit, the bucket refills evenly over the
+// window.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    RateLimitKey
+}
+
+// Declared policies. Each policy has its own buckets, so a request passing
+// through several policies counts against each of them.
+var (
+	// Every /internal request
+	PolicyInternal = RateLimitPolicy{
+		Name:   "internal",
+		Limit:  100,
+		Window: time.Minute,
+		Key:    KeyByIP,
+	}
+	// Login and second factor steps
+	PolicySession = RateLimitPolicy{
+		Name:   "session",
+		Limit:  10,
+		Window: time.Minute,
+		Key:    KeyByIP,
+	}
+	// Public API reads, after the API key is authenticated
+	PolicyPublicAPI = RateLimitPolicy{
+		Name:   "api",
+		Limit:  1000,
+		Window: time.Minute,
+		Key:    KeyByAPIKey,
+	}
+	// View tracking, per visitor behind trusted proxies
+	PolicyTrackView = RateLimitPolicy{
+		Name:   "track-view",
+		Limit:  30,
+		Window: time.Minute,
+		Key:    KeyByIP,
+	}
+	// AI generative fill, after authentication
+	PolicyGenerativeFill = RateLimitPolicy{
+		Name:   "generative-fill",
+		Limit:  10,
+		Window: time.Minute,
+		Key:    KeyByUser,
+	}
+)
+
+type keyedLimiter struct {
 	visitors   map[string]*rate.Limiter
 	mutex      sync.Mutex
 	rate       rate.Limit
@@ -19,12 +83,12 @@ type ipLimiter struct {
 	timeToLive time.Duration
 }
 
-func newIPLimiter(
+func newKeyedLimiter(
 	rateLimit rate.Limit,
 	burst int,
 	timeToLive time.Duration,
-) *ipLimiter {
-	limiter := &ipLimiter{
+) *keyedLimiter {
+	limiter := &keyedLimiter{
 		visitors:   make(map[string]*rate.Limiter),
 		rate:       rateLimit,
 		burst:      burst,
@@ -41,87 +105,78 @@ func newIPLimiter(
 	return limiter
 }
 
-func (ipLimiter *ipLimiter) get(ip string) *rate.Limiter {
-	ipLimiter.mutex.Lock()
-	defer ipLimiter.mutex.Unlock()
+func (keyedLimiter *keyedLimiter) get(key string) *rate.Limiter {
+	keyedLimiter.mutex.Lock()
+	defer keyedLimiter.mutex.Unlock()
 
-	if limiter, ok := ipLimiter.visitors[ip]; ok {
+	if limiter, ok := keyedLimiter.visitors[key]; ok {
 		return limiter
 	}
 
-	limiter := rate.NewLimiter(ipLimiter.rate, ipLimiter.burst)
-	ipLimiter.visitors[ip] = limiter
+	limiter := rate.NewLimiter(keyedLimiter.rate, keyedLimiter.burst)
+	keyedLimiter.visitors[key] = limiter
 	return limiter
 }
 
-func (ipLimiter *ipLimiter) cleanup() {
-	ipLimiter.mutex.Lock()
-	defer ipLimiter.mutex.Unlock()
-	for ip, limiter := range ipLimiter.visitors {
-		if limiter.Allow() {
-			delete(ipLimiter.visitors, ip)
+// Drops buckets that refilled completely, they behave like new ones
+func (keyedLimiter *keyedLimiter) cleanup() {
+	keyedLimiter.mutex.Lock()
+	defer keyedLimiter.mutex.Unlock()
+	for key, limiter := range keyedLimiter.visitors {
+		if limiter.Tokens() >= float64(keyedLimiter.burst) {
+			delete(keyedLimiter.visitors, key)
 		}
 	}
 }
 
-var (
-	globalLimiter = newIPLimiter(
-		rate.Every(time.Minute/100),
-		100,
-		10*time.Minute,
-	)
-)
-
-func GlobalRateLimiter() func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(
-			writer http.ResponseWriter,
-			request *http.Request,
-		) {
-			ip, _, err := net.SplitHostPort(request.RemoteAddr)
-			if err != nil {
-				handlers.WriteError(writer, apierrors.NewAPIError(
-					"Invalid IP",
-					apierrors.ErrBadRequest,
-				), http.StatusBadRequest)
-				return
-			}
-
-			if !globalLimiter.get(ip).Allow() {
-				handlers.WriteError(writer, apierrors.NewAPIError(
-					"Too many request in a short period",
-					apierrors.ErrTooManyRequests,
-				), http.StatusTooManyRequests)
-				return
-			}
-
-			next.ServeHTTP(writer, request)
-		})
+func rateLimitKey(request *http.Request, key RateLimitKey) string {
+	switch key {
+	case KeyByUser:
+		if userId, ok := request.Context().Value(handlers.TokenUserId).(int64); ok {
+			return "user:" + strconv.FormatInt(userId, 10)
+		}
+	case KeyByAPIKey:
+		if keyId, ok := request.Context().Value(handlers.APIKeyId).(int64); ok {
+			return "key:" + strconv.FormatInt(keyId, 10)
+		}
 	}
+	return "ip:" + handlers.GetClientIP(request)
 }
 
-func RateLimitMiddleware(
-	rateLimit rate.Limit,
-	burst int,
-	timeToLive time.Duration,
-) func(http.Handler) http.Handler {
-	limiter := newIPLimiter(rateLimit, burst, timeToLive)
+// Limits requests with the policy and reports the quota in the RateLimit-*
+// headers. When several policies apply, the headers describe the one with
+// the fewest remaining requests.
+func RateLimit(policy RateLimitPolicy) func(http.Handler) http.Handler {
+	perSecond := float64(policy.Limit) / policy.Window.Seconds()
+	limiter := newKeyedLimiter(rate.Limit(perSecond), policy.Limit, policy.Window)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(
 			writer http.ResponseWriter,
 			request *http.Request,
 		) {
-			ip, _, err := net.SplitHostPort(request.RemoteAddr)
-			if err != nil {
-				handlers.WriteError(writer, apierrors.NewAPIError(
-					"Invalid IP",
-					apierrors.ErrBadRequest,
-				), http.StatusBadRequest)
-				return
+			bucket := limiter.get(rateLimitKey(request, policy.Key))
+			allowed := bucket.Allow()
+			tokens := bucket.Tokens()
+
+			remaining := max(int(math.Floor(tokens)), 0)
+			header := writer.Header()
+			if current, err := strconv.Atoi(header.Get("RateLimit-Remaining")); err != nil || remaining < current {
+				reset := math.Ceil((float64(policy.Limit) - tokens) / perSecond)
+				header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+				header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+				header.Set("RateLimit-Reset", strconv.Itoa(int(reset)))
+				header.Set("RateLimit-Policy", fmt.Sprintf(
+					"%d;w=%d;name=%q",
+					policy.Limit,
+					int(policy.Window.Seconds()),
+					policy.Name,
+				))
 			}
 
-			if !limiter.get(ip).Allow() {
+			if !allowed {
+				retryAfter := max(math.Ceil((1-tokens)/perSecond), 1)
+				header.Set("Retry-After", strconv.Itoa(int(retryAfter)))
 				handlers.WriteError(writer, apierrors.NewAPIError(
 					"Too many request in a short period",
 					apierrors.ErrTooManyRequests,
@@ -0,0 +1,103 @@
+package accesstoken
+
+import (
+	"bloggo/internal/module/accesstoken/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type AccessTokenHandler struct {
+	service AccessTokenService
+}
+
+func NewAccessTokenHandler(service AccessTokenService) AccessTokenHandler {
+	return AccessTokenHandler{
+		service,
+	}
+}
+
+func (handler *AccessTokenHandler) ListTokens(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	tokens, err := handler.service.ListTokens(userId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(tokens)
+}
+
+func (handler *AccessTokenHandler) CreateToken(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestAccessTokenCreate](writer, request)
+	if !ok {
+		return
+	}
+
+	created, err := handler.service.CreateToken(body, userId, userRoleId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrForbidden: {
+				Message: "Tokens can only be granted permissions of your role, and cannot be created with a token.",
+				Status:  http.StatusForbidden,
+			},
+			apierrors.ErrBadRequest: {
+				Message: "Expiry must be in the future.",
+				Status:  http.StatusBadRequest,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(created)
+}
+
+func (handler *AccessTokenHandler) RevokeToken(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	tokenId, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := handler.service.RevokeToken(tokenId, userId, userRoleId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
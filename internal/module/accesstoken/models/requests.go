@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// -- Create Personal Access Token -- //
+type RequestAccessTokenCreate struct {
+	Name        string     `json:"name" validate:"required,min=3,max=100"`
+	Permissions []string   `json:"permissions" validate:"required,min=1,dive,required,max=50"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// -- Personal Access Token Details, Never Including The Token -- //
+type ResponseAccessToken struct {
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Permissions []string `json:"permissions"`
+	ExpiresAt   *string  `json:"expiresAt,omitempty"`
+	LastUsedAt  *string  `json:"lastUsedAt,omitempty"`
+	RevokedAt   *string  `json:"revokedAt,omitempty"`
+	CreatedAt   string   `json:"createdAt"`
+}
+
+// -- Created Personal Access Token, Only Shown Once -- //
+type ResponseAccessTokenCreated struct {
+	Id    int64  `json:"id"`
+	Token string `json:"token"`
+}
@@ -0,0 +1,44 @@
+package accesstoken
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type AccessTokenModule struct {
+	Handler    AccessTokenHandler
+	Service    AccessTokenService
+	Repository AccessTokenRepository
+}
+
+func NewModule() AccessTokenModule {
+	database := db.Get()
+
+	repository := NewAccessTokenRepository(database)
+	service := NewAccessTokenService(repository, permissions.Get(), outbox.Get())
+	handler := NewAccessTokenHandler(service)
+
+	return AccessTokenModule{
+		Handler:    handler,
+		Service:    service,
+		Repository: repository,
+	}
+}
+
+func (module AccessTokenModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.With(middleware.AuthMiddleware(&config)).Route(
+		"/access-tokens",
+		func(router chi.Router) {
+			router.Get("/", module.Handler.ListTokens)
+			router.Post("/", module.Handler.CreateToken)
+			router.Delete("/{id}", module.Handler.RevokeToken)
+		},
+	)
+}
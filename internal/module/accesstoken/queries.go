@@ -0,0 +1,20 @@
+package accesstoken
+
+const (
+	QueryListTokensByUser = `
+	SELECT id, name, token_prefix, permissions, expires_at, last_used_at, revoked_at, created_at
+	FROM personal_access_tokens
+	WHERE user_id = ?
+	ORDER BY created_at DESC;`
+	QueryCreateToken = `
+	INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, permissions, expires_at, token_version)
+	VALUES (?, ?, ?, ?, ?, ?, COALESCE((SELECT version FROM user_token_versions WHERE user_id = ?), 0));`
+	QueryGetTokenOwner = `
+	SELECT user_id
+	FROM personal_access_tokens
+	WHERE id = ? AND revoked_at IS NULL;`
+	QueryRevokeToken = `
+	UPDATE personal_access_tokens
+	SET revoked_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND revoked_at IS NULL;`
+)
@@ -0,0 +1,110 @@
+package accesstoken
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/module/accesstoken/models"
+	"bloggo/internal/utils/apierrors"
+	"database/sql"
+	"strings"
+)
+
+type AccessTokenRepository struct {
+	database db.Executor
+}
+
+func NewAccessTokenRepository(database *sql.DB) AccessTokenRepository {
+	return AccessTokenRepository{
+		database,
+	}
+}
+
+// WithTx returns the repository running in the transaction
+func (repository *AccessTokenRepository) WithTx(tx *sql.Tx) AccessTokenRepository {
+	return AccessTokenRepository{
+		tx,
+	}
+}
+
+func (repository *AccessTokenRepository) ListTokensByUser(
+	userId int64,
+) ([]models.ResponseAccessToken, error) {
+	rows, err := repository.database.Query(QueryListTokensByUser, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.ResponseAccessToken{}
+	for rows.Next() {
+		var token models.ResponseAccessToken
+		var permissions string
+		if err := rows.Scan(
+			&token.Id,
+			&token.Name,
+			&token.Prefix,
+			&permissions,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		token.Permissions = []string{}
+		if permissions != "" {
+			token.Permissions = strings.Split(permissions, ",")
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Stores the token with the owner's current token version, bumping the
+// version revokes it.
+func (repository *AccessTokenRepository) CreateToken(
+	userId int64,
+	model *models.RequestAccessTokenCreate,
+	prefix string,
+	hash string,
+) (int64, error) {
+	var expiresAt *string
+	if model.ExpiresAt != nil {
+		formatted := db.FormatTimestamp(*model.ExpiresAt)
+		expiresAt = &formatted
+	}
+
+	result, err := repository.database.Exec(
+		QueryCreateToken,
+		userId,
+		model.Name,
+		prefix,
+		hash,
+		strings.Join(model.Permissions, ","),
+		expiresAt,
+		userId,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Returns ErrNotFound for unknown or already revoked tokens.
+func (repository *AccessTokenRepository) GetTokenOwner(tokenId int64) (int64, error) {
+	var userId int64
+	err := repository.database.QueryRow(QueryGetTokenOwner, tokenId).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return 0, apierrors.ErrNotFound
+	}
+	return userId, err
+}
+
+func (repository *AccessTokenRepository) RevokeToken(tokenId int64) error {
+	_, err := repository.database.Exec(QueryRevokeToken, tokenId)
+	return err
+}
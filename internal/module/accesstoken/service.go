@@ -0,0 +1,122 @@
+package accesstoken
+
+import (
+	"bloggo/internal/infrastructure/accesstokens"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/module/accesstoken/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/cryptography"
+	"time"
+)
+
+// Characters of the token kept in clear text to tell tokens apart
+const prefixLength = 12
+
+type AccessTokenService struct {
+	repository  AccessTokenRepository
+	permissions permissions.Store
+	outbox      *outbox.Outbox
+}
+
+func NewAccessTokenService(
+	repository AccessTokenRepository,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) AccessTokenService {
+	return AccessTokenService{
+		repository,
+		permissions,
+		outbox,
+	}
+}
+
+func (service *AccessTokenService) ListTokens(
+	userId int64,
+) ([]models.ResponseAccessToken, error) {
+	return service.repository.ListTokensByUser(userId)
+}
+
+// Creates a token limited to the given permissions, all of which the
+// user's role must have. Tokens cannot be used to create other tokens.
+func (service *AccessTokenService) CreateToken(
+	model *models.RequestAccessTokenCreate,
+	userId int64,
+	userRoleId int64,
+	auditContext auditcontext.Context,
+) (*models.ResponseAccessTokenCreated, error) {
+	for _, permission := range model.Permissions {
+		if !service.permissions.HasPermission(userRoleId, permission) {
+			return nil, apierrors.ErrForbidden
+		}
+	}
+	if model.ExpiresAt != nil && model.ExpiresAt.Before(time.Now()) {
+		return nil, apierrors.ErrBadRequest
+	}
+
+	secret, err := cryptography.GenerateRandomHS256Secret()
+	if err != nil {
+		return nil, err
+	}
+	rawToken := accesstokens.TokenPrefix + secret
+
+	var id int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.CreateToken(
+			userId,
+			model,
+			rawToken[:prefixLength],
+			cryptography.HashString(rawToken),
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.AccessTokenCreated{
+			ActorID: userId,
+			Context: auditContext,
+			TokenID: id,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseAccessTokenCreated{
+		Id:    id,
+		Token: rawToken,
+	}, nil
+}
+
+// Revokes an own token, or any token with the session:manage permission.
+func (service *AccessTokenService) RevokeToken(
+	tokenId int64,
+	userId int64,
+	userRoleId int64,
+	auditContext auditcontext.Context,
+) error {
+	ownerId, err := service.repository.GetTokenOwner(tokenId)
+	if err != nil {
+		return err
+	}
+	if ownerId != userId && !service.permissions.HasPermission(userRoleId, "session:manage") {
+		return apierrors.ErrNotFound
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.RevokeToken(tokenId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.AccessTokenRevoked{
+			ActorID: userId,
+			Context: auditContext,
+			TokenID: tokenId,
+		})
+	})
+}
@@ -0,0 +1,144 @@
+package account
+
+import (
+	"bloggo/internal/module/account/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type AccountHandler struct {
+	service AccountService
+}
+
+func NewAccountHandler(service AccountService) AccountHandler {
+	return AccountHandler{
+		service,
+	}
+}
+
+// Shared mapping for reset and invitation tokens
+var invalidTokenMapping = apierrors.HTTPErrorMapping{
+	apierrors.ErrNotFound: {
+		Message: "This link is invalid or has expired.",
+		Status:  http.StatusBadRequest,
+	},
+}
+
+func (handler *AccountHandler) RequestPasswordReset(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	body, ok := handlers.BindAndValidate[*models.RequestPasswordReset](writer, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.service.RequestPasswordReset(body.Email, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	// Same answer whether or not the email exists
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+func (handler *AccountHandler) ResetPassword(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	body, ok := handlers.BindAndValidate[*models.RequestSetPassphrase](writer, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.service.ResetPassword(body, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, invalidTokenMapping)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *AccountHandler) AcceptInvitation(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	body, ok := handlers.BindAndValidate[*models.RequestSetPassphrase](writer, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.service.AcceptInvitation(body, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, invalidTokenMapping)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *AccountHandler) Invite(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestInvitation](writer, request)
+	if !ok {
+		return
+	}
+
+	created, err := handler.service.Invite(body, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(created)
+}
+
+func (handler *AccountHandler) ResendInvitation(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	targetUserId, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := handler.service.ResendInvitation(targetUserId, userRoleId, userId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "The user has already set a passphrase.",
+				Status:  http.StatusConflict,
+			},
+			apierrors.ErrServiceUnavailable: {
+				Message: "The invitation email could not be sent.",
+				Status:  http.StatusServiceUnavailable,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,17 @@
+package models
+
+import "database/sql"
+
+// Purposes of emailed single-use tokens
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeInvitation    = "invitation"
+)
+
+// -- Account Looked Up For Emails -- //
+type Account struct {
+	Id             int64
+	Name           string
+	Email          string
+	PassphraseHash sql.NullString
+}
@@ -0,0 +1,19 @@
+package models
+
+// -- Request Passphrase Reset Email -- //
+type RequestPasswordReset struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// -- Set Passphrase With Emailed Token -- //
+type RequestSetPassphrase struct {
+	Token      string `json:"token" validate:"required,max=128"`
+	Passphrase string `json:"passphrase" validate:"required,min=12,max=100"`
+}
+
+// -- Invite New User -- //
+type RequestInvitation struct {
+	Name   string `json:"name" validate:"required,min=3,max=100"`
+	Email  string `json:"email" validate:"required,email,min=5,max=255"`
+	RoleId int64  `json:"roleId" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// -- Invited User, With Whether The Email Went Out -- //
+type ResponseInvitation struct {
+	Id             int64   `json:"id"`
+	InvitationSent bool    `json:"invitationSent"`
+	Message        *string `json:"message,omitempty"`
+}
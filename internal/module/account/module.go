@@ -0,0 +1,65 @@
+package account
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/mailer"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/infrastructure/tokens"
+	"bloggo/internal/infrastructure/tokenversions"
+	"bloggo/internal/middleware"
+	"log"
+
+	"github.com/go-chi/chi"
+)
+
+type AccountModule struct {
+	Handler    AccountHandler
+	Service    AccountService
+	Repository AccountRepository
+}
+
+func NewModule() AccountModule {
+	database := db.Get()
+	config := config.Get()
+
+	repository := NewAccountRepository(database)
+	service := NewAccountService(
+		repository,
+		mailer.Get(),
+		&config,
+		tokens.GetStore(),
+		tokenversions.Get(),
+		loginguard.GetAccountStore(),
+		permissions.Get(),
+		outbox.Get(),
+	)
+	handler := NewAccountHandler(service)
+
+	if err := service.Cleanup(); err != nil {
+		log.Printf("Failed to delete expired account tokens: %v", err)
+	}
+
+	return AccountModule{
+		Handler:    handler,
+		Service:    service,
+		Repository: repository,
+	}
+}
+
+func (module AccountModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.Route("/account", func(router chi.Router) {
+		router.Post("/password-reset", module.Handler.RequestPasswordReset)
+		router.Post("/password-reset/confirm", module.Handler.ResetPassword)
+		router.Post("/invitations/accept", module.Handler.AcceptInvitation)
+
+		router.With(middleware.AuthMiddleware(&config)).Group(func(router chi.Router) {
+			router.Post("/invitations", module.Handler.Invite)
+			router.Post("/invitations/{id}/resend", module.Handler.ResendInvitation)
+		})
+	})
+}
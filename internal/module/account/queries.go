@@ -0,0 +1,38 @@
+package account
+
+const (
+	QueryGetAccountByEmail = `
+	SELECT id, name, email, passphrase_hash
+	FROM users
+	WHERE email = ? AND deleted_at IS NULL;`
+	QueryGetAccountById = `
+	SELECT id, name, email, passphrase_hash
+	FROM users
+	WHERE id = ? AND deleted_at IS NULL;`
+	QueryCreateInvitedUser = `
+	INSERT INTO users (name, email, passphrase_hash, role_id)
+	VALUES (?, ?, NULL, ?);`
+	QueryDeleteUnusedTokens = `
+	DELETE FROM user_action_tokens
+	WHERE user_id = ? AND purpose = ? AND used_at IS NULL;`
+	QueryInsertToken = `
+	INSERT INTO user_action_tokens (user_id, purpose, token_hash, expires_at)
+	VALUES (?, ?, ?, datetime('now', ?));`
+	QueryConsumeToken = `
+	UPDATE user_action_tokens
+	SET used_at = CURRENT_TIMESTAMP
+	WHERE token_hash = ?
+		AND purpose = ?
+		AND used_at IS NULL
+		AND expires_at > CURRENT_TIMESTAMP
+	RETURNING user_id;`
+	QuerySetPassphrase = `
+	UPDATE users
+	SET
+		passphrase_hash = ?,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND deleted_at IS NULL;`
+	QueryDeleteExpiredTokens = `
+	DELETE FROM user_action_tokens
+	WHERE expires_at <= CURRENT_TIMESTAMP OR used_at IS NOT NULL;`
+)
@@ -0,0 +1,172 @@
+package account
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/module/account/models"
+	"bloggo/internal/utils/apierrors"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AccountRepository struct {
+	database db.Executor
+}
+
+func NewAccountRepository(database *sql.DB) AccountRepository {
+	return AccountRepository{
+		database,
+	}
+}
+
+// WithTx returns the repository running in the transaction
+func (repository *AccountRepository) WithTx(tx *sql.Tx) AccountRepository {
+	return AccountRepository{
+		tx,
+	}
+}
+
+// Returns nil without error if no active user has the email.
+func (repository *AccountRepository) GetAccountByEmail(
+	email string,
+) (*models.Account, error) {
+	return repository.getAccount(QueryGetAccountByEmail, email)
+}
+
+func (repository *AccountRepository) GetAccountById(
+	userId int64,
+) (*models.Account, error) {
+	return repository.getAccount(QueryGetAccountById, userId)
+}
+
+func (repository *AccountRepository) getAccount(
+	query string,
+	arg any,
+) (*models.Account, error) {
+	var account models.Account
+	err := repository.database.QueryRow(query, arg).Scan(
+		&account.Id,
+		&account.Name,
+		&account.Email,
+		&account.PassphraseHash,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Creates a user without a passphrase together with its invitation token.
+func (repository *AccountRepository) CreateInvitedUser(
+	model *models.RequestInvitation,
+	tokenHash string,
+	lifetime time.Duration,
+) (int64, error) {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(QueryCreateInvitedUser, model.Name, model.Email, model.RoleId)
+	if err != nil {
+		return 0, err
+	}
+
+	userId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertToken(tx, userId, models.PurposeInvitation, tokenHash, lifetime); err != nil {
+		return 0, err
+	}
+
+	return userId, tx.Commit()
+}
+
+// Stores a new token, replacing unused tokens of the same purpose.
+func (repository *AccountRepository) ReplaceToken(
+	userId int64,
+	purpose string,
+	tokenHash string,
+	lifetime time.Duration,
+) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertToken(tx, userId, purpose, tokenHash, lifetime); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Marks the token as used and sets the passphrase in a single transaction.
+// Returns ErrNotFound if the token is unknown, expired or already used.
+func (repository *AccountRepository) ConsumeTokenAndSetPassphrase(
+	tokenHash string,
+	purpose string,
+	passphraseHash string,
+) (int64, error) {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userId int64
+	err = tx.QueryRow(QueryConsumeToken, tokenHash, purpose).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return 0, apierrors.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(QuerySetPassphrase, passphraseHash, userId)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, apierrors.ErrNotFound
+	}
+
+	return userId, tx.Commit()
+}
+
+func (repository *AccountRepository) DeleteExpiredTokens() error {
+	_, err := repository.database.Exec(QueryDeleteExpiredTokens)
+	return err
+}
+
+func insertToken(
+	tx db.Executor,
+	userId int64,
+	purpose string,
+	tokenHash string,
+	lifetime time.Duration,
+) error {
+	if _, err := tx.Exec(QueryDeleteUnusedTokens, userId, purpose); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		QueryInsertToken,
+		userId,
+		purpose,
+		tokenHash,
+		fmt.Sprintf("+%d seconds", int64(lifetime.Seconds())),
+	)
+	return err
+}
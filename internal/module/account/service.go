@@ -0,0 +1,339 @@
+package account
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/background"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/mailer"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/infrastructure/tokens"
+	"bloggo/internal/infrastructure/tokenversions"
+	"bloggo/internal/module/account/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/cryptography"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// How long an emailed passphrase reset link stays valid
+	PasswordResetLifetime = time.Hour
+	// How long an invitation link stays valid
+	InvitationLifetime = 7 * 24 * time.Hour
+)
+
+type AccountService struct {
+	repository    AccountRepository
+	mailer        mailer.Mailer
+	config        *config.Config
+	refreshStore  tokens.Store
+	tokenVersions tokenversions.Store
+	accountGuard  loginguard.Store
+	permissions   permissions.Store
+	outbox        *outbox.Outbox
+}
+
+func NewAccountService(
+	repository AccountRepository,
+	mailer mailer.Mailer,
+	config *config.Config,
+	refreshStore tokens.Store,
+	tokenVersions tokenversions.Store,
+	accountGuard loginguard.Store,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) AccountService {
+	return AccountService{
+		repository,
+		mailer,
+		config,
+		refreshStore,
+		tokenVersions,
+		accountGuard,
+		permissions,
+		outbox,
+	}
+}
+
+// Emails a reset link if the address belongs to a user. Unknown addresses
+// are ignored silently so the endpoint cannot be used to discover accounts.
+func (service *AccountService) RequestPasswordReset(email string, auditContext auditcontext.Context) error {
+	account, err := service.repository.GetAccountByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return nil
+	}
+
+	token := cryptography.GenerateUniqueId()
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.ReplaceToken(
+			account.Id,
+			models.PurposePasswordReset,
+			cryptography.HashString(token),
+			PasswordResetLifetime,
+		); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.PasswordResetRequested{
+			UserID:  account.Id,
+			Context: auditContext,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sending in the background keeps response times equal for unknown
+	// emails, shutdown waits for the email to go out
+	message := mailer.Message{
+		To:      account.Email,
+		Subject: "Reset your Bloggo passphrase",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n"+
+				"Someone asked to reset the passphrase of your Bloggo account. "+
+				"Open the link below within %d minutes to choose a new one:\n\n%s\n\n"+
+				"If this wasn't you, you can ignore this email.\n",
+			account.Name,
+			int(PasswordResetLifetime.Minutes()),
+			service.link("/reset-password", token),
+		),
+	}
+	background.Go(func() {
+		service.send(message)
+	})
+
+	return nil
+}
+
+// Sets a new passphrase using a reset token and logs the user out everywhere.
+func (service *AccountService) ResetPassword(
+	model *models.RequestSetPassphrase,
+	auditContext auditcontext.Context,
+) error {
+	return service.setPassphrase(model, models.PurposePasswordReset, func(userId int64) interface{} {
+		return events.PasswordReset{
+			UserID:  userId,
+			Context: auditContext,
+		}
+	})
+}
+
+// Creates a user without a passphrase and emails an invitation link.
+func (service *AccountService) Invite(
+	model *models.RequestInvitation,
+	userRoleId int64,
+	invitedBy int64,
+	auditContext auditcontext.Context,
+) (*models.ResponseInvitation, error) {
+	if !service.permissions.HasPermission(userRoleId, "user:register") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	token := cryptography.GenerateUniqueId()
+	var userId int64
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		userId, err = repository.CreateInvitedUser(
+			model,
+			cryptography.HashString(token),
+			InvitationLifetime,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserCreated{
+			ActorID: invitedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Name:    model.Name,
+			Email:   model.Email,
+			Invited: true,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The user is created either way, a failed email can be resent
+	if err := service.mailer.Send(service.invitationMessage(model.Name, model.Email, token)); err != nil {
+		log.Printf("Failed to send invitation to user %d: %v", userId, err)
+		message := fmt.Sprintf(
+			"The user was created but the invitation email could not be sent, resend it with POST /internal/account/invitations/%d/resend.",
+			userId,
+		)
+		return &models.ResponseInvitation{
+			Id:      userId,
+			Message: &message,
+		}, nil
+	}
+
+	return &models.ResponseInvitation{
+		Id:             userId,
+		InvitationSent: true,
+	}, nil
+}
+
+// Sends a fresh invitation to a user that has not set a passphrase yet.
+// Earlier invitation links stop working.
+func (service *AccountService) ResendInvitation(
+	userId int64,
+	userRoleId int64,
+	invitedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "user:register") {
+		return apierrors.ErrForbidden
+	}
+
+	account, err := service.repository.GetAccountById(userId)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return apierrors.ErrNotFound
+	}
+	if account.PassphraseHash.Valid {
+		return apierrors.ErrConflict
+	}
+
+	token := cryptography.GenerateUniqueId()
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.ReplaceToken(
+			userId,
+			models.PurposeInvitation,
+			cryptography.HashString(token),
+			InvitationLifetime,
+		); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.InvitationResent{
+			ActorID: invitedBy,
+			Context: auditContext,
+			UserID:  userId,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := service.mailer.Send(service.invitationMessage(account.Name, account.Email, token)); err != nil {
+		log.Printf("Failed to send invitation to user %d: %v", userId, err)
+		return apierrors.ErrServiceUnavailable
+	}
+
+	return nil
+}
+
+// Sets the first passphrase of an invited user.
+func (service *AccountService) AcceptInvitation(
+	model *models.RequestSetPassphrase,
+	auditContext auditcontext.Context,
+) error {
+	return service.setPassphrase(model, models.PurposeInvitation, func(userId int64) interface{} {
+		return events.InvitationAccepted{
+			UserID:  userId,
+			Context: auditContext,
+		}
+	})
+}
+
+// Removes used and expired tokens.
+func (service *AccountService) Cleanup() error {
+	return service.repository.DeleteExpiredTokens()
+}
+
+// Consumes the token and sets the passphrase, publishing the event built
+// for the token's user in the same transaction
+func (service *AccountService) setPassphrase(
+	model *models.RequestSetPassphrase,
+	purpose string,
+	event func(userId int64) interface{},
+) error {
+	passphraseHash, err := cryptography.HashPassphrase(model.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	var userId int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		userId, err = repository.ConsumeTokenAndSetPassphrase(
+			cryptography.HashString(strings.TrimSpace(model.Token)),
+			purpose,
+			passphraseHash,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(event(userId))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Log out everywhere and lift a lockout caused by the forgotten passphrase
+	service.refreshStore.DeleteByUser(userId, "")
+	if _, err := service.tokenVersions.Bump(userId); err != nil {
+		return err
+	}
+
+	account, err := service.repository.GetAccountById(userId)
+	if err != nil {
+		return err
+	}
+	if account != nil {
+		service.accountGuard.Reset(strings.ToLower(account.Email))
+	}
+
+	return nil
+}
+
+func (service *AccountService) invitationMessage(
+	name string,
+	email string,
+	token string,
+) mailer.Message {
+	return mailer.Message{
+		To:      email,
+		Subject: "You're invited to Bloggo",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n"+
+				"An account was created for you on Bloggo. "+
+				"Open the link below within %d days to choose your passphrase:\n\n%s\n",
+			name,
+			int(InvitationLifetime.Hours()/24),
+			service.link("/accept-invitation", token),
+		),
+	}
+}
+
+func (service *AccountService) link(path string, token string) string {
+	return fmt.Sprintf(
+		"%s%s?token=%s",
+		service.config.PublicURL,
+		path,
+		url.QueryEscape(token),
+	)
+}
+
+func (service *AccountService) send(message mailer.Message) {
+	if err := service.mailer.Send(message); err != nil {
+		log.Printf("Failed to send email: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package authors
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/middleware"
 
 	"github.com/go-chi/chi"
@@ -32,8 +33,11 @@ func (module AuthorsAPIModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/api/authors", func(r chi.Router) {
-		// All endpoints require trusted frontend header
-		r.Use(middleware.TrustedFrontendMiddleware(&config))
+		// All endpoints require an API key with the authors:read scope
+		r.Use(
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeAuthorsRead),
+			middleware.RateLimit(middleware.PolicyPublicAPI),
+		)
 
 		r.Get("/", module.Handler.ListAuthors)
 		r.Get("/{id}", module.Handler.GetAuthorById)
@@ -3,6 +3,7 @@ package categories
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/middleware"
 
 	"github.com/go-chi/chi"
@@ -32,8 +33,11 @@ func (module CategoriesAPIModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/api/categories", func(r chi.Router) {
-		// All endpoints require trusted frontend header
-		r.Use(middleware.TrustedFrontendMiddleware(&config))
+		// All endpoints require an API key with the categories:read scope
+		r.Use(
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeCategoriesRead),
+			middleware.RateLimit(middleware.PolicyPublicAPI),
+		)
 
 		r.Get("/", module.Handler.ListCategories)
 		r.Get("/{slug}", module.Handler.GetCategoryBySlug)
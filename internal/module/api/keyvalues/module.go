@@ -3,6 +3,7 @@ package keyvalues
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/middleware"
 
 	"github.com/go-chi/chi"
@@ -32,8 +33,11 @@ func (module KeyValuesAPIModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/api/key-values", func(r chi.Router) {
-		// All endpoints require trusted frontend header
-		r.Use(middleware.TrustedFrontendMiddleware(&config))
+		// All endpoints require an API key with the keyvalues:read scope
+		r.Use(
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeKeyValuesRead),
+			middleware.RateLimit(middleware.PolicyPublicAPI),
+		)
 
 		r.Get("/", module.Handler.ListKeyValues)
 	})
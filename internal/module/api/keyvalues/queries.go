@@ -1,19 +1,21 @@
 package keyvalues
 
+// Audit log checkpoints are never served
 const (
 	QueryAPIGetAllKeyValues = `
 	SELECT key, value
 	FROM key_value_store
+	WHERE key NOT GLOB 'audit.checkpoint.*'
 	ORDER BY key ASC;`
 
 	QueryAPIGetKeyValueByKey = `
 	SELECT key, value
 	FROM key_value_store
-	WHERE key = ?;`
+	WHERE key = ? AND key NOT GLOB 'audit.checkpoint.*';`
 
 	QueryAPIGetKeyValuesStartingWith = `
 	SELECT key, value
 	FROM key_value_store
-	WHERE key LIKE ?
+	WHERE key LIKE ? AND key NOT GLOB 'audit.checkpoint.*'
 	ORDER BY key ASC;`
 )
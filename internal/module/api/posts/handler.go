@@ -76,7 +76,7 @@ func (h *PostsAPIHandler) TrackPostView(writer http.ResponseWriter, request *htt
 		return
 	}
 
-	err := h.service.TrackView(slug, body.UserAgent)
+	err := h.service.TrackView(slug, body.UserAgent, handlers.GetClientIP(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -3,6 +3,7 @@ package posts
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/middleware"
 
 	"github.com/go-chi/chi"
@@ -32,12 +33,21 @@ func (module PostsAPIModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/api/posts", func(r chi.Router) {
-		// All endpoints require trusted frontend header
-		r.Use(middleware.TrustedFrontendMiddleware(&config))
-
-		r.Get("/", module.Handler.ListPublishedPosts)
-		r.Get("/views", module.Handler.GetAllViewCounts)
-		r.Get("/{slug}", module.Handler.GetPublishedPostBySlug)
-		r.Post("/{slug}/view", module.Handler.TrackPostView)
+		// All endpoints require an API key with the matching scope
+		r.Group(func(r chi.Router) {
+			r.Use(
+				middleware.APIKeyMiddleware(&config, apikeys.ScopePostsRead),
+				middleware.RateLimit(middleware.PolicyPublicAPI),
+			)
+
+			r.Get("/", module.Handler.ListPublishedPosts)
+			r.Get("/views", module.Handler.GetAllViewCounts)
+			r.Get("/{slug}", module.Handler.GetPublishedPostBySlug)
+		})
+
+		r.With(
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeViewsWrite),
+			middleware.RateLimit(middleware.PolicyTrackView),
+		).Post("/{slug}/view", module.Handler.TrackPostView)
 	})
 }
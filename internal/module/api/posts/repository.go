@@ -227,7 +227,7 @@ func (r *PostsAPIRepository) GetPostTagsBySlug(slug string) ([]models.APITag, er
 	return r.GetPostTags(postId)
 }
 
-func (r *PostsAPIRepository) TrackView(slug string, userAgent string) error {
+func (r *PostsAPIRepository) TrackView(slug string, userAgent string, ip string) error {
 	// Get post ID from slug
 	var postId int64
 	row := r.database.QueryRow(`
@@ -251,7 +251,7 @@ func (r *PostsAPIRepository) TrackView(slug string, userAgent string) error {
 	}
 
 	// Insert view record
-	_, err = transaction.Exec(`INSERT INTO post_views (post_id, user_agent) VALUES (?, ?)`, postId, userAgent)
+	_, err = transaction.Exec(`INSERT INTO post_views (post_id, user_agent, ip_address) VALUES (?, ?, ?)`, postId, userAgent, ip)
 	if err != nil {
 		transaction.Rollback()
 		return err
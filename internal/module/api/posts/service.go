@@ -28,8 +28,8 @@ func (service *PostsAPIService) GetPublishedPostBySlug(slug string) (*models.API
 	return service.repository.GetPublishedPostBySlug(slug)
 }
 
-func (service *PostsAPIService) TrackView(slug string, userAgent string) error {
-	return service.repository.TrackView(slug, userAgent)
+func (service *PostsAPIService) TrackView(slug string, userAgent string, ip string) error {
+	return service.repository.TrackView(slug, userAgent, ip)
 }
 
 func (service *PostsAPIService) GetAllViewCounts() (map[string]int64, error) {
@@ -3,6 +3,7 @@ package tags
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/middleware"
 
 	"github.com/go-chi/chi"
@@ -32,8 +33,11 @@ func (module TagsAPIModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/api/tags", func(r chi.Router) {
-		// All endpoints require trusted frontend header
-		r.Use(middleware.TrustedFrontendMiddleware(&config))
+		// All endpoints require an API key with the tags:read scope
+		r.Use(
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeTagsRead),
+			middleware.RateLimit(middleware.PolicyPublicAPI),
+		)
 
 		r.Get("/", module.Handler.ListTags)
 		r.Get("/{slug}", module.Handler.GetTagBySlug)
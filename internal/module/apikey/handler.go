@@ -0,0 +1,149 @@
+package apikey
+
+import (
+	"bloggo/internal/module/apikey/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type APIKeyHandler struct {
+	service APIKeyService
+}
+
+func NewAPIKeyHandler(service APIKeyService) APIKeyHandler {
+	return APIKeyHandler{
+		service,
+	}
+}
+
+func (handler *APIKeyHandler) ListKeys(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keys, err := handler.service.ListKeys(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(keys)
+}
+
+func (handler *APIKeyHandler) ListScopes(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	scopes, err := handler.service.ListScopes(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(scopes)
+}
+
+func (handler *APIKeyHandler) CreateKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestAPIKeyCreate](writer, request)
+	if !ok {
+		return
+	}
+
+	created, err := handler.service.CreateKey(body, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrBadRequest: {
+				Message: "Expiry must be in the future.",
+				Status:  http.StatusBadRequest,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(created)
+}
+
+func (handler *APIKeyHandler) UpdateKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keyId, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestAPIKeyUpdate](writer, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.service.UpdateKey(keyId, body, userRoleId, userId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *APIKeyHandler) RevokeKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keyId, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := handler.service.RevokeKey(keyId, userRoleId, userId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// -- Create API Key -- //
+type RequestAPIKeyCreate struct {
+	Name      string     `json:"name" validate:"required,min=3,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=posts:read views:write categories:read tags:read authors:read keyvalues:read"`
+	RateLimit int        `json:"rateLimit,omitempty" validate:"omitempty,min=1,max=100000"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// -- Update API Key -- //
+type RequestAPIKeyUpdate struct {
+	Name      *string    `json:"name,omitempty" validate:"omitempty,min=3,max=100"`
+	Scopes    []string   `json:"scopes,omitempty" validate:"omitempty,min=1,dive,oneof=posts:read views:write categories:read tags:read authors:read keyvalues:read"`
+	RateLimit *int       `json:"rateLimit,omitempty" validate:"omitempty,min=0,max=100000"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
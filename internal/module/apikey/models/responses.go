@@ -0,0 +1,20 @@
+package models
+
+// -- API Key Details, Never Including The Key -- //
+type ResponseAPIKey struct {
+	Id         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	RateLimit  int      `json:"rateLimit"`
+	ExpiresAt  *string  `json:"expiresAt,omitempty"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	RevokedAt  *string  `json:"revokedAt,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+// -- Created API Key, Only Shown Once -- //
+type ResponseAPIKeyCreated struct {
+	Id  int64  `json:"id"`
+	Key string `json:"key"`
+}
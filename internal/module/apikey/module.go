@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type APIKeyModule struct {
+	Handler    APIKeyHandler
+	Service    APIKeyService
+	Repository APIKeyRepository
+}
+
+func NewModule() APIKeyModule {
+	database := db.Get()
+
+	repository := NewAPIKeyRepository(database)
+	service := NewAPIKeyService(repository, apikeys.Get(), permissions.Get(), outbox.Get())
+	handler := NewAPIKeyHandler(service)
+
+	return APIKeyModule{
+		Handler:    handler,
+		Service:    service,
+		Repository: repository,
+	}
+}
+
+func (module APIKeyModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.With(middleware.AuthMiddleware(&config)).Route(
+		"/api-keys",
+		func(router chi.Router) {
+			router.Get("/", module.Handler.ListKeys)
+			router.Get("/scopes", module.Handler.ListScopes)
+			router.Post("/", module.Handler.CreateKey)
+			router.Patch("/{id}", module.Handler.UpdateKey)
+			router.Delete("/{id}", module.Handler.RevokeKey)
+		},
+	)
+}
@@ -0,0 +1,30 @@
+package apikey
+
+const (
+	QueryListKeys = `
+	SELECT id, name, key_prefix, scopes, rate_limit, expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys
+	ORDER BY created_at DESC;`
+	QueryGetKey = `
+	SELECT id, name, key_prefix, scopes, rate_limit, expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys
+	WHERE id = ?;`
+	QueryCreateKey = `
+	INSERT INTO api_keys (name, key_prefix, key_hash, scopes, rate_limit, expires_at, created_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?);`
+	QueryUpdateKey = `
+	UPDATE api_keys
+	SET
+		name = COALESCE(?, name),
+		scopes = COALESCE(?, scopes),
+		rate_limit = COALESCE(?, rate_limit),
+		expires_at = COALESCE(?, expires_at),
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND revoked_at IS NULL;`
+	QueryRevokeKey = `
+	UPDATE api_keys
+	SET
+		revoked_at = CURRENT_TIMESTAMP,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = ? AND revoked_at IS NULL;`
+)
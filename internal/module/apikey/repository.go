@@ -0,0 +1,159 @@
+package apikey
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/module/apikey/models"
+	"bloggo/internal/utils/apierrors"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type APIKeyRepository struct {
+	database db.Executor
+}
+
+func NewAPIKeyRepository(database *sql.DB) APIKeyRepository {
+	return APIKeyRepository{
+		database,
+	}
+}
+
+// WithTx returns the repository running in the transaction
+func (repository *APIKeyRepository) WithTx(tx *sql.Tx) APIKeyRepository {
+	return APIKeyRepository{
+		tx,
+	}
+}
+
+func (repository *APIKeyRepository) ListKeys() ([]models.ResponseAPIKey, error) {
+	rows, err := repository.database.Query(QueryListKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.ResponseAPIKey{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (repository *APIKeyRepository) GetKey(keyId int64) (*models.ResponseAPIKey, error) {
+	key, err := scanKey(repository.database.QueryRow(QueryGetKey, keyId))
+	if err == sql.ErrNoRows {
+		return nil, apierrors.ErrNotFound
+	}
+	return key, err
+}
+
+func (repository *APIKeyRepository) CreateKey(
+	model *models.RequestAPIKeyCreate,
+	prefix string,
+	hash string,
+	createdBy int64,
+) (int64, error) {
+	result, err := repository.database.Exec(
+		QueryCreateKey,
+		model.Name,
+		prefix,
+		hash,
+		strings.Join(model.Scopes, ","),
+		model.RateLimit,
+		formatTimestamp(model.ExpiresAt),
+		createdBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (repository *APIKeyRepository) UpdateKey(
+	keyId int64,
+	model *models.RequestAPIKeyUpdate,
+) error {
+	var scopes *string
+	if model.Scopes != nil {
+		joined := strings.Join(model.Scopes, ",")
+		scopes = &joined
+	}
+
+	result, err := repository.database.Exec(
+		QueryUpdateKey,
+		model.Name,
+		scopes,
+		model.RateLimit,
+		formatTimestamp(model.ExpiresAt),
+		keyId,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (repository *APIKeyRepository) RevokeKey(keyId int64) error {
+	result, err := repository.database.Exec(QueryRevokeKey, keyId)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanKey(row rowScanner) (*models.ResponseAPIKey, error) {
+	var key models.ResponseAPIKey
+	var scopes string
+	if err := row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.RateLimit,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	key.Scopes = []string{}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	return &key, nil
+}
+
+// Stores times in the same layout as CURRENT_TIMESTAMP so they compare
+func formatTimestamp(moment *time.Time) *string {
+	if moment == nil {
+		return nil
+	}
+	formatted := db.FormatTimestamp(*moment)
+	return &formatted
+}
+
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apierrors.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package apikey
+
+import (
+	"bloggo/internal/infrastructure/apikeys"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/module/apikey/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/cryptography"
+	"time"
+)
+
+// Characters of the key kept in clear text to tell keys apart
+const prefixLength = 12
+
+type APIKeyService struct {
+	repository  APIKeyRepository
+	store       apikeys.Store
+	permissions permissions.Store
+	outbox      *outbox.Outbox
+}
+
+func NewAPIKeyService(
+	repository APIKeyRepository,
+	store apikeys.Store,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) APIKeyService {
+	return APIKeyService{
+		repository,
+		store,
+		permissions,
+		outbox,
+	}
+}
+
+func (service *APIKeyService) ListKeys(
+	userRoleId int64,
+) ([]models.ResponseAPIKey, error) {
+	if !service.permissions.HasPermission(userRoleId, "apikey:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return service.repository.ListKeys()
+}
+
+func (service *APIKeyService) ListScopes(userRoleId int64) ([]string, error) {
+	if !service.permissions.HasPermission(userRoleId, "apikey:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return apikeys.AllScopes, nil
+}
+
+// Creates a key and returns it in clear text. Only its hash is stored.
+func (service *APIKeyService) CreateKey(
+	model *models.RequestAPIKeyCreate,
+	userRoleId int64,
+	createdBy int64,
+	auditContext auditcontext.Context,
+) (*models.ResponseAPIKeyCreated, error) {
+	if !service.permissions.HasPermission(userRoleId, "apikey:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+	if model.ExpiresAt != nil && model.ExpiresAt.Before(time.Now()) {
+		return nil, apierrors.ErrBadRequest
+	}
+
+	secret, err := cryptography.GenerateRandomHS256Secret()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := apikeys.KeyPrefix + secret
+
+	var id int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.CreateKey(
+			model,
+			rawKey[:prefixLength],
+			cryptography.HashString(rawKey),
+			createdBy,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.APIKeyCreated{
+			ActorID: createdBy,
+			Context: auditContext,
+			KeyID:   id,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseAPIKeyCreated{
+		Id:  id,
+		Key: rawKey,
+	}, nil
+}
+
+func (service *APIKeyService) UpdateKey(
+	keyId int64,
+	model *models.RequestAPIKeyUpdate,
+	userRoleId int64,
+	updatedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "apikey:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		before, err := repository.GetKey(keyId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.UpdateKey(keyId, model); err != nil {
+			return err
+		}
+
+		after, err := repository.GetKey(keyId)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.APIKeyUpdated{
+			ActorID: updatedBy,
+			Context: auditContext,
+			KeyID:   keyId,
+			Before:  keySnapshot(before),
+			After:   keySnapshot(after),
+		})
+	})
+}
+
+// Revokes the key immediately, without a redeploy.
+func (service *APIKeyService) RevokeKey(
+	keyId int64,
+	userRoleId int64,
+	revokedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "apikey:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		before, err := repository.GetKey(keyId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.RevokeKey(keyId); err != nil {
+			return err
+		}
+
+		after, err := repository.GetKey(keyId)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.APIKeyRevoked{
+			ActorID: revokedBy,
+			Context: auditContext,
+			KeyID:   keyId,
+			Before:  keySnapshot(before),
+			After:   keySnapshot(after),
+		})
+	})
+}
+
+// The audited fields of a key, never including its hash
+func keySnapshot(key *models.ResponseAPIKey) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      key.Name,
+		"scopes":    key.Scopes,
+		"rateLimit": key.RateLimit,
+		"expiresAt": key.ExpiresAt,
+		"revokedAt": key.RevokedAt,
+	}
+}
@@ -2,7 +2,10 @@ package audit
 
 import (
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/audit/models"
+	"bloggo/internal/utils/auditcontext"
 	"sync"
 )
 
@@ -15,61 +18,81 @@ var (
 func GetGlobalAuditService() AuditService {
 	once.Do(func() {
 		database := db.Get()
-		repository := NewAuditRepository(database)
-		globalAuditService = NewAuditService(repository)
+		repository := NewAuditRepository(database, auditchain.Get())
+		globalAuditService = NewAuditService(repository, auditchain.Get(), permissions.Get())
 	})
 	return globalAuditService
 }
 
-// Helper functions for easy access to audit logging
-func LogAction(userID *int64, entityType string, entityID int64, action string) error {
+// Helper functions for easy access to audit logging. ctx tells where the
+// action came from, metadata may be nil.
+func LogAction(ctx auditcontext.Context, userID *int64, entityType string, entityID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: entityType,
-		EntityID:   entityID,
-		Action:     action,
-	}
-	return service.LogAction(entry)
+	return service.logEntity(ctx, userID, entityType, entityID, action, metadata)
+}
+
+func LogUserAction(ctx auditcontext.Context, userID, targetUserID *int64, action string, metadata map[string]interface{}) error {
+	service := GetGlobalAuditService()
+	return service.LogUserAction(ctx, userID, targetUserID, action, metadata)
+}
+
+func LogPostAction(ctx auditcontext.Context, userID *int64, postID int64, action string, metadata map[string]interface{}) error {
+	service := GetGlobalAuditService()
+	return service.LogPostAction(ctx, userID, postID, action, metadata)
 }
 
-func LogUserAction(userID, targetUserID *int64, action string) error {
+func LogVersionAction(ctx auditcontext.Context, userID *int64, versionID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	return service.LogUserAction(userID, targetUserID, action)
+	return service.LogVersionAction(ctx, userID, versionID, action, metadata)
 }
 
-func LogPostAction(userID *int64, postID int64, action string) error {
+func LogCategoryAction(ctx auditcontext.Context, userID *int64, categoryID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	return service.LogPostAction(userID, postID, action)
+	return service.LogCategoryAction(ctx, userID, categoryID, action, metadata)
 }
 
-func LogVersionAction(userID *int64, versionID int64, action string, metadata map[string]interface{}) error {
+func LogTagAction(ctx auditcontext.Context, userID *int64, tagID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	return service.LogVersionAction(userID, versionID, action, metadata)
+	return service.LogTagAction(ctx, userID, tagID, action, metadata)
 }
 
-func LogCategoryAction(userID *int64, categoryID int64, action string) error {
+func LogAuthAction(ctx auditcontext.Context, userID *int64, action string) error {
 	service := GetGlobalAuditService()
-	return service.LogCategoryAction(userID, categoryID, action)
+	return service.LogAuthAction(ctx, userID, action, nil)
 }
 
-func LogTagAction(userID *int64, tagID int64, action string) error {
+// LogAuthEvent logs an authentication event with extra details such as the
+// attempted email. userID is nil for unknown accounts.
+func LogAuthEvent(ctx auditcontext.Context, userID *int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	return service.LogTagAction(userID, tagID, action)
+	return service.LogAuthAction(ctx, userID, action, metadata)
 }
 
-func LogAuthAction(userID *int64, action string) error {
+func LogWebhookAction(ctx auditcontext.Context, userID *int64, endpointID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	return service.LogAuthAction(userID, action)
+	// endpointID is 0 for actions not tied to an endpoint
+	return service.logEntity(ctx, userID, "webhook", endpointID, action, metadata)
 }
 
-func LogWebhookAction(userID *int64, action string) error {
+// LogRoleAction logs a change to a role, with the affected permission in
+// the metadata when permissions are granted or revoked.
+func LogRoleAction(ctx auditcontext.Context, userID *int64, roleID int64, action string, metadata map[string]interface{}) error {
 	service := GetGlobalAuditService()
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: "webhook",
-		EntityID:   1, // Webhook config is always ID 1
-		Action:     action,
+	return service.logEntity(ctx, userID, models.EntityRole, roleID, action, metadata)
+}
+
+// LogSigningKeyAction logs a change to the JWT keyring. Key ids are not
+// numeric, so the id is kept in the metadata.
+func LogSigningKeyAction(ctx auditcontext.Context, userID *int64, keyID string, action string) error {
+	service := GetGlobalAuditService()
+	return service.logEntity(ctx, userID, models.EntitySigningKey, 0, action, map[string]interface{}{"keyId": keyID})
+}
+
+// Snapshot is the metadata of an update or delete, the audited fields of
+// the entity before and after the change. after is nil for deletes.
+func Snapshot(before, after map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"before": before,
+		"after":  after,
 	}
-	return service.LogAction(entry)
-}
\ No newline at end of file
+}
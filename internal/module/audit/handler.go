@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
 	"encoding/json"
@@ -186,3 +187,27 @@ func (handler *AuditHandler) GetAuditLogsByUser(
 
 	json.NewEncoder(writer).Encode(logs)
 }
+
+// VerifyChain handles GET /audit-logs/verify
+func (handler *AuditHandler) VerifyChain(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	roleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	result, err := handler.service.VerifyChain(roleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrForbidden: {
+				Message: "You don't have permission to verify the audit log.",
+				Status:  http.StatusForbidden,
+			},
+		})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(result)
+}
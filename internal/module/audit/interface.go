@@ -1,14 +1,17 @@
 package audit
 
-import "bloggo/internal/module/audit/models"
+import (
+	"bloggo/internal/module/audit/models"
+	"bloggo/internal/utils/auditcontext"
+)
 
 // AuditLogger is the interface for audit logging
 type AuditLogger interface {
 	LogAction(entry *models.AuditLogEntry) error
-	LogUserAction(userID, targetUserID *int64, action string) error
-	LogPostAction(userID *int64, postID int64, action string) error
-	LogVersionAction(userID *int64, versionID int64, action string, metadata map[string]interface{}) error
-	LogCategoryAction(userID *int64, categoryID int64, action string) error
-	LogTagAction(userID *int64, tagID int64, action string) error
-	LogAuthAction(userID *int64, action string) error
-}
\ No newline at end of file
+	LogUserAction(ctx auditcontext.Context, userID, targetUserID *int64, action string, metadata map[string]interface{}) error
+	LogPostAction(ctx auditcontext.Context, userID *int64, postID int64, action string, metadata map[string]interface{}) error
+	LogVersionAction(ctx auditcontext.Context, userID *int64, versionID int64, action string, metadata map[string]interface{}) error
+	LogCategoryAction(ctx auditcontext.Context, userID *int64, categoryID int64, action string, metadata map[string]interface{}) error
+	LogTagAction(ctx auditcontext.Context, userID *int64, tagID int64, action string, metadata map[string]interface{}) error
+	LogAuthAction(ctx auditcontext.Context, userID *int64, action string, metadata map[string]interface{}) error
+}
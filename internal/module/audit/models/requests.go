@@ -1,11 +1,16 @@
 package models
 
+import "bloggo/internal/utils/auditcontext"
+
+// Context tells where the action came from, it is empty for actions the
+// system takes on its own
 type AuditLogEntry struct {
 	UserID     *int64                 `json:"userId,omitempty"`
 	EntityType string                 `json:"entityType"`
 	EntityID   int64                  `json:"entityId"`
 	Action     string                 `json:"action"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Context    auditcontext.Context   `json:"-"`
 }
 
 // Predefined action constants
@@ -16,8 +21,24 @@ const (
 	ActionDeleted = "deleted"
 
 	// Authentication actions
-	ActionLogin  = "login"
-	ActionLogout = "logout"
+	ActionLogin          = "login"
+	ActionLogout         = "logout"
+	ActionSessionRevoked = "session_revoked"
+	ActionLoginFailed    = "login_failed"
+	ActionLockedOut      = "locked_out"
+	ActionUnlocked       = "unlocked"
+
+	// Account recovery and onboarding actions
+	ActionPasswordResetRequested = "password_reset_requested"
+	ActionPasswordReset          = "password_reset"
+	ActionInvited                = "invited"
+	ActionInvitationAccepted     = "invitation_accepted"
+
+	// Two-factor authentication actions
+	ActionTwoFactorEnabled         = "two_factor_enabled"
+	ActionTwoFactorDisabled        = "two_factor_disabled"
+	ActionTwoFactorFailed          = "two_factor_failed"
+	ActionRecoveryCodesRegenerated = "recovery_codes_regenerated"
 
 	// Workflow actions
 	ActionSubmitted   = "submitted"
@@ -34,6 +55,10 @@ const (
 	ActionRequested        = "requested"
 	ActionAdded           = "added"
 	ActionDenied          = "denied"
+	ActionRevoked         = "revoked"
+	ActionUsed            = "used"
+	ActionPromoted        = "promoted"
+	ActionRetired         = "retired"
 
 	// Legacy constants for backward compatibility (deprecated)
 	ActionUserCreated = ActionCreated
@@ -89,4 +114,7 @@ const (
 	EntityPermission     = "permission"
 	EntityRemovalRequest = "removal_request"
 	EntityKeyValue       = "keyvalue"
+	EntityAPIKey         = "api_key"
+	EntityAccessToken    = "access_token"
+	EntitySigningKey     = "signing_key"
 )
\ No newline at end of file
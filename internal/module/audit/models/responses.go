@@ -11,6 +11,9 @@ type AuditLogResponse struct {
 	EntityName *string                 `json:"entityName,omitempty"`
 	Action     string                  `json:"action"`
 	Metadata   *map[string]interface{} `json:"metadata,omitempty"`
+	IPAddress  *string                 `json:"ipAddress,omitempty"`
+	UserAgent  *string                 `json:"userAgent,omitempty"`
+	RequestID  *string                 `json:"requestId,omitempty"`
 	CreatedAt  time.Time               `json:"createdAt"`
 }
 
@@ -3,6 +3,8 @@ package audit
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 	"github.com/go-chi/chi"
 )
@@ -19,13 +21,14 @@ func (m *Module) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	// Create layers
-	repository := NewAuditRepository(database)
-	service := NewAuditService(repository)
+	repository := NewAuditRepository(database, auditchain.Get())
+	service := NewAuditService(repository, auditchain.Get(), permissions.Get())
 	handler := NewAuditHandler(service)
 
 	// Define routes
 	router.With(middleware.AuthMiddleware(&config)).Route("/audit-logs", func(r chi.Router) {
 		r.Get("/", handler.GetAuditLogs)
+		r.Get("/verify", handler.VerifyChain)
 		r.Get("/entity/{type}/{id}", handler.GetAuditLogsByEntity)
 		r.Get("/user/{id}", handler.GetAuditLogsByUser)
 	})
@@ -1,17 +1,11 @@
 package audit
 
 const (
-	QueryInsertAuditLog = `
-	INSERT INTO audit_logs (
-		user_id, entity_type, entity_id, action,
-		metadata
-	) VALUES (?, ?, ?, ?, ?);`
-
 	QueryGetAuditLogs = `
 	SELECT
 		al.id, al.user_id, u.name as user_name,
 		al.entity_type, al.entity_id, al.action,
-		al.metadata,
+		al.metadata, al.ip_address, al.user_agent, al.request_id,
 		al.created_at,
 		CASE
 			WHEN al.entity_type = 'user' THEN (SELECT name FROM users WHERE id = al.entity_id)
@@ -31,7 +25,7 @@ const (
 	SELECT
 		al.id, al.user_id, u.name as user_name,
 		al.entity_type, al.entity_id, al.action,
-		al.metadata,
+		al.metadata, al.ip_address, al.user_agent, al.request_id,
 		al.created_at,
 		CASE
 			WHEN al.entity_type = 'user' THEN (SELECT name FROM users WHERE id = al.entity_id)
@@ -52,7 +46,7 @@ const (
 	SELECT
 		al.id, al.user_id, u.name as user_name,
 		al.entity_type, al.entity_id, al.action,
-		al.metadata,
+		al.metadata, al.ip_address, al.user_agent, al.request_id,
 		al.created_at,
 		CASE
 			WHEN al.entity_type = 'user' THEN (SELECT name FROM users WHERE id = al.entity_id)
@@ -84,7 +78,7 @@ const (
 	SELECT
 		al.id, al.user_id, u.name as user_name,
 		al.entity_type, al.entity_id, al.action,
-		al.metadata,
+		al.metadata, al.ip_address, al.user_agent, al.request_id,
 		al.created_at,
 		CASE
 			WHEN al.entity_type = 'user' THEN (SELECT name FROM users WHERE id = al.entity_id)
@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"bloggo/internal/infrastructure/auditchain"
 	"bloggo/internal/module/audit/models"
 	"database/sql"
 	"encoding/json"
@@ -10,14 +11,17 @@ import (
 
 type AuditRepository struct {
 	database *sql.DB
+	chain    *auditchain.Chain
 }
 
-func NewAuditRepository(database *sql.DB) AuditRepository {
+func NewAuditRepository(database *sql.DB, chain *auditchain.Chain) AuditRepository {
 	return AuditRepository{
 		database,
+		chain,
 	}
 }
 
+// LogAction appends the entry to the hash chain
 func (repository *AuditRepository) LogAction(entry *models.AuditLogEntry) error {
 	var metadataJSON *string
 
@@ -29,16 +33,17 @@ func (repository *AuditRepository) LogAction(entry *models.AuditLogEntry) error
 		}
 	}
 
-	_, err := repository.database.Exec(
-		QueryInsertAuditLog,
-		entry.UserID,
-		entry.EntityType,
-		entry.EntityID,
-		entry.Action,
-		metadataJSON,
-	)
-
-	return err
+	return repository.chain.Append(auditchain.Record{
+		UserID:     entry.UserID,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		Metadata:   metadataJSON,
+		IPAddress:  nullIfEmpty(entry.Context.IP),
+		UserAgent:  nullIfEmpty(entry.Context.UserAgent),
+		RequestID:  nullIfEmpty(entry.Context.RequestID),
+		OutboxID:   entry.Context.OutboxID,
+	})
 }
 
 func (repository *AuditRepository) GetAuditLogs(limit, offset int) ([]models.AuditLogResponse, error) {
@@ -213,6 +218,9 @@ func (repository *AuditRepository) scanAuditLogs(rows *sql.Rows) ([]models.Audit
 			&log.EntityID,
 			&log.Action,
 			&metadataJSON,
+			&log.IPAddress,
+			&log.UserAgent,
+			&log.RequestID,
 			&createdAtStr,
 			&log.EntityName,
 		)
@@ -238,4 +246,12 @@ func (repository *AuditRepository) scanAuditLogs(rows *sql.Rows) ([]models.Audit
 	}
 
 	return logs, rows.Err()
-}
\ No newline at end of file
+}
+
+// Missing context values are stored as NULL
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -1,16 +1,24 @@
 package audit
 
 import (
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/audit/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 )
 
 type AuditService struct {
-	repository AuditRepository
+	repository  AuditRepository
+	chain       *auditchain.Chain
+	permissions permissions.Store
 }
 
-func NewAuditService(repository AuditRepository) AuditService {
+func NewAuditService(repository AuditRepository, chain *auditchain.Chain, permissions permissions.Store) AuditService {
 	return AuditService{
 		repository,
+		chain,
+		permissions,
 	}
 }
 
@@ -111,71 +119,44 @@ func (service *AuditService) GetAuditLogsByUser(userID int64, limit, offset int)
 	}, nil
 }
 
+// VerifyChain walks the hash chain of the audit log and its checkpoints
+func (service *AuditService) VerifyChain(userRoleId int64) (auditchain.Result, error) {
+	if !service.permissions.HasPermission(userRoleId, "auditlog:view") {
+		return auditchain.Result{}, apierrors.ErrForbidden
+	}
+
+	return service.chain.Verify()
+}
+
 // Helper functions to create audit log entries
 
 // LogUserAction logs a user-related action
-func (service *AuditService) LogUserAction(userID, targetUserID *int64, action string) error {
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: models.EntityUser,
-		EntityID:   *targetUserID,
-		Action:     action,
-	}
-
-	return service.LogAction(entry)
+func (service *AuditService) LogUserAction(ctx auditcontext.Context, userID, targetUserID *int64, action string, metadata map[string]interface{}) error {
+	return service.logEntity(ctx, userID, models.EntityUser, *targetUserID, action, metadata)
 }
 
 // LogPostAction logs a post-related action
-func (service *AuditService) LogPostAction(userID *int64, postID int64, action string) error {
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: models.EntityPost,
-		EntityID:   postID,
-		Action:     action,
-	}
-
-	return service.LogAction(entry)
+func (service *AuditService) LogPostAction(ctx auditcontext.Context, userID *int64, postID int64, action string, metadata map[string]interface{}) error {
+	return service.logEntity(ctx, userID, models.EntityPost, postID, action, metadata)
 }
 
 // LogVersionAction logs a post version-related action
-func (service *AuditService) LogVersionAction(userID *int64, versionID int64, action string, metadata map[string]interface{}) error {
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: models.EntityPostVersion,
-		EntityID:   versionID,
-		Action:     action,
-		Metadata:   metadata,
-	}
-
-	return service.LogAction(entry)
+func (service *AuditService) LogVersionAction(ctx auditcontext.Context, userID *int64, versionID int64, action string, metadata map[string]interface{}) error {
+	return service.logEntity(ctx, userID, models.EntityPostVersion, versionID, action, metadata)
 }
 
 // LogCategoryAction logs a category-related action
-func (service *AuditService) LogCategoryAction(userID *int64, categoryID int64, action string) error {
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: models.EntityCategory,
-		EntityID:   categoryID,
-		Action:     action,
-	}
-
-	return service.LogAction(entry)
+func (service *AuditService) LogCategoryAction(ctx auditcontext.Context, userID *int64, categoryID int64, action string, metadata map[string]interface{}) error {
+	return service.logEntity(ctx, userID, models.EntityCategory, categoryID, action, metadata)
 }
 
 // LogTagAction logs a tag-related action
-func (service *AuditService) LogTagAction(userID *int64, tagID int64, action string) error {
-	entry := &models.AuditLogEntry{
-		UserID:     userID,
-		EntityType: models.EntityTag,
-		EntityID:   tagID,
-		Action:     action,
-	}
-
-	return service.LogAction(entry)
+func (service *AuditService) LogTagAction(ctx auditcontext.Context, userID *int64, tagID int64, action string, metadata map[string]interface{}) error {
+	return service.logEntity(ctx, userID, models.EntityTag, tagID, action, metadata)
 }
 
 // LogAuthAction logs an authentication-related action
-func (service *AuditService) LogAuthAction(userID *int64, action string) error {
+func (service *AuditService) LogAuthAction(ctx auditcontext.Context, userID *int64, action string, metadata map[string]interface{}) error {
 	// For auth actions, we use the userID as the entityID since auth is tied to a specific user
 	// If userID is nil (system action), we'll use 0 as placeholder
 	entityID := int64(0)
@@ -183,13 +164,18 @@ func (service *AuditService) LogAuthAction(userID *int64, action string) error {
 		entityID = *userID
 	}
 
+	return service.logEntity(ctx, userID, models.EntityAuth, entityID, action, metadata)
+}
+
+func (service *AuditService) logEntity(ctx auditcontext.Context, userID *int64, entityType string, entityID int64, action string, metadata map[string]interface{}) error {
 	entry := &models.AuditLogEntry{
 		UserID:     userID,
-		EntityType: models.EntityAuth,
+		EntityType: entityType,
 		EntityID:   entityID,
 		Action:     action,
+		Metadata:   metadata,
+		Context:    ctx,
 	}
 
 	return service.LogAction(entry)
 }
-
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/module/audit/models"
+	"bloggo/internal/utils/auditcontext"
+)
+
+// SubscribeEvents records audit entries for domain events. A failed entry
+// is retried by the outbox relay.
+func SubscribeEvents(bus *events.Bus) {
+	// Posts
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.PostCreated) error {
+		return LogPostAction(delivered(event.Context, delivery), &event.ActorID, event.PostID, models.ActionPostCreated, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.PostDeleted) error {
+		return LogPostAction(delivered(event.Context, delivery), &event.ActorID, event.PostID, models.ActionPostDeleted, Snapshot(event.Before, nil))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.PostPublished) error {
+		return LogVersionAction(delivered(event.Context, delivery), &event.ActorID, event.VersionID, models.ActionVersionPublished, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.VersionUpdated) error {
+		return LogVersionAction(delivered(event.Context, delivery), &event.ActorID, event.VersionID, models.ActionVersionUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.VersionDeleted) error {
+		return LogVersionAction(delivered(event.Context, delivery), &event.ActorID, event.VersionID, models.ActionVersionDeleted, Snapshot(event.Before, nil))
+	})
+
+	// Removal requests
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RemovalRequestCreated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityRemovalRequest, event.RequestID, models.ActionRemovalRequested, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RemovalRequestApproved) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityRemovalRequest, event.RequestID, models.ActionRemovalApproved, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RemovalRequestRejected) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityRemovalRequest, event.RequestID, models.ActionRejected, nil)
+	})
+
+	// Categories
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.CategoryCreated) error {
+		return LogCategoryAction(delivered(event.Context, delivery), &event.ActorID, event.CategoryID, models.ActionCategoryCreated, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.CategoryUpdated) error {
+		return LogCategoryAction(delivered(event.Context, delivery), &event.ActorID, event.CategoryID, models.ActionCategoryUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.CategoryDeleted) error {
+		return LogCategoryAction(delivered(event.Context, delivery), &event.ActorID, event.CategoryID, models.ActionCategoryDeleted, Snapshot(event.Before, nil))
+	})
+
+	// Tags
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TagCreated) error {
+		return LogTagAction(delivered(event.Context, delivery), &event.ActorID, event.TagID, models.ActionTagCreated, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TagUpdated) error {
+		return LogTagAction(delivered(event.Context, delivery), &event.ActorID, event.TagID, models.ActionTagUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TagDeleted) error {
+		return LogTagAction(delivered(event.Context, delivery), &event.ActorID, event.TagID, models.ActionTagDeleted, Snapshot(event.Before, nil))
+	})
+
+	// Users
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserCreated) error {
+		action := models.ActionUserCreated
+		if event.Invited {
+			action = models.ActionInvited
+		}
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, action, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserUpdated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionUserUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserDeleted) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionUserDeleted, Snapshot(event.Before, nil))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserRoleAssigned) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionAssigned, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserPassphraseChanged) error {
+		// Only the field name is recorded, never the passphrase
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionUpdated, map[string]interface{}{
+			"fields": []string{"passphrase"},
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserUnlocked) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionUnlocked, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserCategoryPermissionGranted) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionPermissionAdded, map[string]interface{}{
+			"permission": event.Permission,
+			"categoryId": event.CategoryID,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserCategoryPermissionRevoked) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionPermissionRemoved, map[string]interface{}{
+			"permission": event.Permission,
+			"categoryId": event.CategoryID,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserLoggedIn) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionLogin)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserLoginFailed) error {
+		return LogAuthEvent(delivered(event.Context, delivery), event.UserID, models.ActionLoginFailed, map[string]interface{}{
+			"email": event.Email,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserLockedOut) error {
+		return LogAuthEvent(delivered(event.Context, delivery), event.UserID, models.ActionLockedOut, map[string]interface{}{
+			"email":    event.Email,
+			"account":  event.Account,
+			"duration": event.Duration,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserLoggedOut) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionLogout)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserSessionsRevoked) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionSessionRevoked)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.UserSessionsRevokedByAdmin) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionSessionRevoked, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.PasswordResetRequested) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionPasswordResetRequested)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.PasswordReset) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionPasswordReset)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.InvitationResent) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionInvited, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.InvitationAccepted) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionInvitationAccepted)
+	})
+
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TwoFactorEnabled) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionTwoFactorEnabled)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TwoFactorFailed) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionTwoFactorFailed)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TwoFactorDisabled) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionTwoFactorDisabled)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TwoFactorRecoveryCodesRegenerated) error {
+		return LogAuthAction(delivered(event.Context, delivery), &event.UserID, models.ActionRecoveryCodesRegenerated)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.TwoFactorReset) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityUser, event.UserID, models.ActionTwoFactorDisabled, nil)
+	})
+
+	// Roles
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RoleCreated) error {
+		return LogRoleAction(delivered(event.Context, delivery), &event.ActorID, event.RoleID, models.ActionRoleCreated, map[string]interface{}{
+			"name":        event.Name,
+			"permissions": event.Permissions,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RoleUpdated) error {
+		return LogRoleAction(delivered(event.Context, delivery), &event.ActorID, event.RoleID, models.ActionRoleUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RolePermissionsGranted) error {
+		return LogRoleAction(delivered(event.Context, delivery), &event.ActorID, event.RoleID, models.ActionPermissionAdded, map[string]interface{}{
+			"permissions": event.Permissions,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RolePermissionRevoked) error {
+		return LogRoleAction(delivered(event.Context, delivery), &event.ActorID, event.RoleID, models.ActionPermissionRemoved, map[string]interface{}{
+			"permission": event.Permission,
+		})
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.RoleDeleted) error {
+		return LogRoleAction(delivered(event.Context, delivery), &event.ActorID, event.RoleID, models.ActionRoleDeleted, Snapshot(event.Before, nil))
+	})
+
+	// API keys and access tokens
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.APIKeyCreated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityAPIKey, event.KeyID, models.ActionCreated, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.APIKeyUpdated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityAPIKey, event.KeyID, models.ActionUpdated, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.APIKeyRevoked) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityAPIKey, event.KeyID, models.ActionRevoked, Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.AccessTokenCreated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityAccessToken, event.TokenID, models.ActionCreated, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.AccessTokenRevoked) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityAccessToken, event.TokenID, models.ActionRevoked, nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.AccessTokenUsed) error {
+		return LogAction(delivered(event.Context, delivery), &event.UserID, models.EntityAccessToken, event.TokenID, models.ActionUsed, nil)
+	})
+
+	// Signing keys
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.SigningKeyGenerated) error {
+		return LogSigningKeyAction(delivered(event.Context, delivery), &event.ActorID, event.KeyID, models.ActionCreated)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.SigningKeyPromoted) error {
+		return LogSigningKeyAction(delivered(event.Context, delivery), &event.ActorID, event.KeyID, models.ActionPromoted)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.SigningKeyRetired) error {
+		return LogSigningKeyAction(delivered(event.Context, delivery), &event.ActorID, event.KeyID, models.ActionRetired)
+	})
+
+	// Webhooks, actions not tied to an endpoint are logged with id 0
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookEndpointCreated) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, event.EndpointID, "endpoint_created", nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookEndpointUpdated) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, event.EndpointID, "endpoint_updated", Snapshot(event.Before, event.After))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookSecretRotated) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, event.EndpointID, "secret_rotated", nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookEndpointDeleted) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, event.EndpointID, "endpoint_deleted", Snapshot(event.Before, nil))
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookFired) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, 0, "manual_fire", nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookRequestRedelivered) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, event.RequestID, "request_redelivered", nil)
+	})
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.WebhookRequestsReplayed) error {
+		return LogWebhookAction(delivered(event.Context, delivery), &event.ActorID, 0, "requests_replayed", nil)
+	})
+
+	// Settings, a bulk change so it isn't tied to one entity
+	events.SubscribeDelivery(bus, "audit", func(delivery events.Delivery, event events.KeyValuesUpdated) error {
+		return LogAction(delivered(event.Context, delivery), &event.ActorID, models.EntityKeyValue, 0, models.ActionUpdated, Snapshot(event.Before, event.Values))
+	})
+}
+
+// Keys the entry on the outbox row, an entry already written for it is
+// skipped
+func delivered(ctx auditcontext.Context, delivery events.Delivery) auditcontext.Context {
+	ctx.OutboxID = delivery.ID
+	return ctx
+}
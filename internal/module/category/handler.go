@@ -3,6 +3,7 @@ package category
 import (
 	"bloggo/internal/module/category/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
@@ -39,7 +40,7 @@ func (handler *CategoryHandler) CategoryCreate(
 		return
 	}
 
-	response, err := handler.service.CategoryCreate(&body, roleId, userId)
+	response, err := handler.service.CategoryCreate(&body, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
@@ -134,7 +135,7 @@ func (handler *CategoryHandler) CategoryUpdate(
 		return
 	}
 
-	err := handler.service.CategoryUpdate(slug, &body, roleId, userId)
+	err := handler.service.CategoryUpdate(slug, &body, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
@@ -167,7 +168,7 @@ func (handler *CategoryHandler) CategoryDelete(
 		return
 	}
 
-	err := handler.service.CategoryDelete(slug, roleId, userId)
+	err := handler.service.CategoryDelete(slug, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
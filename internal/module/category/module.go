@@ -3,6 +3,7 @@ package category
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 	"bloggo/internal/module/ai"
@@ -21,7 +22,7 @@ func NewModule() CategoryModule {
 	permissionStore := permissions.Get()
 	aiService := ai.NewAIService()
 	repository := NewCategoryRepository(database)
-	service := NewCategoryService(repository, permissionStore, aiService)
+	service := NewCategoryService(repository, permissionStore, aiService, outbox.Get())
 	handler := NewCategoryHandler(service)
 
 	return CategoryModule{
@@ -34,16 +35,17 @@ func NewModule() CategoryModule {
 func (module CategoryModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
-	router.With(middleware.AuthMiddleware(&config)).Route(
+	// Personal access tokens need the permission named by the route
+	router.Route(
 		"/categories",
 		func(router chi.Router) {
-			router.Get("/", module.Handler.GetCategories)
-			router.Get("/list", module.Handler.GetCategoryList)
-			router.Get("/{slug}", module.Handler.GetCategoryBySlug)
-			router.Post("/", module.Handler.CategoryCreate)
-			router.Patch("/{slug}", module.Handler.CategoryUpdate)
-			router.Delete("/{slug}", module.Handler.CategoryDelete)
-			router.Get("/generative-fill", module.Handler.GenerativeFill)
+			router.With(middleware.AuthMiddleware(&config, "category:list")).Get("/", module.Handler.GetCategories)
+			router.With(middleware.AuthMiddleware(&config, "category:list")).Get("/list", module.Handler.GetCategoryList)
+			router.With(middleware.AuthMiddleware(&config, "category:view")).Get("/{slug}", module.Handler.GetCategoryBySlug)
+			router.With(middleware.AuthMiddleware(&config, "category:create")).Post("/", module.Handler.CategoryCreate)
+			router.With(middleware.AuthMiddleware(&config, "category:update")).Patch("/{slug}", module.Handler.CategoryUpdate)
+			router.With(middleware.AuthMiddleware(&config, "category:delete")).Delete("/{slug}", module.Handler.CategoryDelete)
+			router.With(middleware.AuthMiddleware(&config)).Get("/generative-fill", module.Handler.GenerativeFill)
 		},
 	)
 }
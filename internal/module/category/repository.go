@@ -1,6 +1,7 @@
 package category
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/category/models"
 	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/filter"
@@ -10,7 +11,7 @@ import (
 )
 
 type CategoryRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewCategoryRepository(database *sql.DB) CategoryRepository {
@@ -19,6 +20,13 @@ func NewCategoryRepository(database *sql.DB) CategoryRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *CategoryRepository) WithTx(tx *sql.Tx) CategoryRepository {
+	return CategoryRepository{
+		tx,
+	}
+}
+
 func (repository *CategoryRepository) CategoryCreate(
 	model *models.QueryParamsCategoryCreate,
 ) (int64, error) {
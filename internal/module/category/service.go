@@ -1,13 +1,13 @@
 package category
 
 import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/ai"
-	"bloggo/internal/module/audit"
-	auditmodels "bloggo/internal/module/audit/models"
 	"bloggo/internal/module/category/models"
-	"bloggo/internal/module/webhook"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/pagination"
 	"bloggo/internal/utils/schemas/responses"
@@ -17,13 +17,15 @@ type CategoryService struct {
 	repository  CategoryRepository
 	permissions permissions.Store
 	aiService   ai.AIService
+	outbox      *outbox.Outbox
 }
 
-func NewCategoryService(repository CategoryRepository, permissions permissions.Store, aiService ai.AIService) CategoryService {
+func NewCategoryService(repository CategoryRepository, permissions permissions.Store, aiService ai.AIService, outbox *outbox.Outbox) CategoryService {
 	return CategoryService{
 		repository,
 		permissions,
 		aiService,
+		outbox,
 	}
 }
 
@@ -31,6 +33,7 @@ func (service *CategoryService) CategoryCreate(
 	model *models.RequestCategoryCreate,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) (*responses.ResponseCreated, error) {
 	// Check if user has permission to create categories
 	hasPermission := service.permissions.HasPermission(userRoleId, "category:create")
@@ -39,24 +42,29 @@ func (service *CategoryService) CategoryCreate(
 	}
 
 	params := models.ToCreateCategoryParams(model)
-	id, err := service.repository.CategoryCreate(params)
+	var id int64
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.CategoryCreate(params)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.CategoryCreated{
+			ActorID:     userId,
+			Context:     auditContext,
+			CategoryID:  id,
+			Name:        params.Name,
+			Slug:        params.Slug,
+			Spot:        params.Spot,
+			Description: params.Description,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Log the action
-	audit.LogCategoryAction(&userId, id, auditmodels.ActionCategoryCreated)
-
-	// Trigger webhook
-	go func() {
-		webhook.TriggerCategoryCreated(id, params.Slug, map[string]interface{}{
-			"name":        params.Name,
-			"slug":        params.Slug,
-			"spot":        params.Spot,
-			"description": params.Description,
-		})
-	}()
-
 	return &responses.ResponseCreated{
 		Id: id,
 	}, nil
@@ -112,6 +120,7 @@ func (service *CategoryService) CategoryUpdate(
 	model *models.RequestCategoryUpdate,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to update categories
 	hasPermission := service.permissions.HasPermission(userRoleId, "category:update")
@@ -119,22 +128,15 @@ func (service *CategoryService) CategoryUpdate(
 		return apierrors.ErrForbidden
 	}
 
-	// First get the category ID to log it
+	// First get the category as it was for the audit log
 	category, err := service.repository.GetCategoryBySlug(slug)
 	if err != nil {
 		return err
 	}
 
 	params := models.ToUpdateCategoryParams(model)
-	err = service.repository.CategoryUpdate(slug, params)
-	if err != nil {
-		return err
-	}
-
-	// Log the action
-	audit.LogCategoryAction(&userId, category.Id, auditmodels.ActionCategoryUpdated)
 
-	// Trigger webhook with updated slug
+	// Publish with the updated slug
 	newSlug := slug
 	var oldSlug *string
 	if params.Slug != nil {
@@ -143,22 +145,38 @@ func (service *CategoryService) CategoryUpdate(
 			oldSlug = &slug
 		}
 	}
-	go func() {
-		webhook.TriggerCategoryUpdated(category.Id, newSlug, oldSlug, map[string]interface{}{
-			"name":        model.Name,
-			"slug":        newSlug,
-			"spot":        model.Spot,
-			"description": model.Description,
-		})
-	}()
 
-	return nil
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.CategoryUpdate(slug, params); err != nil {
+			return err
+		}
+
+		updated, err := repository.GetCategoryBySlug(newSlug)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.CategoryUpdated{
+			ActorID:     userId,
+			Context:     auditContext,
+			CategoryID:  category.Id,
+			Slug:        newSlug,
+			OldSlug:     oldSlug,
+			Name:        model.Name,
+			Spot:        model.Spot,
+			Description: model.Description,
+			Before:      categorySnapshot(category),
+			After:       categorySnapshot(updated),
+		})
+	})
 }
 
 func (service *CategoryService) CategoryDelete(
 	slug string,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to delete categories
 	hasPermission := service.permissions.HasPermission(userRoleId, "category:delete")
@@ -172,21 +190,21 @@ func (service *CategoryService) CategoryDelete(
 		return err
 	}
 
-	// Soft delete the category (posts will keep reference but show as archived)
-	err = service.repository.CategoryDelete(slug)
-	if err != nil {
-		return err
-	}
-
-	// Log the action
-	audit.LogCategoryAction(&userId, category.Id, auditmodels.ActionCategoryDeleted)
-
-	// Trigger webhook
-	go func() {
-		webhook.TriggerCategoryDeleted(category.Id, category.Slug)
-	}()
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		// Soft delete the category (posts will keep reference but show as archived)
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.CategoryDelete(slug); err != nil {
+			return err
+		}
 
-	return nil
+		return tx.Publish(events.CategoryDeleted{
+			ActorID:    userId,
+			Context:    auditContext,
+			CategoryID: category.Id,
+			Slug:       category.Slug,
+			Before:     categorySnapshot(category),
+		})
+	})
 }
 
 func (service *CategoryService) GenerativeFill(
@@ -209,3 +227,13 @@ func (service *CategoryService) GenerativeFill(
 		Description: result.Description,
 	}, nil
 }
+
+// The audited fields of a category
+func categorySnapshot(category *models.ResponseCategoryDetails) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        category.Name,
+		"slug":        category.Slug,
+		"spot":        category.Spot,
+		"description": category.Description,
+	}
+}
@@ -3,6 +3,7 @@ package keyvalue
 import (
 	"bloggo/internal/module/keyvalue/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/handlers"
 	"encoding/json"
 	"net/http"
@@ -61,13 +62,17 @@ func (handler *KeyValueHandler) BulkUpsert(
 		return
 	}
 
-	err := handler.service.BulkUpsert(body.Items, roleId, userId)
+	err := handler.service.BulkUpsert(body.Items, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
 				Message: "Only editors and admins can manage key-values.",
 				Status:  http.StatusForbidden,
 			},
+			apierrors.ErrBadRequest: {
+				Message: "Keys starting with audit.checkpoint. are reserved for the audit log.",
+				Status:  http.StatusBadRequest,
+			},
 		})
 		return
 	}
@@ -3,6 +3,7 @@ package keyvalue
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 
@@ -20,7 +21,7 @@ func NewModule() KeyValueModule {
 	permissionStore := permissions.Get()
 
 	repository := NewKeyValueRepository(database)
-	service := NewKeyValueService(repository, permissionStore)
+	service := NewKeyValueService(repository, permissionStore, outbox.Get())
 	handler := NewKeyValueHandler(service)
 
 	return KeyValueModule{
@@ -33,7 +34,7 @@ func NewModule() KeyValueModule {
 func (module KeyValueModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
-	router.With(middleware.AuthMiddleware(&config)).Route(
+	router.With(middleware.AuthMiddleware(&config, "keyvalue:manage")).Route(
 		"/key-values",
 		func(router chi.Router) {
 			router.Get("/", module.Handler.GetAll)
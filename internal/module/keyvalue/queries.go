@@ -1,9 +1,11 @@
 package keyvalue
 
+// Audit log checkpoints share the table but are not settings
 const (
 	QueryGetAll = `
 	SELECT key, value, created_at, updated_at
 	FROM key_value_store
+	WHERE key NOT GLOB 'audit.checkpoint.*'
 	ORDER BY key ASC;`
 
 	QueryUpsert = `
@@ -17,5 +19,6 @@ const (
 	DELETE FROM key_value_store WHERE key = ?;`
 
 	QueryDeleteAll = `
-	DELETE FROM key_value_store;`
+	DELETE FROM key_value_store
+	WHERE key NOT GLOB 'audit.checkpoint.*';`
 )
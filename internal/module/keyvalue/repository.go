@@ -1,12 +1,13 @@
 package keyvalue
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/keyvalue/models"
 	"database/sql"
 )
 
 type KeyValueRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewKeyValueRepository(database *sql.DB) KeyValueRepository {
@@ -15,6 +16,13 @@ func NewKeyValueRepository(database *sql.DB) KeyValueRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *KeyValueRepository) WithTx(tx *sql.Tx) KeyValueRepository {
+	return KeyValueRepository{
+		tx,
+	}
+}
+
 func (repository *KeyValueRepository) GetAll() ([]models.KeyValue, error) {
 	rows, err := repository.database.Query(QueryGetAll)
 	if err != nil {
@@ -56,7 +64,7 @@ func (repository *KeyValueRepository) Upsert(key, value string) error {
 }
 
 func (repository *KeyValueRepository) BulkUpsert(items []models.RequestKeyValueUpsert) error {
-	tx, err := repository.database.Begin()
+	tx, err := db.Begin(repository.database)
 	if err != nil {
 		return err
 	}
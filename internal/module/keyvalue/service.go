@@ -1,23 +1,27 @@
 package keyvalue
 
 import (
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/keyvalue/models"
-	"bloggo/internal/module/webhook"
 	"bloggo/internal/utils/apierrors"
-	"bloggo/internal/utils/audit"
-	auditmodels "bloggo/internal/module/audit/models"
+	"bloggo/internal/utils/auditcontext"
+	"strings"
 )
 
 type KeyValueService struct {
 	repository  KeyValueRepository
 	permissions permissions.Store
+	outbox      *outbox.Outbox
 }
 
-func NewKeyValueService(repository KeyValueRepository, permissions permissions.Store) KeyValueService {
+func NewKeyValueService(repository KeyValueRepository, permissions permissions.Store, outbox *outbox.Outbox) KeyValueService {
 	return KeyValueService{
 		repository,
 		permissions,
+		outbox,
 	}
 }
 
@@ -35,6 +39,7 @@ func (service *KeyValueService) BulkUpsert(
 	items []models.RequestKeyValueUpsert,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to manage key-values
 	hasPermission := service.permissions.HasPermission(userRoleId, "keyvalue:manage")
@@ -42,27 +47,43 @@ func (service *KeyValueService) BulkUpsert(
 		return apierrors.ErrForbidden
 	}
 
-	// First delete all existing entries
-	err := service.repository.DeleteAll()
-	if err != nil {
-		return err
-	}
-
-	// Then insert/update all items
-	err = service.repository.BulkUpsert(items)
-	if err != nil {
-		return err
-	}
-
-	// Log the audit event (using 0 as entity ID since it's a bulk operation)
-	audit.LogAction(&userId, auditmodels.EntityKeyValue, 0, auditmodels.ActionUpdated)
-
-	// Trigger webhook with updated key-value pairs
 	keyValueMap := make(map[string]interface{})
 	for _, item := range items {
+		// Checkpoints are written by the audit log only
+		if strings.HasPrefix(item.Key, auditchain.CheckpointKeyPrefix) {
+			return apierrors.ErrBadRequest
+		}
 		keyValueMap[item.Key] = item.Value
 	}
-	go func() { webhook.TriggerKeyValueUpdated(keyValueMap) }()
 
-	return nil
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+
+		// Keep the replaced entries for the audit log
+		previous, err := repository.GetAll()
+		if err != nil {
+			return err
+		}
+		before := make(map[string]interface{})
+		for _, item := range previous {
+			before[item.Key] = item.Value
+		}
+
+		// First delete all existing entries
+		if err := repository.DeleteAll(); err != nil {
+			return err
+		}
+
+		// Then insert/update all items
+		if err := repository.BulkUpsert(items); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.KeyValuesUpdated{
+			ActorID: userId,
+			Context: auditContext,
+			Values:  keyValueMap,
+			Before:  before,
+		})
+	})
 }
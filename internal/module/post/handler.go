@@ -3,6 +3,7 @@ package post
 import (
 	"bloggo/internal/module/post/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
@@ -26,6 +27,11 @@ func (handler *PostHandler) ListPosts(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
 	// Get pagination options
 	paginationOptions, ok := pagination.GetPaginationOptions(
 		writer,
@@ -72,7 +78,7 @@ func (handler *PostHandler) ListPosts(
 		AuthorId:   authorIdPtr,
 	}
 
-	details, err := handler.service.GetPostListPaginated(filters)
+	details, err := handler.service.GetPostListPaginated(filters, userId)
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -138,6 +144,7 @@ func (handler *PostHandler) CreatePostWithFirstVersion(
 	createdPost, err := handler.service.CreatePostWithFirstVersion(
 		body,
 		userId,
+		auditcontext.FromRequest(request),
 	)
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
@@ -191,12 +198,17 @@ func (handler *PostHandler) DeletePostById(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
 	id, ok := handlers.GetParam[int64](writer, request, "id")
 	if !ok {
 		return
 	}
 
-	if err := handler.service.DeletePostById(id); err != nil {
+	if err := handler.service.DeletePostById(id, userId, auditcontext.FromRequest(request)); err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
 	}
@@ -283,6 +295,7 @@ func (handler *PostHandler) UpdateUnsubmittedOwnVersion(
 		versionId,
 		userId,
 		body,
+		auditcontext.FromRequest(request),
 	); err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			// Custom error messages for this endpoint
@@ -323,6 +336,7 @@ func (handler *PostHandler) SubmitVersionForReview(
 		postId,
 		versionId,
 		userId,
+		auditcontext.FromRequest(request),
 	); err != nil {
 		// Handle validation errors specially
 		if apiErr, ok := err.(*apierrors.APIError); ok {
@@ -355,6 +369,11 @@ func (handler *PostHandler) ApproveVersion(
 		return
 	}
 
+	roleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
 	postId, ok := handlers.GetParam[int64](writer, request, "id")
 	if !ok {
 		return
@@ -381,13 +400,19 @@ func (handler *PostHandler) ApproveVersion(
 		postId,
 		versionId,
 		userId,
+		roleId,
 		note,
+		auditcontext.FromRequest(request),
 	); err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrPreconditionFailed: {
 				Message: "Drafts and published versions cannot be approved.",
 				Status:  http.StatusPreconditionFailed,
 			},
+			apierrors.ErrForbidden: {
+				Message: "You don't have permission to review versions in this category.",
+				Status:  http.StatusForbidden,
+			},
 		})
 		return
 	}
@@ -404,6 +429,11 @@ func (handler *PostHandler) RejectVersion(
 		return
 	}
 
+	roleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
 	postId, ok := handlers.GetParam[int64](writer, request, "id")
 	if !ok {
 		return
@@ -430,13 +460,19 @@ func (handler *PostHandler) RejectVersion(
 		postId,
 		versionId,
 		userId,
+		roleId,
 		note,
+		auditcontext.FromRequest(request),
 	); err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrPreconditionFailed: {
 				Message: "Drafts and published versions cannot be rejected.",
 				Status:  http.StatusPreconditionFailed,
 			},
+			apierrors.ErrForbidden: {
+				Message: "You don't have permission to review versions in this category.",
+				Status:  http.StatusForbidden,
+			},
 		})
 		return
 	}
@@ -472,6 +508,7 @@ func (handler *PostHandler) DeleteVersionById(
 		versionId,
 		userId,
 		roleId,
+		auditcontext.FromRequest(request),
 	)
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
@@ -518,6 +555,7 @@ func (handler *PostHandler) PublishVersion(
 		versionId,
 		userId,
 		roleId,
+		auditcontext.FromRequest(request),
 	); err != nil {
 		// Handle API errors (like category deleted) specially
 		if apiErr, ok := err.(*apierrors.APIError); ok {
@@ -533,7 +571,7 @@ func (handler *PostHandler) PublishVersion(
 				Status:  http.StatusPreconditionFailed,
 			},
 			apierrors.ErrForbidden: {
-				Message: "You don't have permission to publish versions.",
+				Message: "You don't have permission to publish versions in this category.",
 				Status:  http.StatusForbidden,
 			},
 		})
@@ -555,7 +593,7 @@ func (handler *PostHandler) TrackView(
 		return
 	}
 
-	err := handler.service.TrackView(body)
+	err := handler.service.TrackView(body, handlers.GetClientIP(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -660,7 +698,7 @@ func (handler *PostHandler) UpdateVersionCategory(
 		return
 	}
 
-	err := handler.service.UpdateVersionCategory(postId, versionId, body.CategoryId, userId, roleId)
+	err := handler.service.UpdateVersionCategory(postId, versionId, body.CategoryId, userId, roleId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
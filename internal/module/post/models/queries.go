@@ -1,5 +1,14 @@
 package models
 
+// The fields of a version kept in the audit log when it changes
+type QueryGetVersionAuditFields struct {
+	Title       *string
+	Slug        *string
+	Content     *string
+	Description *string
+	CategoryId  *int64
+}
+
 type QueryGetPostVersionDuplicateData struct {
 	VersionId   int64
 	PostId      int64
@@ -36,6 +36,14 @@ type RequestPostFilters struct {
 	Status     *int    `form:"status" validate:"omitempty,min=0,max=5"`
 	CategoryId *int64  `form:"categoryId"`
 	AuthorId   *int64  `form:"authorId"`
+
+	// Set by the service for users whose listing is narrowed to categories
+	Scope *PostListScope `form:"-"`
+}
+
+type PostListScope struct {
+	UserId      int64
+	CategoryIds []int64
 }
 
 // -- Validation for Post Submission -- //
@@ -3,7 +3,9 @@ package post
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/apikeys"
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 	"bloggo/internal/utils/file/transformfile"
@@ -30,7 +32,7 @@ func NewModule() PostModule {
 	permissionStore := permissions.Get()
 
 	repository := NewPostRepository(database)
-	service := NewPostService(repository, bucket, imageValidator, coverResizer, permissionStore)
+	service := NewPostService(repository, bucket, imageValidator, coverResizer, permissionStore, outbox.Get())
 	handler := NewPostHandler(service)
 
 	return PostModule{
@@ -44,30 +46,41 @@ func (module PostModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
 	router.Route("/posts", func(router chi.Router) {
-		// Most routes require authentication
-		router.With(middleware.AuthMiddleware(&config)).Group(func(router chi.Router) {
-			router.Get("/", module.Handler.ListPosts)
+		// Most routes require authentication, personal access tokens need
+		// the permission the route is grouped under
+		router.With(middleware.AuthMiddleware(&config, "post:list")).Get("/", module.Handler.ListPosts)
+		router.With(middleware.AuthMiddleware(&config, "post:view")).Group(func(router chi.Router) {
 			router.Get("/{id}", module.Handler.GetPostById)
-			router.Post("/", module.Handler.CreatePostWithFirstVersion)
-			router.Delete("/{id}", module.Handler.DeletePostById)
 			router.Get("/{id}/versions", module.Handler.ListPostVersionsGetByPostId)
 			router.Get("/{id}/versions/{versionId}", module.Handler.GetPostVersionById)
+		})
+		router.With(middleware.AuthMiddleware(&config, "post:create")).Group(func(router chi.Router) {
+			router.Post("/", module.Handler.CreatePostWithFirstVersion)
 			router.Post("/{id}/versions", module.Handler.CreateVersionFromLatest)
 			router.Post("/versions/{versionId}/duplicate", module.Handler.CreateVersionFromSpecificVersion)
 			router.Patch("/{id}/versions/{versionId}", module.Handler.UpdateUnsubmittedOwnVersion)
 			router.Post("/{id}/versions/{versionId}/submit", module.Handler.SubmitVersionForReview)
+		})
+		router.With(middleware.AuthMiddleware(&config, "post:publish")).Group(func(router chi.Router) {
 			router.Post("/{id}/versions/{versionId}/approve", module.Handler.ApproveVersion)
 			router.Post("/{id}/versions/{versionId}/reject", module.Handler.RejectVersion)
 			router.Post("/{id}/versions/{versionId}/publish", module.Handler.PublishVersion)
 			router.Patch("/{id}/versions/{versionId}/category", module.Handler.UpdateVersionCategory)
+		})
+		router.With(middleware.AuthMiddleware(&config, "post:delete")).Group(func(router chi.Router) {
+			router.Delete("/{id}", module.Handler.DeletePostById)
 			router.Delete("/{id}/versions/{versionId}", module.Handler.DeleteVersionById)
-			router.Get("/{id}/versions/{versionId}/generative-fill", module.Handler.GenerativeFill)
-			router.Post("/{id}/tags", module.Handler.AssignTagsToPost)
 		})
+		router.With(middleware.AuthMiddleware(&config, "tag:assign")).Post("/{id}/tags", module.Handler.AssignTagsToPost)
+		router.With(
+			middleware.AuthMiddleware(&config),
+			middleware.RateLimit(middleware.PolicyGenerativeFill),
+		).Get("/{id}/versions/{versionId}/generative-fill", module.Handler.GenerativeFill)
 
-		// Track-view endpoint only requires trusted frontend header
+		// Track-view endpoint only requires an API key
 		router.With(
-			middleware.TrustedFrontendMiddleware(&config),
+			middleware.APIKeyMiddleware(&config, apikeys.ScopeViewsWrite),
+			middleware.RateLimit(middleware.PolicyTrackView),
 		).Post("/track-view", module.Handler.TrackView)
 	})
 }
@@ -279,8 +279,8 @@ const (
 	WHERE id = ?
 	AND deleted_at IS NULL;`
 	QueryInsertPostView = `
-	INSERT INTO post_views (post_id, user_agent)
-	VALUES (?, ?);`
+	INSERT INTO post_views (post_id, user_agent, ip_address)
+	VALUES (?, ?, ?);`
 	QueryPostGetListCount = `
 	SELECT COUNT(*)
 	FROM posts p
@@ -336,6 +336,14 @@ const (
 	FROM post_versions pv
 	LEFT JOIN categories c ON c.id = pv.category_id
 	WHERE pv.id = ? AND pv.deleted_at IS NULL;`
+	QueryGetVersionAuditFields = `
+	SELECT title, slug, content, description, category_id
+	FROM post_versions
+	WHERE id = ? AND deleted_at IS NULL;`
+	QueryGetVersionCategoryId = `
+	SELECT category_id
+	FROM post_versions
+	WHERE id = ? AND deleted_at IS NULL;`
 	QueryUpdateVersionCategoryOnly = `
 	UPDATE post_versions
 	SET category_id = ?, updated_at = CURRENT_TIMESTAMP
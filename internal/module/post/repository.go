@@ -1,6 +1,7 @@
 package post
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/post/models"
 	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/schemas/responses"
@@ -12,7 +13,7 @@ import (
 )
 
 type PostRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 // formatCoverImagePath converts database cover image filename to API path format
@@ -34,6 +35,13 @@ func NewPostRepository(database *sql.DB) PostRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *PostRepository) WithTx(tx *sql.Tx) PostRepository {
+	return PostRepository{
+		tx,
+	}
+}
+
 func (repository *PostRepository) GetPostList() (
 	[]models.ResponsePostCard,
 	error,
@@ -110,6 +118,27 @@ func (repository *PostRepository) GetPostListPaginated(
 		args = append(args, *filters.AuthorId)
 	}
 
+	// Limit category-scoped users to their categories and their own posts
+	if filters.Scope != nil {
+		clause := "p.created_by = ?"
+		if len(filters.Scope.CategoryIds) > 0 {
+			placeholders := strings.TrimSuffix(
+				strings.Repeat("?, ", len(filters.Scope.CategoryIds)),
+				", ",
+			)
+			clause = fmt.Sprintf(
+				"(COALESCE(current_pv.category_id, best_pv.category_id) IN (%s) OR %s)",
+				placeholders,
+				clause,
+			)
+			for _, categoryId := range filters.Scope.CategoryIds {
+				args = append(args, categoryId)
+			}
+		}
+		whereClauses = append(whereClauses, clause)
+		args = append(args, filters.Scope.UserId)
+	}
+
 	// Build WHERE clause
 	whereClause := ""
 	if len(whereClauses) > 0 {
@@ -303,7 +332,7 @@ func (repository *PostRepository) CreatePost(
 	readTime int,
 	authorId int64,
 ) (int64, int64, error) {
-	transaction, err := repository.database.Begin()
+	transaction, err := db.Begin(repository.database)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -525,7 +554,7 @@ func (repository *PostRepository) CreateVersionFromLatest(
 	id int64,
 	authorId int64,
 ) (int64, error) {
-	transaction, err := repository.database.Begin()
+	transaction, err := db.Begin(repository.database)
 	if err != nil {
 		return 0, err
 	}
@@ -582,7 +611,7 @@ func (repository *PostRepository) CreateVersionFromSpecificVersion(
 	versionId int64,
 	authorId int64,
 ) (int64, error) {
-	transaction, err := repository.database.Begin()
+	transaction, err := db.Begin(repository.database)
 	if err != nil {
 		return 0, err
 	}
@@ -827,14 +856,18 @@ func (repository *PostRepository) GetVersionSlug(versionId int64) (string, error
 	return slug, nil
 }
 
-func (repository *PostRepository) TrackView(postId int64, userAgent string) error {
-	transaction, err := repository.database.Begin()
+func (repository *PostRepository) TrackView(
+	postId int64,
+	userAgent string,
+	ip string,
+) error {
+	transaction, err := db.Begin(repository.database)
 	if err != nil {
 		return err
 	}
 
 	// Insert view record
-	_, err = transaction.Exec(QueryInsertPostView, postId, userAgent)
+	_, err = transaction.Exec(QueryInsertPostView, postId, userAgent, ip)
 	if err != nil {
 		transaction.Rollback()
 		return err
@@ -899,7 +932,7 @@ func (repository *PostRepository) AssignTagsToPost(postId int64, tagIds []int64)
 	}
 
 	// Start transaction
-	transaction, err := repository.database.Begin()
+	transaction, err := db.Begin(repository.database)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -966,7 +999,7 @@ func (repository *PostRepository) checkTagExists(tagId int64) (bool, error) {
 	return count > 0, nil
 }
 
-func (repository *PostRepository) removeTagsFromPostBatch(transaction *sql.Tx, postId int64, tagIds []int64) error {
+func (repository *PostRepository) removeTagsFromPostBatch(transaction db.Executor, postId int64, tagIds []int64) error {
 	if len(tagIds) == 0 {
 		return nil
 	}
@@ -986,7 +1019,7 @@ func (repository *PostRepository) removeTagsFromPostBatch(transaction *sql.Tx, p
 	return err
 }
 
-func (repository *PostRepository) addTagsToPostBatch(transaction *sql.Tx, postId int64, tagIds []int64) error {
+func (repository *PostRepository) addTagsToPostBatch(transaction db.Executor, postId int64, tagIds []int64) error {
 	if len(tagIds) == 0 {
 		return nil
 	}
@@ -1120,6 +1153,42 @@ func (repository *PostRepository) CheckIfVersionCategoryIsDeleted(versionId int6
 	return isDeleted, nil
 }
 
+func (repository *PostRepository) GetVersionAuditFields(versionId int64) (*models.QueryGetVersionAuditFields, error) {
+	row := repository.database.QueryRow(QueryGetVersionAuditFields, versionId)
+
+	var fields models.QueryGetVersionAuditFields
+	err := row.Scan(
+		&fields.Title,
+		&fields.Slug,
+		&fields.Content,
+		&fields.Description,
+		&fields.CategoryId,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apierrors.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &fields, nil
+}
+
+// Returns the version's category, or nil if none is set yet.
+func (repository *PostRepository) GetVersionCategoryId(versionId int64) (*int64, error) {
+	row := repository.database.QueryRow(QueryGetVersionCategoryId, versionId)
+
+	var categoryId *int64
+	if err := row.Scan(&categoryId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apierrors.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return categoryId, nil
+}
+
 func (repository *PostRepository) UpdateVersionCategoryOnly(versionId int64, categoryId int64) error {
 	result, err := repository.database.Exec(QueryUpdateVersionCategoryOnly, categoryId, versionId)
 	if err != nil {
@@ -2,14 +2,14 @@ package post
 
 import (
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/ai"
 	aimodels "bloggo/internal/module/ai/models"
-	"bloggo/internal/module/audit"
-	auditmodels "bloggo/internal/module/audit/models"
 	"bloggo/internal/module/post/models"
-	"bloggo/internal/module/webhook"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/cryptography"
 	"bloggo/internal/utils/file/transformfile"
 	"bloggo/internal/utils/file/validatefile"
@@ -34,6 +34,7 @@ type PostService struct {
 	permissions    permissions.Store
 	aiService      ai.AIService
 	cache          *GenerativeFillCache
+	outbox         *outbox.Outbox
 }
 
 type CacheEntry struct {
@@ -86,6 +87,7 @@ func NewPostService(
 	imageValidator validatefile.FileValidator,
 	coverResizer transformfile.FileTransformer,
 	permissions permissions.Store,
+	outbox *outbox.Outbox,
 ) PostService {
 	return PostService{
 		repository:     repository,
@@ -95,6 +97,7 @@ func NewPostService(
 		permissions:    permissions,
 		aiService:      ai.NewAIService(),
 		cache:          NewGenerativeFillCache(),
+		outbox:         outbox,
 	}
 }
 
@@ -107,7 +110,16 @@ func (service *PostService) GetPostList() (
 
 func (service *PostService) GetPostListPaginated(
 	filters *models.RequestPostFilters,
+	userId int64,
 ) (*responses.PaginatedResponse[models.ResponsePostCard], error) {
+	// Users whose listing is narrowed to some categories still see their own posts
+	if categoryIds, scoped := service.permissions.CategoryScope(userId, "post:list"); scoped {
+		filters.Scope = &models.PostListScope{
+			UserId:      userId,
+			CategoryIds: categoryIds,
+		}
+	}
+
 	response, err := service.repository.GetPostListPaginated(filters)
 	if err != nil {
 		return nil, err
@@ -180,6 +192,7 @@ func (service *PostService) GetPostBySlug(
 func (service *PostService) CreatePostWithFirstVersion(
 	model *models.RequestPostUpsert,
 	userId int64,
+	auditContext auditcontext.Context,
 ) (*models.ResponsePostCreated, error) {
 	var filePath string
 
@@ -212,7 +225,21 @@ func (service *PostService) CreatePostWithFirstVersion(
 	}
 	estimatedReadTime := readtime.EstimateReadTime(content)
 
-	createdPostId, createdVersionId, err := service.repository.CreatePost(model, filePath, estimatedReadTime, userId)
+	var createdPostId, createdVersionId int64
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		createdPostId, createdVersionId, err = repository.CreatePost(model, filePath, estimatedReadTime, userId)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.PostCreated{
+			ActorID: userId,
+			Context: auditContext,
+			PostID:  createdPostId,
+		})
+	})
 	if err != nil {
 		// If cannot created and file was uploaded, delete it
 		if filePath != "" {
@@ -221,9 +248,6 @@ func (service *PostService) CreatePostWithFirstVersion(
 		return nil, err
 	}
 
-	// Log post creation audit
-	audit.LogPostAction(&userId, createdPostId, auditmodels.ActionPostCreated)
-
 	return &models.ResponsePostCreated{
 		PostId:    createdPostId,
 		VersionId: createdVersionId,
@@ -275,6 +299,8 @@ func (service *PostService) GetPostVersionById(
 
 func (service *PostService) DeletePostById(
 	id int64,
+	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Store cover photo paths before deleting post
 	coverPaths, err := service.repository.GetAllRelatedCovers(id)
@@ -284,12 +310,30 @@ func (service *PostService) DeletePostById(
 
 	// Get post details before deletion to get the slug
 	post, err := service.repository.GetPostById(id)
-	slug := ""
+	var slug *string
 	if err == nil && post != nil && post.Slug != nil && *post.Slug != "" {
-		slug = *post.Slug
+		slug = post.Slug
+	}
+	var before map[string]interface{}
+	if err == nil && post != nil {
+		before = versionSnapshot(post.VersionId, post.Title, post.Slug)
 	}
 
-	if err := service.repository.SoftDeletePostById(id); err != nil {
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.SoftDeletePostById(id); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.PostDeleted{
+			ActorID: userId,
+			Context: auditContext,
+			PostID:  id,
+			Slug:    slug,
+			Before:  before,
+		})
+	})
+	if err != nil {
 		return err
 	}
 
@@ -298,13 +342,6 @@ func (service *PostService) DeletePostById(
 		service.bucket.Delete(path)
 	}
 
-	// Trigger webhook for post deletion
-	if slug != "" {
-		go func() {
-			webhook.TriggerPostDeleted(id, slug)
-		}()
-	}
-
 	return nil
 }
 
@@ -341,6 +378,7 @@ func (service *PostService) UpdateUnsubmittedOwnVersion(
 	versionId int64,
 	userId int64,
 	model *models.RequestPostUpsert,
+	auditContext auditcontext.Context,
 ) error {
 	// 1. Check if the owner of version ismn same as requester
 	versionCreator, versionStatus, err :=
@@ -393,14 +431,39 @@ func (service *PostService) UpdateUnsubmittedOwnVersion(
 		readTime = &calculatedReadTime
 	}
 
-	if err := service.repository.UpdateVersionById(
-		postId,
-		versionId,
-		userId,
-		model,
-		filePath,
-		readTime,
-	); err != nil {
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		before, err := repository.GetVersionAuditFields(versionId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.UpdateVersionById(
+			postId,
+			versionId,
+			userId,
+			model,
+			filePath,
+			readTime,
+		); err != nil {
+			return err
+		}
+
+		after, err := repository.GetVersionAuditFields(versionId)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.VersionUpdated{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Before:    versionAuditSnapshot(before),
+			After:     versionAuditSnapshot(after),
+		})
+	})
+	if err != nil {
 		// If cannot created, delete newly uploaded file
 		if filePath != nil {
 			service.bucket.Delete(*filePath)
@@ -415,6 +478,7 @@ func (service *PostService) SubmitVersionForReview(
 	postId int64,
 	versionId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if the owner of version is same as requester
 	versionCreator, versionStatus, err :=
@@ -438,20 +502,41 @@ func (service *PostService) SubmitVersionForReview(
 		return err
 	}
 
-	// Update version status to pending (submitted for review)
-	return service.repository.UpdateVersionStatus(
-		versionId,
-		models.STATUS_PENDING,
-		userId,
-	)
+	slug := service.versionSlug(versionId)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		// Update version status to pending (submitted for review)
+		repository := service.repository.WithTx(tx.Tx)
+		err := repository.UpdateVersionStatus(
+			versionId,
+			models.STATUS_PENDING,
+			userId,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.VersionSubmitted{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Slug:      slug,
+		})
+	})
 }
 
 func (service *PostService) ApproveVersion(
 	postId int64,
 	versionId int64,
 	userId int64,
+	roleId int64,
 	note *string,
+	auditContext auditcontext.Context,
 ) error {
+	if err := service.checkVersionCategoryPermission(versionId, userId, roleId); err != nil {
+		return err
+	}
+
 	// Check if version exists and get current status
 	_, versionStatus, err := service.repository.GetVersionCreatorAndStatus(versionId)
 	if err != nil {
@@ -463,21 +548,43 @@ func (service *PostService) ApproveVersion(
 		return apierrors.ErrPreconditionFailed
 	}
 
-	// Update version status to approved
-	return service.repository.UpdateVersionStatusWithNote(
-		versionId,
-		models.STATUS_APPROVED,
-		userId,
-		note,
-	)
+	slug := service.versionSlug(versionId)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		// Update version status to approved
+		repository := service.repository.WithTx(tx.Tx)
+		err := repository.UpdateVersionStatusWithNote(
+			versionId,
+			models.STATUS_APPROVED,
+			userId,
+			note,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.VersionApproved{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Slug:      slug,
+			Note:      note,
+		})
+	})
 }
 
 func (service *PostService) RejectVersion(
 	postId int64,
 	versionId int64,
 	userId int64,
+	roleId int64,
 	note *string,
+	auditContext auditcontext.Context,
 ) error {
+	if err := service.checkVersionCategoryPermission(versionId, userId, roleId); err != nil {
+		return err
+	}
+
 	// Check if version exists and get current status
 	_, versionStatus, err := service.repository.GetVersionCreatorAndStatus(versionId)
 	if err != nil {
@@ -489,13 +596,29 @@ func (service *PostService) RejectVersion(
 		return apierrors.ErrPreconditionFailed
 	}
 
-	// Update version status to rejected
-	return service.repository.UpdateVersionStatusWithNote(
-		versionId,
-		models.STATUS_REJECTED,
-		userId,
-		note,
-	)
+	slug := service.versionSlug(versionId)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		// Update version status to rejected
+		repository := service.repository.WithTx(tx.Tx)
+		err := repository.UpdateVersionStatusWithNote(
+			versionId,
+			models.STATUS_REJECTED,
+			userId,
+			note,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.VersionRejected{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Slug:      slug,
+			Note:      note,
+		})
+	})
 }
 
 func (service *PostService) DeleteVersionById(
@@ -503,6 +626,7 @@ func (service *PostService) DeleteVersionById(
 	versionId int64,
 	userId int64,
 	roleId int64,
+	auditContext auditcontext.Context,
 ) (*models.ResponseVersionDeleted, error) {
 	// Get version details including creator and status
 	versionCreator, versionStatus, err := service.repository.GetVersionCreatorAndStatus(versionId)
@@ -530,6 +654,13 @@ func (service *PostService) DeleteVersionById(
 		}
 	}
 
+	// Keep the version as it was for the audit log
+	version, err := service.repository.GetPostVersionById(postId, versionId)
+	if err != nil {
+		return nil, err
+	}
+	before := versionSnapshot(versionId, version.Title, version.Slug)
+
 	// Check if this is the last version of the post
 	versionCount, err := service.repository.CountPostVersions(postId)
 	if err != nil {
@@ -543,9 +674,27 @@ func (service *PostService) DeleteVersionById(
 		if err != nil {
 			return nil, err
 		}
+		publishedSlug, err := service.repository.GetPostPublishedSlug(postId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+			// Delete the entire post (soft delete)
+			repository := service.repository.WithTx(tx.Tx)
+			if err := repository.SoftDeletePostById(postId); err != nil {
+				return err
+			}
 
-		// Delete the entire post (soft delete)
-		if err := service.repository.SoftDeletePostById(postId); err != nil {
+			return tx.Publish(events.PostDeleted{
+				ActorID: userId,
+				Context: auditContext,
+				PostID:  postId,
+				Slug:    publishedSlug,
+				Before:  before,
+			})
+		})
+		if err != nil {
 			return nil, err
 		}
 
@@ -554,9 +703,6 @@ func (service *PostService) DeleteVersionById(
 			service.bucket.Delete(path)
 		}
 
-		// Log post deletion audit
-		audit.LogPostAction(&userId, postId, auditmodels.ActionPostDeleted)
-
 		return &models.ResponseVersionDeleted{PostDeleted: true}, nil
 	}
 
@@ -572,15 +718,29 @@ func (service *PostService) DeleteVersionById(
 		return nil, err
 	}
 
-	// If it's currently published, set the post's current_version_id to NULL
-	if isCurrentlyPublished {
-		if err := service.repository.SetPostCurrentVersionToNull(versionId); err != nil {
-			return nil, err
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+
+		// If it's currently published, set the post's current_version_id to NULL
+		if isCurrentlyPublished {
+			if err := repository.SetPostCurrentVersionToNull(versionId); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Perform soft delete
-	if err := service.repository.SoftDeleteVersionById(versionId); err != nil {
+		// Perform soft delete
+		if err := repository.SoftDeleteVersionById(versionId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.VersionDeleted{
+			ActorID:   userId,
+			Context:   auditContext,
+			VersionID: versionId,
+			Before:    before,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -602,9 +762,6 @@ func (service *PostService) DeleteVersionById(
 		}
 	}
 
-	// Log version deletion audit
-	audit.LogVersionAction(&userId, versionId, auditmodels.ActionVersionDeleted, nil)
-
 	return &models.ResponseVersionDeleted{PostDeleted: false}, nil
 }
 
@@ -613,11 +770,11 @@ func (service *PostService) PublishVersion(
 	versionId int64,
 	userId int64,
 	roleId int64,
+	auditContext auditcontext.Context,
 ) error {
-	// Check if user has publish permission
-	hasPublishPermission := service.permissions.HasPermission(roleId, "post:publish")
-	if !hasPublishPermission {
-		return apierrors.ErrForbidden
+	// Check if user has publish permission for the version's category
+	if err := service.checkVersionCategoryPermission(versionId, userId, roleId); err != nil {
+		return err
 	}
 
 	// Check if version exists and get current status
@@ -645,102 +802,156 @@ func (service *PostService) PublishVersion(
 		)
 	}
 
-	// Check if the post already has a published version and unpublish it
-	currentVersionId, currentStatus, err := service.repository.GetPostCurrentVersionIdAndStatus(postId)
-	if err != nil {
-		return err
-	}
+	// The unpublishing, publishing and its event are committed together
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
 
-	// Get the old slug and category (from currently published version) if it exists
-	var oldSlug *string
-	var oldCategory *string
-	if currentVersionId != nil && currentStatus != nil && *currentStatus == models.STATUS_PUBLISHED {
-		oldSlugValue, err := service.repository.GetVersionSlug(*currentVersionId)
-		if err == nil {
-			oldSlug = &oldSlugValue
-		}
-		oldCategoryValue, err := service.repository.GetVersionCategorySlug(*currentVersionId)
-		if err == nil {
-			oldCategory = oldCategoryValue
-		}
-	}
-
-	// If there's a current version that's published and it's different from the one being published
-	if currentVersionId != nil && currentStatus != nil &&
-		*currentStatus == models.STATUS_PUBLISHED && *currentVersionId != versionId {
-		// Unpublish the previous version (set it back to approved)
-		if err := service.repository.UnpublishVersionById(*currentVersionId); err != nil {
+		// Check if the post already has a published version and unpublish it
+		currentVersionId, currentStatus, err := repository.GetPostCurrentVersionIdAndStatus(postId)
+		if err != nil {
 			return err
 		}
-	}
 
-	// Get the slug and category of the version being published
-	slug, err := service.repository.GetVersionSlug(versionId)
-	if err != nil {
-		return err
-	}
+		// Get the old slug and category (from currently published version) if it exists
+		var oldSlug *string
+		var oldCategory *string
+		if currentVersionId != nil && currentStatus != nil && *currentStatus == models.STATUS_PUBLISHED {
+			oldSlugValue, err := repository.GetVersionSlug(*currentVersionId)
+			if err == nil {
+				oldSlug = &oldSlugValue
+			}
+			oldCategoryValue, err := repository.GetVersionCategorySlug(*currentVersionId)
+			if err == nil {
+				oldCategory = oldCategoryValue
+			}
+		}
 
-	newCategory, err := service.repository.GetVersionCategorySlug(versionId)
-	if err != nil {
-		return err
-	}
+		// If there's a current version that's published and it's different from the one being published
+		if currentVersionId != nil && currentStatus != nil &&
+			*currentStatus == models.STATUS_PUBLISHED && *currentVersionId != versionId {
+			// Unpublish the previous version (set it back to approved)
+			if err := repository.UnpublishVersionById(*currentVersionId); err != nil {
+				return err
+			}
+		}
 
-	// Check if there's already a published version with the same slug
-	existingPublished, err := service.repository.GetPublishedVersionBySlug(slug)
-	if err == nil && existingPublished != nil {
-		// Unpublish the existing version (set it back to approved status)
-		if err := service.repository.UnpublishVersionBySlug(slug); err != nil {
+		// Get the slug and category of the version being published
+		slug, err := repository.GetVersionSlug(versionId)
+		if err != nil {
 			return err
 		}
 
-		// Clear the current_version_id from the post that was using the old published version
-		if err := service.repository.SetPostCurrentVersionToNull(existingPublished.Id); err != nil {
+		newCategory, err := repository.GetVersionCategorySlug(versionId)
+		if err != nil {
 			return err
 		}
-	}
 
-	// Update version status to published
-	if err := service.repository.UpdateVersionStatus(
-		versionId,
-		models.STATUS_PUBLISHED,
-		userId,
-	); err != nil {
-		return err
-	}
+		// Check if there's already a published version with the same slug
+		existingPublished, err := repository.GetPublishedVersionBySlug(slug)
+		if err == nil && existingPublished != nil {
+			// Unpublish the existing version (set it back to approved status)
+			if err := repository.UnpublishVersionBySlug(slug); err != nil {
+				return err
+			}
 
-	// Log version publication audit
-	audit.LogVersionAction(&userId, versionId, auditmodels.ActionVersionPublished, nil)
+			// Clear the current_version_id from the post that was using the old published version
+			if err := repository.SetPostCurrentVersionToNull(existingPublished.Id); err != nil {
+				return err
+			}
+		}
 
-	// Set this version as the current published version for the post
-	err = service.repository.SetCurrentVersionForPost(postId, versionId)
-	if err != nil {
-		return err
-	}
+		// Update version status to published
+		if err := repository.UpdateVersionStatus(
+			versionId,
+			models.STATUS_PUBLISHED,
+			userId,
+		); err != nil {
+			return err
+		}
 
-	// Trigger webhook for post publish
-	go func() {
-		data := map[string]interface{}{
-			"versionId": versionId,
-			"slug":      slug,
+		// Set this version as the current published version for the post
+		err = repository.SetCurrentVersionForPost(postId, versionId)
+		if err != nil {
+			return err
 		}
 
+		published := events.PostPublished{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Slug:      slug,
+			OldSlug:   oldSlug,
+		}
 		// Include category information if categories are different
 		if oldCategory != nil && newCategory != nil && *oldCategory != *newCategory {
-			data["oldCategory"] = *oldCategory
-			data["newCategory"] = *newCategory
+			published.OldCategory = oldCategory
+			published.NewCategory = newCategory
 		} else if oldCategory == nil && newCategory != nil {
 			// First publish or old version had no category
-			data["newCategory"] = *newCategory
+			published.NewCategory = newCategory
 		}
+		return tx.Publish(published)
+	})
+}
 
-		webhook.TriggerPostUpdated(postId, slug, oldSlug, data)
-	}()
+// Moderating a version requires post:publish for the version's category.
+func (service *PostService) checkVersionCategoryPermission(
+	versionId int64,
+	userId int64,
+	roleId int64,
+) error {
+	categoryId, err := service.repository.GetVersionCategoryId(versionId)
+	if err != nil {
+		return err
+	}
 
+	if !service.permissions.HasCategoryPermission(roleId, userId, "post:publish", categoryId) {
+		return apierrors.ErrForbidden
+	}
 	return nil
 }
 
-func (service *PostService) TrackView(model *models.RequestTrackView) error {
-	return service.repository.TrackView(model.PostId, model.UserAgent)
+// The audited fields of a deleted version, or of the version shown for a
+// deleted post
+func versionSnapshot(versionId int64, title *string, slug *string) map[string]interface{} {
+	return map[string]interface{}{
+		"versionId": versionId,
+		"title":     title,
+		"slug":      slug,
+	}
+}
+
+// The audited fields of an updated version, the content only as a hash
+func versionAuditSnapshot(fields *models.QueryGetVersionAuditFields) map[string]interface{} {
+	var contentHash *string
+	if fields.Content != nil {
+		hash := cryptography.HashString(*fields.Content)
+		contentHash = &hash
+	}
+	return map[string]interface{}{
+		"title":       fields.Title,
+		"slug":        fields.Slug,
+		"contentHash": contentHash,
+		"description": fields.Description,
+		"categoryId":  fields.CategoryId,
+	}
+}
+
+// Slug of the version for webhooks, nil when it has none yet
+func (service *PostService) versionSlug(versionId int64) *string {
+	slug, err := service.repository.GetVersionSlug(versionId)
+	if err != nil || slug == "" {
+		return nil
+	}
+	return &slug
+}
+
+func (service *PostService) TrackView(
+	model *models.RequestTrackView,
+	ip string,
+) error {
+	return service.repository.TrackView(model.PostId, model.UserAgent, ip)
 }
 
 // validateVersionForSubmission validates that a post version has all required fields
@@ -871,46 +1082,45 @@ func (service *PostService) AssignTagsToPost(
 		return apierrors.ErrForbidden
 	}
 
-	addedTagIds, removedTagIds, err := service.repository.AssignTagsToPost(postId, tagIds)
-	if err != nil {
-		return err
-	}
-
-	// If there were changes, trigger webhook
-	if len(addedTagIds) > 0 || len(removedTagIds) > 0 {
-		// Get the published slug for this post
-		publishedSlug, err := service.repository.GetPostPublishedSlug(postId)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		addedTagIds, removedTagIds, err := repository.AssignTagsToPost(postId, tagIds)
 		if err != nil {
-			// Log error but don't fail the operation
-			log.Printf("Failed to get published slug for webhook: %v", err)
+			return err
 		}
 
-		// Only trigger webhook if post has a published version
-		if publishedSlug != nil {
+		// If there were changes, publish them
+		if len(addedTagIds) > 0 || len(removedTagIds) > 0 {
+			// Get the published slug for this post
+			publishedSlug, err := repository.GetPostPublishedSlug(postId)
+			if err != nil {
+				// Log error but don't fail the operation
+				log.Printf("Failed to get published slug of post %d: %v", postId, err)
+			}
+
 			// Get slugs for added and removed tags
-			addedTagSlugs, err := service.repository.GetTagSlugsByIds(addedTagIds)
+			addedTagSlugs, err := repository.GetTagSlugsByIds(addedTagIds)
 			if err != nil {
-				log.Printf("Failed to get added tag slugs for webhook: %v", err)
+				log.Printf("Failed to get added tag slugs: %v", err)
 				addedTagSlugs = []string{}
 			}
 
-			removedTagSlugs, err := service.repository.GetTagSlugsByIds(removedTagIds)
+			removedTagSlugs, err := repository.GetTagSlugsByIds(removedTagIds)
 			if err != nil {
-				log.Printf("Failed to get removed tag slugs for webhook: %v", err)
+				log.Printf("Failed to get removed tag slugs: %v", err)
 				removedTagSlugs = []string{}
 			}
 
-			// Trigger webhook with tag changes
-			go func() {
-				webhook.TriggerPostUpdated(postId, *publishedSlug, nil, map[string]interface{}{
-					"addedTags":   addedTagSlugs,
-					"removedTags": removedTagSlugs,
-				})
-			}()
+			return tx.Publish(events.PostTagsChanged{
+				PostID:        postId,
+				PublishedSlug: publishedSlug,
+				AddedTags:     addedTagSlugs,
+				RemovedTags:   removedTagSlugs,
+			})
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 func (service *PostService) UpdateVersionCategory(
@@ -919,13 +1129,25 @@ func (service *PostService) UpdateVersionCategory(
 	categoryId int64,
 	userId int64,
 	roleId int64,
+	auditContext auditcontext.Context,
 ) error {
-	// Check if user has publish permission (required to update approved version category)
-	hasPublishPermission := service.permissions.HasPermission(roleId, "post:publish")
+	// Check if user has publish permission for the new category (required to update approved version category)
+	hasPublishPermission := service.permissions.HasCategoryPermission(
+		roleId,
+		userId,
+		"post:publish",
+		&categoryId,
+	)
 	if !hasPublishPermission {
 		return apierrors.ErrForbidden
 	}
 
+	// And for the category it leaves, so a version can't be moved from a
+	// category the user can't publish in to one they can
+	if err := service.checkVersionCategoryPermission(versionId, userId, roleId); err != nil {
+		return err
+	}
+
 	// Check if version exists and get current status
 	_, versionStatus, err := service.repository.GetVersionCreatorAndStatus(versionId)
 	if err != nil {
@@ -951,10 +1173,32 @@ func (service *PostService) UpdateVersionCategory(
 	}
 
 	// Update the category
-	if err := service.repository.UpdateVersionCategoryOnly(versionId, categoryId); err != nil {
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		before, err := repository.GetVersionAuditFields(versionId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.UpdateVersionCategoryOnly(versionId, categoryId); err != nil {
+			return err
+		}
+
+		after := *before
+		after.CategoryId = &categoryId
+		return tx.Publish(events.VersionUpdated{
+			ActorID:   userId,
+			Context:   auditContext,
+			PostID:    postId,
+			VersionID: versionId,
+			Before:    versionAuditSnapshot(before),
+			After:     versionAuditSnapshot(&after),
+		})
+	})
+	if err != nil {
 		return err
 	}
 
 	// Automatically publish the version after updating the category
-	return service.PublishVersion(postId, versionId, userId, roleId)
+	return service.PublishVersion(postId, versionId, userId, roleId, auditContext)
 }
@@ -3,6 +3,7 @@ package removal_request
 import (
 	"bloggo/internal/module/removal_request/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
@@ -46,6 +47,7 @@ func (handler *RemovalRequestHandler) CreateRemovalRequest(
 		body.PostVersionId,
 		userId,
 		note,
+		auditcontext.FromRequest(request),
 	)
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
@@ -169,7 +171,7 @@ func (handler *RemovalRequestHandler) ApproveRemovalRequest(
 		return
 	}
 
-	if err := handler.service.ApproveRemovalRequest(id, userId, roleId, &body.DecisionNote); err != nil {
+	if err := handler.service.ApproveRemovalRequest(id, userId, roleId, &body.DecisionNote, auditcontext.FromRequest(request)); err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
 				Message: "You don't have permission to approve removal requests.",
@@ -213,7 +215,7 @@ func (handler *RemovalRequestHandler) RejectRemovalRequest(
 		return
 	}
 
-	if err := handler.service.RejectRemovalRequest(id, userId, roleId, &body.DecisionNote); err != nil {
+	if err := handler.service.RejectRemovalRequest(id, userId, roleId, &body.DecisionNote, auditcontext.FromRequest(request)); err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
 				Message: "You don't have permission to reject removal requests.",
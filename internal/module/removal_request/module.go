@@ -4,6 +4,7 @@ import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 	"fmt"
@@ -28,7 +29,7 @@ func NewModule() RemovalRequestModule {
 	}
 
 	repository := NewRemovalRequestRepository(database)
-	service := NewRemovalRequestService(repository, permissionStore, bucketInstance)
+	service := NewRemovalRequestService(repository, permissionStore, bucketInstance, outbox.Get())
 	handler := NewRemovalRequestHandler(service)
 
 	return RemovalRequestModule{
@@ -118,6 +118,12 @@ const (
 	WHERE id = ?
 	AND deleted_at IS NULL;`
 
+	QueryGetPostPublishedSlug = `
+	SELECT pv.slug
+	FROM posts p
+	JOIN post_versions pv ON pv.id = p.current_version_id
+	WHERE p.id = ? AND pv.status = 5 AND p.deleted_at IS NULL AND pv.deleted_at IS NULL;`
+
 	QueryGetAllVersionsForPost = `
 	SELECT cover_image
 	FROM post_versions
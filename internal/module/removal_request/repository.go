@@ -1,6 +1,7 @@
 package removal_request
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/removal_request/models"
 	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/filter"
@@ -14,7 +15,7 @@ import (
 )
 
 type RemovalRequestRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewRemovalRequestRepository(database *sql.DB) RemovalRequestRepository {
@@ -23,6 +24,13 @@ func NewRemovalRequestRepository(database *sql.DB) RemovalRequestRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *RemovalRequestRepository) WithTx(tx *sql.Tx) RemovalRequestRepository {
+	return RemovalRequestRepository{
+		tx,
+	}
+}
+
 func (repository *RemovalRequestRepository) CreateRemovalRequest(
 	postVersionId int64,
 	requestedBy int64,
@@ -618,6 +626,25 @@ func (repository *RemovalRequestRepository) GetPostIdFromVersionId(
 	return postId, nil
 }
 
+// GetPostPublishedSlug gets the slug of the post's published version, nil
+// when it has none
+func (repository *RemovalRequestRepository) GetPostPublishedSlug(
+	postId int64,
+) (*string, error) {
+	row := repository.database.QueryRow(QueryGetPostPublishedSlug, postId)
+
+	var slug string
+	err := row.Scan(&slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &slug, nil
+}
+
 // VersionInfo holds minimal version information for image cleanup
 type VersionInfo struct {
 	CoverImage *string
@@ -2,10 +2,12 @@ package removal_request
 
 import (
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/removal_request/models"
 	"bloggo/internal/utils/apierrors"
-	"bloggo/internal/utils/audit"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/pagination"
 	"bloggo/internal/utils/schemas/responses"
@@ -15,17 +17,20 @@ type RemovalRequestService struct {
 	repository  RemovalRequestRepository
 	permissions permissions.Store
 	bucket      bucket.Bucket
+	outbox      *outbox.Outbox
 }
 
 func NewRemovalRequestService(
 	repository RemovalRequestRepository,
 	permissions permissions.Store,
 	bucket bucket.Bucket,
+	outbox *outbox.Outbox,
 ) RemovalRequestService {
 	return RemovalRequestService{
 		repository,
 		permissions,
 		bucket,
+		outbox,
 	}
 }
 
@@ -33,6 +38,7 @@ func (service *RemovalRequestService) CreateRemovalRequest(
 	postVersionId int64,
 	requestedBy int64,
 	note *string,
+	auditContext auditcontext.Context,
 ) (*responses.ResponseCreated, error) {
 	// Check if the version exists (but allow all statuses)
 	_, err := service.repository.GetVersionOwnerAndStatus(postVersionId)
@@ -50,14 +56,27 @@ func (service *RemovalRequestService) CreateRemovalRequest(
 	}
 
 	// Create the removal request
-	id, err := service.repository.CreateRemovalRequest(postVersionId, requestedBy, note)
+	var id int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.CreateRemovalRequest(postVersionId, requestedBy, note)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RemovalRequestCreated{
+			ActorID:       requestedBy,
+			Context:       auditContext,
+			RequestID:     id,
+			PostVersionID: postVersionId,
+			Note:          note,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Log the audit event
-	audit.LogAction(&requestedBy, "removal_request", id, "requested")
-
 	return &responses.ResponseCreated{
 		Id: id,
 	}, nil
@@ -110,6 +129,7 @@ func (service *RemovalRequestService) ApproveRemovalRequest(
 	decidedBy int64,
 	userRoleId int64,
 	decisionNote *string,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to approve removal requests (editors/admins)
 	hasPermission := service.permissions.HasPermission(userRoleId, "post:delete")
@@ -134,6 +154,12 @@ func (service *RemovalRequestService) ApproveRemovalRequest(
 		return err
 	}
 
+	// Receivers of the deletion know the post by its published slug
+	publishedSlug, err := service.repository.GetPostPublishedSlug(postId)
+	if err != nil {
+		return err
+	}
+
 	// Get all versions for this post to collect cover images
 	versions, err := service.repository.GetAllVersionsForPost(postId)
 	if err != nil {
@@ -148,45 +174,69 @@ func (service *RemovalRequestService) ApproveRemovalRequest(
 		}
 	}
 
-	// Soft delete all versions of the post
-	if err := service.repository.SoftDeleteAllVersionsForPost(postId); err != nil {
-		return err
-	}
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
 
-	// Soft delete the post itself
-	if err := service.repository.SoftDeletePost(postId); err != nil {
-		return err
-	}
+		// Soft delete all versions of the post
+		if err := repository.SoftDeleteAllVersionsForPost(postId); err != nil {
+			return err
+		}
 
-	// Clean up all cover images from storage
-	for imagePath := range coverImages {
-		// Delete the image from storage
-		service.bucket.Delete(imagePath)
-	}
+		// Soft delete the post itself
+		if err := repository.SoftDeletePost(postId); err != nil {
+			return err
+		}
 
-	// Finally, approve the removal request
-	err = service.repository.ApproveRemovalRequest(id, decidedBy, decisionNote)
+		// Finally, approve the removal request
+		if err := repository.ApproveRemovalRequest(id, decidedBy, decisionNote); err != nil {
+			return err
+		}
+
+		// Auto-approve all other pending removal requests for the same post
+		autoApprovalNote := "Automatically approved - post was already deleted"
+		err := repository.AutoApproveOtherRemovalRequestsForPost(
+			postId,
+			id,
+			decidedBy,
+			&autoApprovalNote,
+		)
+		if err != nil {
+			// Log error but don't fail the operation
+			// The main request was already approved successfully
+		}
+
+		if err := tx.Publish(events.RemovalRequestApproved{
+			ActorID:       decidedBy,
+			Context:       auditContext,
+			RequestID:     id,
+			PostVersionID: request.PostVersionId,
+			PostID:        postId,
+			Note:          decisionNote,
+		}); err != nil {
+			return err
+		}
+		return tx.Publish(events.PostDeleted{
+			ActorID: decidedBy,
+			Context: auditContext,
+			PostID:  postId,
+			Slug:    publishedSlug,
+			Before: map[string]interface{}{
+				"versionId": request.PostVersionId,
+				"title":     request.PostTitle,
+				"slug":      publishedSlug,
+			},
+		})
+	})
 	if err != nil {
 		return err
 	}
 
-	// Auto-approve all other pending removal requests for the same post
-	autoApprovalNote := "Automatically approved - post was already deleted"
-	err = service.repository.AutoApproveOtherRemovalRequestsForPost(
-		postId,
-		id,
-		decidedBy,
-		&autoApprovalNote,
-	)
-	if err != nil {
-		// Log error but don't fail the operation
-		// The main request was already approved successfully
+	// Clean up all cover images from storage once the deletion is committed
+	for imagePath := range coverImages {
+		// Delete the image from storage
+		service.bucket.Delete(imagePath)
 	}
 
-	// Log the audit events
-	audit.LogAction(&decidedBy, "removal_request", id, "approved")
-	audit.LogAction(&decidedBy, "post", postId, "deleted")
-
 	return nil
 }
 
@@ -195,6 +245,7 @@ func (service *RemovalRequestService) RejectRemovalRequest(
 	decidedBy int64,
 	userRoleId int64,
 	decisionNote *string,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to reject removal requests (editors/admins)
 	hasPermission := service.permissions.HasPermission(userRoleId, "post:delete")
@@ -213,14 +264,19 @@ func (service *RemovalRequestService) RejectRemovalRequest(
 		return apierrors.ErrPreconditionFailed
 	}
 
-	// Reject the request
-	err = service.repository.RejectRemovalRequest(id, decidedBy, decisionNote)
-	if err != nil {
-		return err
-	}
-
-	// Log the audit event
-	audit.LogAction(&decidedBy, "removal_request", id, "rejected")
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		// Reject the request
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.RejectRemovalRequest(id, decidedBy, decisionNote); err != nil {
+			return err
+		}
 
-	return nil
-}
\ No newline at end of file
+		return tx.Publish(events.RemovalRequestRejected{
+			ActorID:       decidedBy,
+			Context:       auditContext,
+			RequestID:     id,
+			PostVersionID: request.PostVersionId,
+			Note:          decisionNote,
+		})
+	})
+}
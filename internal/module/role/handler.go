@@ -0,0 +1,237 @@
+package role
+
+import (
+	"bloggo/internal/module/role/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type RoleHandler struct {
+	service RoleService
+}
+
+func NewRoleHandler(service RoleService) RoleHandler {
+	return RoleHandler{
+		service,
+	}
+}
+
+func (handler *RoleHandler) ListRoles(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	roles, err := handler.service.ListRoles(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(roles)
+}
+
+func (handler *RoleHandler) ListPermissions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	permissions, err := handler.service.ListPermissions(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(permissions)
+}
+
+func (handler *RoleHandler) CreateRole(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestRoleCreate](writer, request)
+	if !ok {
+		return
+	}
+
+	roleId, err := handler.service.CreateRole(body, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrBadRequest: {
+				Message: "One or more permissions do not exist.",
+				Status:  http.StatusBadRequest,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(models.ResponseRoleCreated{Id: roleId})
+}
+
+func (handler *RoleHandler) RenameRole(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestRoleUpdate](writer, request)
+	if !ok {
+		return
+	}
+
+	err := handler.service.RenameRole(id, body, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "Built-in roles can't be renamed.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *RoleHandler) GrantPermissions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestPermissionsGrant](writer, request)
+	if !ok {
+		return
+	}
+
+	err := handler.service.GrantPermissions(id, body, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrBadRequest: {
+				Message: "One or more permissions do not exist.",
+				Status:  http.StatusBadRequest,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *RoleHandler) RevokePermission(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	permission, ok := handlers.GetParam[string](writer, request, "permission")
+	if !ok {
+		return
+	}
+
+	err := handler.service.RevokePermission(id, permission, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "This permission can't be removed from the admin role.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *RoleHandler) DeleteRole(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	err := handler.service.DeleteRole(id, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "Built-in roles and roles assigned to users can't be deleted.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
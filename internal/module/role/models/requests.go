@@ -0,0 +1,17 @@
+package models
+
+// -- Create Role -- //
+type RequestRoleCreate struct {
+	Name        string   `json:"name" validate:"required,min=3,max=50"`
+	Permissions []string `json:"permissions,omitempty" validate:"omitempty,dive,required,max=50"`
+}
+
+// -- Rename Role -- //
+type RequestRoleUpdate struct {
+	Name string `json:"name" validate:"required,min=3,max=50"`
+}
+
+// -- Grant Permissions -- //
+type RequestPermissionsGrant struct {
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,required,max=50"`
+}
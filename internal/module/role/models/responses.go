@@ -0,0 +1,15 @@
+package models
+
+// -- Role With Its Permissions -- //
+type ResponseRole struct {
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	BuiltIn     bool     `json:"builtIn"`
+	UserCount   int64    `json:"userCount"`
+	Permissions []string `json:"permissions"`
+}
+
+// -- Create Role -- //
+type ResponseRoleCreated struct {
+	Id int64 `json:"id"`
+}
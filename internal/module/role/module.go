@@ -0,0 +1,44 @@
+package role
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type RoleModule struct {
+	Handler RoleHandler
+	Service RoleService
+}
+
+func NewModule() RoleModule {
+	repository := NewRoleRepository(db.Get())
+	service := NewRoleService(repository, permissions.Get(), outbox.Get())
+	handler := NewRoleHandler(service)
+
+	return RoleModule{
+		Handler: handler,
+		Service: service,
+	}
+}
+
+func (module RoleModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.With(middleware.AuthMiddleware(&config)).Route(
+		"/roles",
+		func(router chi.Router) {
+			router.Get("/", module.Handler.ListRoles)
+			router.Get("/permissions", module.Handler.ListPermissions)
+			router.Post("/", module.Handler.CreateRole)
+			router.Patch("/{id}", module.Handler.RenameRole)
+			router.Delete("/{id}", module.Handler.DeleteRole)
+			router.Post("/{id}/permissions", module.Handler.GrantPermissions)
+			router.Delete("/{id}/permissions/{permission}", module.Handler.RevokePermission)
+		},
+	)
+}
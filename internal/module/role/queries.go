@@ -0,0 +1,55 @@
+package role
+
+const (
+	QueryListRoles = `
+	SELECT r.id, r.name,
+		(SELECT COUNT(*) FROM users u WHERE u.role_id = r.id AND u.deleted_at IS NULL) AS user_count,
+		COALESCE((
+			SELECT GROUP_CONCAT(p.name, ',')
+			FROM role_permissions rp
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE rp.role_id = r.id
+		), '') AS permissions
+	FROM roles r
+	ORDER BY r.id ASC;`
+	QueryGetRoleName = `
+	SELECT name
+	FROM roles
+	WHERE id = ?;`
+	QueryListPermissions = `
+	SELECT name
+	FROM permissions
+	ORDER BY name ASC;`
+	QueryCreateRole = `
+	INSERT INTO roles (name)
+	VALUES (?);`
+	QueryRenameRole = `
+	UPDATE roles
+	SET name = ?
+	WHERE id = ?;`
+	QueryGrantPermission = `
+	INSERT INTO role_permissions (role_id, permission_id)
+	SELECT ?, id FROM permissions WHERE name = ?
+	ON CONFLICT(role_id, permission_id) DO NOTHING;`
+	QueryRevokePermission = `
+	DELETE FROM role_permissions
+	WHERE role_id = ?
+		AND permission_id = (SELECT id FROM permissions WHERE name = ?);`
+	QueryPermissionExists = `
+	SELECT COUNT(*)
+	FROM permissions
+	WHERE name = ?;`
+	QueryCountRoleUsers = `
+	SELECT COUNT(*)
+	FROM users
+	WHERE role_id = ?;`
+	QueryDeleteRolePermissions = `
+	DELETE FROM role_permissions
+	WHERE role_id = ?;`
+	QueryDeleteRoleTwoFactorRequirement = `
+	DELETE FROM role_two_factor_requirements
+	WHERE role_id = ?;`
+	QueryDeleteRole = `
+	DELETE FROM roles
+	WHERE id = ?;`
+)
@@ -0,0 +1,188 @@
+package role
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/module/role/models"
+	"bloggo/internal/utils/apierrors"
+	"database/sql"
+	"strings"
+)
+
+type RoleRepository struct {
+	database db.Executor
+}
+
+func NewRoleRepository(database *sql.DB) RoleRepository {
+	return RoleRepository{
+		database,
+	}
+}
+
+// WithTx returns the repository running in the transaction
+func (repository *RoleRepository) WithTx(tx *sql.Tx) RoleRepository {
+	return RoleRepository{
+		tx,
+	}
+}
+
+func (repository *RoleRepository) ListRoles() ([]models.ResponseRole, error) {
+	rows, err := repository.database.Query(QueryListRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.ResponseRole{}
+	for rows.Next() {
+		var role models.ResponseRole
+		var permissions string
+		if err := rows.Scan(
+			&role.Id,
+			&role.Name,
+			&role.UserCount,
+			&permissions,
+		); err != nil {
+			return nil, err
+		}
+		role.Permissions = []string{}
+		if permissions != "" {
+			role.Permissions = strings.Split(permissions, ",")
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Returns ErrNotFound for unknown roles.
+func (repository *RoleRepository) GetRoleName(roleId int64) (string, error) {
+	var name string
+	err := repository.database.QueryRow(QueryGetRoleName, roleId).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", apierrors.ErrNotFound
+	}
+	return name, err
+}
+
+func (repository *RoleRepository) ListPermissions() ([]string, error) {
+	rows, err := repository.database.Query(QueryListPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+func (repository *RoleRepository) PermissionExists(permission string) (bool, error) {
+	var count int64
+	err := repository.database.QueryRow(QueryPermissionExists, permission).Scan(&count)
+	return count > 0, err
+}
+
+// Creates the role with its initial permissions in a single transaction.
+func (repository *RoleRepository) CreateRole(
+	name string,
+	permissions []string,
+) (int64, error) {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(QueryCreateRole, name)
+	if err != nil {
+		return 0, err
+	}
+
+	roleId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, permission := range permissions {
+		if _, err := tx.Exec(QueryGrantPermission, roleId, permission); err != nil {
+			return 0, err
+		}
+	}
+
+	return roleId, tx.Commit()
+}
+
+func (repository *RoleRepository) RenameRole(roleId int64, name string) error {
+	_, err := repository.database.Exec(QueryRenameRole, name, roleId)
+	return err
+}
+
+func (repository *RoleRepository) GrantPermissions(
+	roleId int64,
+	permissions []string,
+) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, permission := range permissions {
+		if _, err := tx.Exec(QueryGrantPermission, roleId, permission); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Returns false if the role did not have the permission.
+func (repository *RoleRepository) RevokePermission(
+	roleId int64,
+	permission string,
+) (bool, error) {
+	result, err := repository.database.Exec(QueryRevokePermission, roleId, permission)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Counts users of the role, including deleted ones that still reference it.
+func (repository *RoleRepository) CountRoleUsers(roleId int64) (int64, error) {
+	var count int64
+	err := repository.database.QueryRow(QueryCountRoleUsers, roleId).Scan(&count)
+	return count, err
+}
+
+func (repository *RoleRepository) DeleteRole(roleId int64) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		QueryDeleteRolePermissions,
+		QueryDeleteRoleTwoFactorRequirement,
+		QueryDeleteRole,
+	} {
+		if _, err := tx.Exec(query, roleId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
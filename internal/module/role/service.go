@@ -0,0 +1,276 @@
+package role
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/module/role/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"slices"
+)
+
+// Roles created by the seeder. They can't be renamed or deleted since the
+// rest of the application refers to them by id.
+const (
+	authorRoleId = 1
+	editorRoleId = 2
+	adminRoleId  = 3
+)
+
+// Permissions the admin role always keeps, so role and user management
+// can't be locked out through this API.
+var protectedAdminPermissions = []string{
+	"role:manage",
+	"user:assign_role",
+	"user:list",
+	"user:view",
+}
+
+type RoleService struct {
+	repository  RoleRepository
+	permissions permissions.Store
+	outbox      *outbox.Outbox
+}
+
+func NewRoleService(
+	repository RoleRepository,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) RoleService {
+	return RoleService{
+		repository,
+		permissions,
+		outbox,
+	}
+}
+
+func isBuiltInRole(roleId int64) bool {
+	return roleId >= authorRoleId && roleId <= adminRoleId
+}
+
+func (service *RoleService) ListRoles(userRoleId int64) ([]models.ResponseRole, error) {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	roles, err := service.repository.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range roles {
+		roles[i].BuiltIn = isBuiltInRole(roles[i].Id)
+	}
+	return roles, nil
+}
+
+func (service *RoleService) ListPermissions(userRoleId int64) ([]string, error) {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return service.repository.ListPermissions()
+}
+
+func (service *RoleService) CreateRole(
+	model *models.RequestRoleCreate,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) (int64, error) {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return 0, apierrors.ErrForbidden
+	}
+
+	if err := service.validatePermissions(model.Permissions); err != nil {
+		return 0, err
+	}
+
+	var roleId int64
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		roleId, err = repository.CreateRole(model.Name, model.Permissions)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RoleCreated{
+			ActorID:     userId,
+			Context:     auditContext,
+			RoleID:      roleId,
+			Name:        model.Name,
+			Permissions: model.Permissions,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return roleId, nil
+}
+
+func (service *RoleService) RenameRole(
+	roleId int64,
+	model *models.RequestRoleUpdate,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		previousName, err := repository.GetRoleName(roleId)
+		if err != nil {
+			return err
+		}
+
+		if isBuiltInRole(roleId) {
+			return apierrors.ErrConflict
+		}
+
+		if err := repository.RenameRole(roleId, model.Name); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RoleUpdated{
+			ActorID: userId,
+			Context: auditContext,
+			RoleID:  roleId,
+			Before:  map[string]interface{}{"name": previousName},
+			After:   map[string]interface{}{"name": model.Name},
+		})
+	})
+}
+
+func (service *RoleService) GrantPermissions(
+	roleId int64,
+	model *models.RequestPermissionsGrant,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	if _, err := service.repository.GetRoleName(roleId); err != nil {
+		return err
+	}
+
+	if err := service.validatePermissions(model.Permissions); err != nil {
+		return err
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.GrantPermissions(roleId, model.Permissions); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RolePermissionsGranted{
+			ActorID:     userId,
+			Context:     auditContext,
+			RoleID:      roleId,
+			Permissions: model.Permissions,
+		})
+	})
+}
+
+func (service *RoleService) RevokePermission(
+	roleId int64,
+	permission string,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	if _, err := service.repository.GetRoleName(roleId); err != nil {
+		return err
+	}
+
+	if roleId == adminRoleId && slices.Contains(protectedAdminPermissions, permission) {
+		return apierrors.ErrConflict
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		revoked, err := repository.RevokePermission(roleId, permission)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return apierrors.ErrNotFound
+		}
+
+		return tx.Publish(events.RolePermissionRevoked{
+			ActorID:    userId,
+			Context:    auditContext,
+			RoleID:     roleId,
+			Permission: permission,
+		})
+	})
+}
+
+// Deletes a custom role. Roles still referenced by a user, deleted users
+// included, can't be removed.
+func (service *RoleService) DeleteRole(
+	roleId int64,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "role:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		name, err := repository.GetRoleName(roleId)
+		if err != nil {
+			return err
+		}
+
+		if isBuiltInRole(roleId) {
+			return apierrors.ErrConflict
+		}
+
+		count, err := repository.CountRoleUsers(roleId)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return apierrors.ErrConflict
+		}
+
+		if err := repository.DeleteRole(roleId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RoleDeleted{
+			ActorID: userId,
+			Context: auditContext,
+			RoleID:  roleId,
+			Before:  map[string]interface{}{"name": name},
+		})
+	})
+}
+
+func (service *RoleService) validatePermissions(names []string) error {
+	for _, name := range names {
+		exists, err := service.repository.PermissionExists(name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apierrors.ErrBadRequest
+		}
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package session
 
 import (
 	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/tokens"
 	"bloggo/internal/module/session/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/handlers"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 )
 
 type SessionHandler struct {
@@ -33,15 +38,85 @@ func (handler *SessionHandler) CreateSession(
 		return
 	}
 
-	session, refreshToken, err := handler.service.CreateSession(body)
+	session, refreshToken, challenge, err := handler.service.CreateSession(
+		body,
+		getClientInfo(request),
+		auditcontext.FromRequest(request),
+	)
 	if err != nil {
+		var locked *loginguard.LockedError
+		if errors.As(err, &locked) {
+			writer.Header().Set(
+				"Retry-After",
+				strconv.Itoa(locked.RetryAfterSeconds()),
+			)
+		}
 		apierrors.MapErrors(err, writer, nil)
 		return
 	}
 
+	// Second factor is needed, no tokens yet
+	if challenge != nil {
+		writer.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(writer).Encode(challenge)
+		return
+	}
+
 	handler.sendSession(writer, session, refreshToken)
 }
 
+func (handler *SessionHandler) CompleteTwoFactor(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	body, ok := handlers.BindAndValidate[*models.RequestSessionTwoFactor](writer, request)
+	if !ok {
+		return
+	}
+
+	session, refreshToken, err := handler.service.CompleteTwoFactor(
+		body,
+		getClientInfo(request),
+		auditcontext.FromRequest(request),
+	)
+	if err != nil {
+		var locked *loginguard.LockedError
+		if errors.As(err, &locked) {
+			writer.Header().Set(
+				"Retry-After",
+				strconv.Itoa(locked.RetryAfterSeconds()),
+			)
+		}
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	handler.sendSession(writer, session, refreshToken)
+}
+
+func (handler *SessionHandler) BeginChallengeEnrollment(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	body, ok := handlers.BindAndValidate[*models.RequestSessionEnroll](writer, request)
+	if !ok {
+		return
+	}
+
+	enrollment, err := handler.service.BeginChallengeEnrollment(body.ChallengeToken)
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "Two-factor authentication is already enabled.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(enrollment)
+}
+
 func (handler *SessionHandler) RefreshSession(
 	writer http.ResponseWriter,
 	request *http.Request,
@@ -56,6 +131,7 @@ func (handler *SessionHandler) RefreshSession(
 	// Refresh all tokens
 	session, newRefreshToken, err := handler.service.RefreshSession(
 		refreshCookie.Value,
+		getClientInfo(request),
 	)
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
@@ -77,7 +153,7 @@ func (handler *SessionHandler) DeleteSession(
 	}
 
 	// Revoke refresh token from store
-	handler.service.RevokeSession(refreshCookie.Value)
+	handler.service.RevokeSession(refreshCookie.Value, auditcontext.FromRequest(request))
 
 	// Remove refresh token from client
 	cookie := http.Cookie{
@@ -92,6 +168,104 @@ func (handler *SessionHandler) DeleteSession(
 	writer.WriteHeader(http.StatusOK)
 }
 
+func (handler *SessionHandler) ListSessions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	// Current session is optional, it is only used to mark it in the list
+	currentToken := ""
+	if refreshCookie, err := request.Cookie("refreshToken"); err == nil {
+		currentToken = refreshCookie.Value
+	}
+
+	sessions := handler.service.ListSessions(userId, currentToken)
+	json.NewEncoder(writer).Encode(sessions)
+}
+
+func (handler *SessionHandler) RevokeSessionById(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := handlers.GetParam[string](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := handler.service.RevokeSessionById(userId, sessionId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *SessionHandler) RevokeOtherSessions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	// The current session is identified by its refresh token
+	refreshCookie, err := request.Cookie("refreshToken")
+	if err != nil {
+		apierrors.MapErrors(apierrors.ErrUnauthorized, writer, nil)
+		return
+	}
+
+	revoked, err := handler.service.RevokeOtherSessions(userId, refreshCookie.Value, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(models.ResponseSessionsRevoked{
+		Revoked: revoked,
+	})
+}
+
+func (handler *SessionHandler) RevokeUserSessions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	targetUserId, ok := handlers.GetParam[int64](writer, request, "userId")
+	if !ok {
+		return
+	}
+
+	revoked, err := handler.service.RevokeUserSessions(targetUserId, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(models.ResponseSessionsRevoked{
+		Revoked: revoked,
+	})
+}
+
 func (handler *SessionHandler) sendSession(
 	writer http.ResponseWriter,
 	session *models.ResponseSession,
@@ -110,10 +284,19 @@ func (handler *SessionHandler) sendSession(
 
 	// Write access token to response body
 	response := models.ResponseSession{
-		AccessToken: session.AccessToken,
-		Name:        session.Name,
-		Role:        session.Role,
-		Permissions: session.Permissions,
+		AccessToken:   session.AccessToken,
+		Name:          session.Name,
+		Role:          session.Role,
+		Permissions:   session.Permissions,
+		RecoveryCodes: session.RecoveryCodes,
 	}
 	json.NewEncoder(writer).Encode(response)
 }
+
+// Collects the client details to record alongside the refresh token
+func getClientInfo(request *http.Request) tokens.ClientInfo {
+	return tokens.ClientInfo{
+		IP:        handlers.GetClientIP(request),
+		UserAgent: request.UserAgent(),
+	}
+}
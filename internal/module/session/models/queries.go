@@ -5,6 +5,7 @@ type SessionCreateDetails struct {
 	UserId         int64
 	RoleId         int64
 	UserName       string
+	Email          string
 	RoleName       string
 	PassphraseHash string
 }
@@ -5,3 +5,14 @@ type RequestSessionCreate struct {
 	Email      string `json:"email" validate:"required,email"`
 	Passphrase string `json:"passphrase" validate:"required"`
 }
+
+// -- Second Step Of Login -- //
+type RequestSessionTwoFactor struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required,min=6,max=11"`
+}
+
+// -- Enroll While Logging In -- //
+type RequestSessionEnroll struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+}
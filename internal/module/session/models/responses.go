@@ -2,8 +2,34 @@ package models
 
 // -- Login or Refresh Response -- //
 type ResponseSession struct {
-	AccessToken string   `json:"accessToken"`
-	Name        string   `json:"name"`
-	Role        string   `json:"role"`
-	Permissions []string `json:"permissions"`
+	AccessToken   string   `json:"accessToken"`
+	Name          string   `json:"name"`
+	Role          string   `json:"role"`
+	Permissions   []string `json:"permissions"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// -- Passphrase Accepted, Second Factor Needed -- //
+type ResponseTwoFactorChallenge struct {
+	ChallengeToken     string `json:"challengeToken"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+}
+
+// -- Active Session Listing -- //
+type ResponseActiveSession struct {
+	Id         string `json:"id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	DeviceType string `json:"deviceType"`
+	OS         string `json:"os"`
+	Browser    string `json:"browser"`
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	Current    bool   `json:"current"`
+}
+
+// -- Revoke Multiple Sessions -- //
+type ResponseSessionsRevoked struct {
+	Revoked int `json:"revoked"`
 }
@@ -4,7 +4,15 @@ import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/challenges"
+	"bloggo/internal/infrastructure/keyring"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/infrastructure/tokens"
+	"bloggo/internal/infrastructure/tokenversions"
+	"bloggo/internal/middleware"
+	"bloggo/internal/module/twofactor"
 	"bloggo/internal/module/user"
 	"bloggo/internal/utils/file/transformfile"
 	"bloggo/internal/utils/file/validatefile"
@@ -23,6 +31,9 @@ func NewModule() SessionModule {
 	database := db.Get()
 	config := config.Get()
 	refreshStore := tokens.GetStore()
+	permissionStore := permissions.Get()
+	tokenVersions := tokenversions.Get()
+	accountGuard := loginguard.GetAccountStore()
 
 	// Create user service for updating last login
 	bucket, err := bucket.NewFileSystemBucket("users/avatars")
@@ -32,10 +43,36 @@ func NewModule() SessionModule {
 	imageValidator := validatefile.NewImageValidator(5 << 20) // 5MB
 	avatarResizer := transformfile.NewImageTransformer(512, 512)
 	userRepository := user.NewUserRepository(database)
-	userService := user.NewUserService(userRepository, bucket, imageValidator, avatarResizer)
+	userService := user.NewUserService(
+		userRepository,
+		bucket,
+		imageValidator,
+		avatarResizer,
+		refreshStore,
+		tokenVersions,
+		accountGuard,
+		permissionStore,
+		outbox.Get(),
+	)
+
+	twoFactorRepository := twofactor.NewTwoFactorRepository(database)
+	twoFactorService := twofactor.NewTwoFactorService(twoFactorRepository, permissionStore, outbox.Get())
 
 	repository := NewSessionRepository(database)
-	service := NewSessionService(repository, &config, refreshStore, &userService)
+	service := NewSessionService(
+		repository,
+		&config,
+		refreshStore,
+		&userService,
+		permissionStore,
+		tokenVersions,
+		&twoFactorService,
+		challenges.GetStore(),
+		accountGuard,
+		loginguard.GetIPStore(),
+		keyring.Get(),
+		outbox.Get(),
+	)
 	handler := NewSessionHandler(service, &config)
 
 	return SessionModule{
@@ -46,9 +83,25 @@ func NewModule() SessionModule {
 }
 
 func (module SessionModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
 	router.Route("/session", func(router chi.Router) {
-		router.Post("/", module.Handler.CreateSession)
+		// Login steps share a strict per-IP policy
+		router.Group(func(router chi.Router) {
+			router.Use(middleware.RateLimit(middleware.PolicySession))
+			router.Post("/", module.Handler.CreateSession)
+			router.Post("/two-factor", module.Handler.CompleteTwoFactor)
+			router.Post("/two-factor/enroll", module.Handler.BeginChallengeEnrollment)
+		})
 		router.Post("/refresh", module.Handler.RefreshSession)
 		router.Delete("/", module.Handler.DeleteSession)
+
+		// Active session management
+		router.With(middleware.AuthMiddleware(&config)).Group(func(router chi.Router) {
+			router.Get("/list", module.Handler.ListSessions)
+			router.Delete("/others", module.Handler.RevokeOtherSessions)
+			router.Delete("/user/{userId}", module.Handler.RevokeUserSessions)
+			router.Delete("/{id}", module.Handler.RevokeSessionById)
+		})
 	})
 }
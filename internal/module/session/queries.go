@@ -2,12 +2,12 @@ package session
 
 const (
 	QuerySessionCreateDataByEmail = `
-	SELECT u.id, u.name, u.role_id, r.name AS role_name, u.passphrase_hash
+	SELECT u.id, u.name, u.email, u.role_id, r.name AS role_name, COALESCE(u.passphrase_hash, '')
 	FROM users u
 	JOIN roles r ON r.id = u.role_id
 	WHERE u.email = ? AND u.deleted_at IS NULL;`
 	QuerySessionCreateDataById = `
-	SELECT u.id, u.name, u.role_id, r.name AS role_name, u.passphrase_hash
+	SELECT u.id, u.name, u.email, u.role_id, r.name AS role_name, COALESCE(u.passphrase_hash, '')
 	FROM users u
 	JOIN roles r ON r.id = u.role_id
 	WHERE u.id = ? AND u.deleted_at IS NULL;`
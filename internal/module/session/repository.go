@@ -24,6 +24,7 @@ func (repository *SessionRepository) GetUserLoginDataByEmail(
 	err := row.Scan(
 		&result.UserId,
 		&result.UserName,
+		&result.Email,
 		&result.RoleId,
 		&result.RoleName,
 		&result.PassphraseHash,
@@ -44,6 +45,7 @@ func (repository *SessionRepository) GetUserLoginDataById(
 	err := row.Scan(
 		&result.UserId,
 		&result.UserName,
+		&result.Email,
 		&result.RoleId,
 		&result.RoleName,
 		&result.PassphraseHash,
@@ -2,23 +2,53 @@ package session
 
 import (
 	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/challenges"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/keyring"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/infrastructure/tokens"
-	"bloggo/internal/module/audit"
-	auditmodels "bloggo/internal/module/audit/models"
+	"bloggo/internal/infrastructure/tokenversions"
 	"bloggo/internal/module/session/models"
+	twofactormodels "bloggo/internal/module/twofactor/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/cryptography"
+	"bloggo/internal/utils/useragent"
+	"errors"
+	"log"
+	"strings"
+	"time"
 )
 
+// Seconds a user has to enter the second factor after the passphrase
+const TwoFactorChallengeDuration = 5 * 60
+
 type UserService interface {
 	UpdateLastLogin(userId int64) error
 }
 
+type TwoFactorService interface {
+	LoginRequirement(userId int64, roleId int64) (enabled bool, required bool, err error)
+	BeginEnrollment(userId int64) (*twofactormodels.ResponseEnrollment, error)
+	ConfirmEnrollment(userId int64, code string, auditContext auditcontext.Context) (*twofactormodels.ResponseRecoveryCodes, error)
+	Verify(userId int64, code string, auditContext auditcontext.Context) error
+}
+
 type SessionService struct {
-	repository   SessionRepository
-	config       *config.Config
-	refreshStore tokens.Store
-	userService  UserService
+	repository    SessionRepository
+	config        *config.Config
+	refreshStore  tokens.Store
+	userService   UserService
+	permissions   permissions.Store
+	tokenVersions tokenversions.Store
+	twoFactor     TwoFactorService
+	challenges    challenges.Store
+	accountGuard  loginguard.Store
+	ipGuard       loginguard.Store
+	keyring       keyring.Store
+	outbox        *outbox.Outbox
 }
 
 func NewSessionService(
@@ -26,40 +56,240 @@ func NewSessionService(
 	config *config.Config,
 	refreshStore tokens.Store,
 	userService UserService,
+	permissions permissions.Store,
+	tokenVersions tokenversions.Store,
+	twoFactor TwoFactorService,
+	challenges challenges.Store,
+	accountGuard loginguard.Store,
+	ipGuard loginguard.Store,
+	keyring keyring.Store,
+	outbox *outbox.Outbox,
 ) SessionService {
 	return SessionService{
 		repository,
 		config,
 		refreshStore,
 		userService,
+		permissions,
+		tokenVersions,
+		twoFactor,
+		challenges,
+		accountGuard,
+		ipGuard,
+		keyring,
+		outbox,
 	}
 }
 
+// Verifies the passphrase. If a second factor is enabled or required for
+// the role, a challenge is returned instead of the session.
 func (service *SessionService) CreateSession(
 	model *models.RequestSessionCreate,
-) (session *models.ResponseSession, refreshToken string, err error) {
+	client tokens.ClientInfo,
+	auditContext auditcontext.Context,
+) (
+	session *models.ResponseSession,
+	refreshToken string,
+	challenge *models.ResponseTwoFactorChallenge,
+	err error,
+) {
+	// Refuse early while the account or the IP has to wait
+	accountKey := strings.ToLower(strings.TrimSpace(model.Email))
+	wait := max(
+		service.accountGuard.Check(accountKey),
+		service.ipGuard.Check(client.IP),
+	)
+	if wait > 0 {
+		return nil, "", nil, &loginguard.LockedError{RetryAfter: wait}
+	}
+
 	// Compare passphrase hashes
 	details, err := service.repository.GetUserLoginDataByEmail(model.Email)
 	if err != nil {
 		// Not sending "resource not found" error
 		// Do not allow hackers to brute force to
 		// find registered emails
-		return nil, "", apierrors.ErrUnauthorized
+		service.recordFailedLogin(accountKey, nil, client, auditContext)
+		return nil, "", nil, apierrors.ErrUnauthorized
 	}
 
 	if !cryptography.ComparePassphrase(
 		details.PassphraseHash,
 		model.Passphrase,
 	) {
+		service.recordFailedLogin(accountKey, &details.UserId, client, auditContext)
+		return nil, "", nil, apierrors.ErrUnauthorized
+	}
+
+	// Access token is only issued after the second step, the account
+	// guard is cleared once it is, so failed codes keep counting
+	enabled, required, err := service.twoFactor.LoginRequirement(
+		details.UserId,
+		details.RoleId,
+	)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if enabled || required {
+		challengeToken := cryptography.GenerateUniqueId()
+		service.challenges.Set(
+			challengeToken,
+			details.UserId,
+			TwoFactorChallengeDuration,
+		)
+		return nil, "", &models.ResponseTwoFactorChallenge{
+			ChallengeToken:     challengeToken,
+			EnrollmentRequired: !enabled,
+		}, nil
+	}
+
+	session, refreshToken, err = service.issueSession(details, model.Email, client, auditContext)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Successful login clears the account, but not the shared IP
+	service.accountGuard.Reset(accountKey)
+	return session, refreshToken, nil, nil
+}
+
+// Starts the enrollment of a user whose role requires two-factor but who
+// has not enrolled yet, using the pending login challenge.
+func (service *SessionService) BeginChallengeEnrollment(
+	challengeToken string,
+) (*twofactormodels.ResponseEnrollment, error) {
+	userId, found := service.challenges.Get(challengeToken)
+	if !found {
+		return nil, apierrors.ErrUnauthorized
+	}
+
+	return service.twoFactor.BeginEnrollment(userId)
+}
+
+// Completes the login by verifying the second factor of the challenge.
+func (service *SessionService) CompleteTwoFactor(
+	model *models.RequestSessionTwoFactor,
+	client tokens.ClientInfo,
+	auditContext auditcontext.Context,
+) (session *models.ResponseSession, refreshToken string, err error) {
+	userId, found := service.challenges.Get(model.ChallengeToken)
+	if !found {
+		return nil, "", apierrors.ErrUnauthorized
+	}
+
+	details, err := service.repository.GetUserLoginDataById(userId)
+	if err != nil {
 		return nil, "", apierrors.ErrUnauthorized
 	}
 
+	// Wrong codes count against the account and the IP like wrong
+	// passphrases, a new challenge doesn't start over
+	accountKey := strings.ToLower(strings.TrimSpace(details.Email))
+	wait := max(
+		service.accountGuard.Check(accountKey),
+		service.ipGuard.Check(client.IP),
+	)
+	if wait > 0 {
+		return nil, "", &loginguard.LockedError{RetryAfter: wait}
+	}
+
+	enabled, _, err := service.twoFactor.LoginRequirement(userId, details.RoleId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Users forced to enroll confirm their new secret with the code
+	var recoveryCodes []string
+	if enabled {
+		err = service.twoFactor.Verify(userId, model.Code, auditContext)
+	} else {
+		var confirmed *twofactormodels.ResponseRecoveryCodes
+		confirmed, err = service.twoFactor.ConfirmEnrollment(userId, model.Code, auditContext)
+		if err == nil {
+			recoveryCodes = confirmed.RecoveryCodes
+		}
+	}
+	if err != nil {
+		if errors.Is(err, apierrors.ErrInvalidTwoFactorCode) {
+			service.challenges.Fail(model.ChallengeToken)
+			service.recordFailedLogin(accountKey, &userId, client, auditContext)
+		}
+		return nil, "", err
+	}
+	service.challenges.Delete(model.ChallengeToken)
+
+	session, refreshToken, err = service.issueSession(details, "", client, auditContext)
+	if err != nil {
+		return nil, "", err
+	}
+	session.RecoveryCodes = recoveryCodes
+	service.accountGuard.Reset(accountKey)
+
+	return session, refreshToken, nil
+}
+
+// Counts the failure for both the account and the IP, audits it and
+// audits the lockout when this failure triggered one.
+func (service *SessionService) recordFailedLogin(
+	accountKey string,
+	userId *int64,
+	client tokens.ClientInfo,
+	auditContext auditcontext.Context,
+) {
+	_, accountLocked := service.accountGuard.Fail(accountKey)
+	_, ipLocked := service.ipGuard.Fail(client.IP)
+
+	// The guards live in memory, so the failure is recorded on its own
+	err := service.outbox.Publish(events.UserLoginFailed{
+		UserID:  userId,
+		Context: auditContext,
+		Email:   accountKey,
+	})
+	if err != nil {
+		log.Printf("Failed to record failed login of %s: %v", accountKey, err)
+	}
+
+	if accountLocked || ipLocked {
+		duration := int(loginguard.AccountPolicy.LockoutDuration.Seconds())
+		if ipLocked {
+			duration = int(loginguard.IPPolicy.LockoutDuration.Seconds())
+		}
+		err := service.outbox.Publish(events.UserLockedOut{
+			UserID:   userId,
+			Context:  auditContext,
+			Email:    accountKey,
+			Account:  accountLocked,
+			Duration: duration,
+		})
+		if err != nil {
+			log.Printf("Failed to record lockout of %s: %v", accountKey, err)
+		}
+	}
+}
+
+// Issues the access and refresh tokens of an authenticated user
+func (service *SessionService) issueSession(
+	details *models.SessionCreateDetails,
+	subject string,
+	client tokens.ClientInfo,
+	auditContext auditcontext.Context,
+) (session *models.ResponseSession, refreshToken string, err error) {
 	// Generate tokens
+	tokenVersion, err := service.tokenVersions.Current(details.UserId)
+	if err != nil {
+		return nil, "", err
+	}
+	keyId, key, err := service.keyring.SigningKey()
+	if err != nil {
+		return nil, "", err
+	}
 	accessToken, err := cryptography.GenerateJWT(
-		model.Email,
+		subject,
 		details.UserId,
 		details.RoleId,
-		service.config.JWTSecret,
+		tokenVersion,
+		keyId,
+		key,
 		service.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -80,6 +310,7 @@ func (service *SessionService) CreateSession(
 		refreshToken,
 		details.UserId,
 		service.config.RefreshTokenDuration,
+		client,
 	)
 
 	// Update last login timestamp
@@ -96,14 +327,24 @@ func (service *SessionService) CreateSession(
 		Permissions: permissions,
 	}
 
-	// Log login action
-	audit.LogAuthAction(&details.UserId, auditmodels.ActionLogin)
+	// The session lives in the refresh store, so the login is recorded on
+	// its own
+	err = service.outbox.Publish(events.UserLoggedIn{
+		UserID:  details.UserId,
+		Name:    details.UserName,
+		Role:    details.RoleName,
+		Context: auditContext,
+	})
+	if err != nil {
+		log.Printf("Failed to record login of user %d: %v", details.UserId, err)
+	}
 
 	return sessionData, refreshToken, nil
 }
 
 func (service *SessionService) RefreshSession(
 	refreshToken string,
+	client tokens.ClientInfo,
 ) (session *models.ResponseSession, rotatedRefreshToken string, err error) {
 	userId, found := service.refreshStore.Get(refreshToken)
 	if !found {
@@ -116,11 +357,21 @@ func (service *SessionService) RefreshSession(
 	}
 
 	// Generate new access token
+	tokenVersion, err := service.tokenVersions.Current(details.UserId)
+	if err != nil {
+		return nil, "", err
+	}
+	keyId, key, err := service.keyring.SigningKey()
+	if err != nil {
+		return nil, "", err
+	}
 	accessToken, err := cryptography.GenerateJWT(
 		"", // Email is not available here, can be added if needed
 		details.UserId,
 		details.RoleId,
-		service.config.JWTSecret,
+		tokenVersion,
+		keyId,
+		key,
 		service.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -133,17 +384,16 @@ func (service *SessionService) RefreshSession(
 		return nil, "", err
 	}
 
-	// Rotate refresh token
+	// Rotate refresh token, keeping the same session record
 	newRefreshToken := cryptography.GenerateUniqueId()
-
-	// Set new refresh token to Refresh Token Store
-	service.refreshStore.Set(
+	if _, found := service.refreshStore.Rotate(
+		refreshToken,
 		newRefreshToken,
-		details.UserId,
 		service.config.RefreshTokenDuration,
-	)
-	// Revoke old refresh token
-	service.refreshStore.Delete(refreshToken)
+		client,
+	); !found {
+		return nil, "", apierrors.ErrUnauthorized
+	}
 
 	sessionData := &models.ResponseSession{
 		AccessToken: accessToken,
@@ -157,6 +407,7 @@ func (service *SessionService) RefreshSession(
 
 func (service *SessionService) RevokeSession(
 	refreshToken string,
+	auditContext auditcontext.Context,
 ) {
 	// Get user ID before deleting token for audit logging
 	userId, found := service.refreshStore.Get(refreshToken)
@@ -164,8 +415,110 @@ func (service *SessionService) RevokeSession(
 	// Revoke refresh token
 	service.refreshStore.Delete(refreshToken)
 
-	// Log logout action if we found the user
+	// Record the logout if we found the user
 	if found {
-		audit.LogAuthAction(&userId, auditmodels.ActionLogout)
+		err := service.outbox.Publish(events.UserLoggedOut{
+			UserID:  userId,
+			Context: auditContext,
+		})
+		if err != nil {
+			log.Printf("Failed to record logout of user %d: %v", userId, err)
+		}
+	}
+}
+
+// Lists active sessions of the user, marking the one owning currentToken
+func (service *SessionService) ListSessions(
+	userId int64,
+	currentToken string,
+) []models.ResponseActiveSession {
+	current, hasCurrent := service.refreshStore.GetSession(currentToken)
+
+	sessions := service.refreshStore.ListByUser(userId)
+	response := make([]models.ResponseActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		device := useragent.ParseUserAgent(session.UserAgent)
+		response = append(response, models.ResponseActiveSession{
+			Id:         session.Id,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			DeviceType: device.DeviceType,
+			OS:         device.OS,
+			Browser:    device.Browser,
+			CreatedAt:  session.CreatedAt.UTC().Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.UTC().Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.UTC().Format(time.RFC3339),
+			Current:    hasCurrent && current.Id == session.Id,
+		})
+	}
+
+	return response
+}
+
+// Revokes a single session owned by the user
+func (service *SessionService) RevokeSessionById(
+	userId int64,
+	sessionId string,
+	auditContext auditcontext.Context,
+) error {
+	if !service.refreshStore.DeleteById(userId, sessionId) {
+		return apierrors.ErrNotFound
+	}
+
+	return service.outbox.Publish(events.UserSessionsRevoked{
+		UserID:  userId,
+		Context: auditContext,
+	})
+}
+
+// Revokes every session of the user except the current one
+func (service *SessionService) RevokeOtherSessions(
+	userId int64,
+	currentToken string,
+	auditContext auditcontext.Context,
+) (int, error) {
+	current, found := service.refreshStore.GetSession(currentToken)
+	if !found || current.UserId != userId {
+		return 0, apierrors.ErrUnauthorized
+	}
+
+	revoked := service.refreshStore.DeleteByUser(userId, currentToken)
+
+	err := service.outbox.Publish(events.UserSessionsRevoked{
+		UserID:  userId,
+		Context: auditContext,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+// Revokes all sessions of another user, for compromised accounts
+func (service *SessionService) RevokeUserSessions(
+	targetUserId int64,
+	userRoleId int64,
+	revokedBy int64,
+	auditContext auditcontext.Context,
+) (int, error) {
+	if !service.permissions.HasPermission(userRoleId, "session:manage") {
+		return 0, apierrors.ErrForbidden
+	}
+
+	revoked := service.refreshStore.DeleteByUser(targetUserId, "")
+
+	// Also reject the access tokens that are still in use
+	if _, err := service.tokenVersions.Bump(targetUserId); err != nil {
+		return 0, err
+	}
+
+	err := service.outbox.Publish(events.UserSessionsRevokedByAdmin{
+		ActorID: revokedBy,
+		Context: auditContext,
+		UserID:  targetUserId,
+	})
+	if err != nil {
+		return 0, err
 	}
+	return revoked, nil
 }
@@ -0,0 +1,124 @@
+package signingkey
+
+import (
+	"bloggo/internal/module/signingkey/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type SigningKeyHandler struct {
+	service SigningKeyService
+}
+
+func NewSigningKeyHandler(service SigningKeyService) SigningKeyHandler {
+	return SigningKeyHandler{
+		service,
+	}
+}
+
+func (handler *SigningKeyHandler) ListKeys(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keys, err := handler.service.ListKeys(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(keys)
+}
+
+func (handler *SigningKeyHandler) GenerateKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	handler.createKey(writer, request, handler.service.GenerateKey)
+}
+
+func (handler *SigningKeyHandler) RotateKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	handler.createKey(writer, request, handler.service.RotateKey)
+}
+
+func (handler *SigningKeyHandler) PromoteKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	handler.changeKey(writer, request, handler.service.PromoteKey)
+}
+
+func (handler *SigningKeyHandler) RetireKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	handler.changeKey(writer, request, handler.service.RetireKey)
+}
+
+func (handler *SigningKeyHandler) createKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+	create func(userRoleId int64, userId int64, auditContext auditcontext.Context) (string, error),
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keyId, err := create(userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(models.ResponseSigningKeyCreated{Id: keyId})
+}
+
+func (handler *SigningKeyHandler) changeKey(
+	writer http.ResponseWriter,
+	request *http.Request,
+	change func(keyId string, userRoleId int64, userId int64, auditContext auditcontext.Context) error,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	keyId, ok := handlers.GetParam[string](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := change(keyId, userRoleId, userId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "The current signing key cannot be retired, promote another key first.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
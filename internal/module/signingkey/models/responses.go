@@ -0,0 +1,6 @@
+package models
+
+// -- Created Signing Key -- //
+type ResponseSigningKeyCreated struct {
+	Id string `json:"id"`
+}
@@ -0,0 +1,41 @@
+package signingkey
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/keyring"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type SigningKeyModule struct {
+	Handler SigningKeyHandler
+	Service SigningKeyService
+}
+
+func NewModule() SigningKeyModule {
+	service := NewSigningKeyService(keyring.Get(), permissions.Get(), outbox.Get())
+	handler := NewSigningKeyHandler(service)
+
+	return SigningKeyModule{
+		Handler: handler,
+		Service: service,
+	}
+}
+
+func (module SigningKeyModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.With(middleware.AuthMiddleware(&config)).Route(
+		"/signing-keys",
+		func(router chi.Router) {
+			router.Get("/", module.Handler.ListKeys)
+			router.Post("/", module.Handler.GenerateKey)
+			router.Post("/rotate", module.Handler.RotateKey)
+			router.Post("/{id}/promote", module.Handler.PromoteKey)
+			router.Post("/{id}/retire", module.Handler.RetireKey)
+		},
+	)
+}
@@ -0,0 +1,134 @@
+package signingkey
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/keyring"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"errors"
+)
+
+// The keyring stores keys on its own, so changes to it are published once
+// they are stored rather than in their transaction
+type SigningKeyService struct {
+	keyring     keyring.Store
+	permissions permissions.Store
+	outbox      *outbox.Outbox
+}
+
+func NewSigningKeyService(
+	keyring keyring.Store,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) SigningKeyService {
+	return SigningKeyService{
+		keyring,
+		permissions,
+		outbox,
+	}
+}
+
+func (service *SigningKeyService) ListKeys(userRoleId int64) ([]keyring.Key, error) {
+	if !service.permissions.HasPermission(userRoleId, "signingkey:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return service.keyring.List()
+}
+
+// Adds a key that only verifies tokens until it is promoted.
+func (service *SigningKeyService) GenerateKey(
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) (string, error) {
+	if !service.permissions.HasPermission(userRoleId, "signingkey:manage") {
+		return "", apierrors.ErrForbidden
+	}
+
+	keyId, err := service.keyring.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	err = service.outbox.Publish(events.SigningKeyGenerated{
+		ActorID: userId,
+		Context: auditContext,
+		KeyID:   keyId,
+	})
+	return keyId, err
+}
+
+// Generates a key and signs new tokens with it right away. Tokens signed
+// with the previous key stay valid until that key is retired.
+func (service *SigningKeyService) RotateKey(
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) (string, error) {
+	keyId, err := service.GenerateKey(userRoleId, userId, auditContext)
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.PromoteKey(keyId, userRoleId, userId, auditContext); err != nil {
+		return "", err
+	}
+	return keyId, nil
+}
+
+func (service *SigningKeyService) PromoteKey(
+	keyId string,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "signingkey:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	if err := service.keyring.Promote(keyId); err != nil {
+		return mapKeyringError(err)
+	}
+
+	return service.outbox.Publish(events.SigningKeyPromoted{
+		ActorID: userId,
+		Context: auditContext,
+		KeyID:   keyId,
+	})
+}
+
+// Retires a key. Access tokens signed with it stop working, so wait for
+// the access token lifetime after a rotation before retiring the old key.
+func (service *SigningKeyService) RetireKey(
+	keyId string,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "signingkey:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	if err := service.keyring.Retire(keyId); err != nil {
+		return mapKeyringError(err)
+	}
+
+	return service.outbox.Publish(events.SigningKeyRetired{
+		ActorID: userId,
+		Context: auditContext,
+		KeyID:   keyId,
+	})
+}
+
+func mapKeyringError(err error) error {
+	switch {
+	case errors.Is(err, keyring.ErrUnknownKey):
+		return apierrors.ErrNotFound
+	case errors.Is(err, keyring.ErrKeyIsSigning):
+		return apierrors.ErrConflict
+	}
+	return err
+}
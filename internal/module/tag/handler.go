@@ -3,6 +3,7 @@ package tag
 import (
 	"bloggo/internal/module/tag/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
@@ -39,7 +40,7 @@ func (handler *TagHandler) TagCreate(
 		return
 	}
 
-	response, err := handler.service.TagCreate(&body, roleId, userId)
+	response, err := handler.service.TagCreate(&body, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
@@ -134,7 +135,7 @@ func (handler *TagHandler) TagUpdate(
 		return
 	}
 
-	err := handler.service.TagUpdate(slug, &body, roleId, userId)
+	err := handler.service.TagUpdate(slug, &body, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
@@ -167,7 +168,7 @@ func (handler *TagHandler) TagDelete(
 		return
 	}
 
-	err := handler.service.TagDelete(slug, roleId, userId)
+	err := handler.service.TagDelete(slug, roleId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
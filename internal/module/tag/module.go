@@ -3,6 +3,7 @@ package tag
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 
@@ -20,7 +21,7 @@ func NewModule() TagModule {
 	permissionStore := permissions.Get()
 
 	repository := NewTagRepository(database)
-	service := NewTagService(repository, permissionStore)
+	service := NewTagService(repository, permissionStore, outbox.Get())
 	handler := NewTagHandler(service)
 
 	return TagModule{
@@ -33,18 +34,19 @@ func NewModule() TagModule {
 func (module TagModule) RegisterModule(router *chi.Mux) {
 	config := config.Get()
 
-	router.With(middleware.AuthMiddleware(&config)).Route(
+	// Personal access tokens need the permission named by the route
+	router.Route(
 		"/tags",
 		func(router chi.Router) {
 			// Public routes
-			router.Get("/", module.Handler.GetTags)
-			router.Get("/list", module.Handler.GetTagList)
-			router.Get("/{slug}", module.Handler.GetTagBySlug)
+			router.With(middleware.AuthMiddleware(&config, "tag:list")).Get("/", module.Handler.GetTags)
+			router.With(middleware.AuthMiddleware(&config, "tag:list")).Get("/list", module.Handler.GetTagList)
+			router.With(middleware.AuthMiddleware(&config, "tag:view")).Get("/{slug}", module.Handler.GetTagBySlug)
 
 			// Editor-only routes
-			router.Post("/", module.Handler.TagCreate)
-			router.Patch("/{slug}", module.Handler.TagUpdate)
-			router.Delete("/{slug}", module.Handler.TagDelete)
+			router.With(middleware.AuthMiddleware(&config, "tag:create")).Post("/", module.Handler.TagCreate)
+			router.With(middleware.AuthMiddleware(&config, "tag:update")).Patch("/{slug}", module.Handler.TagUpdate)
+			router.With(middleware.AuthMiddleware(&config, "tag:delete")).Delete("/{slug}", module.Handler.TagDelete)
 		},
 	)
 
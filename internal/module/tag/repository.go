@@ -1,6 +1,7 @@
 package tag
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/tag/models"
 	"bloggo/internal/utils/apierrors"
 	"bloggo/internal/utils/filter"
@@ -10,7 +11,7 @@ import (
 )
 
 type TagRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewTagRepository(database *sql.DB) TagRepository {
@@ -19,6 +20,13 @@ func NewTagRepository(database *sql.DB) TagRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *TagRepository) WithTx(tx *sql.Tx) TagRepository {
+	return TagRepository{
+		tx,
+	}
+}
+
 func (repository *TagRepository) TagCreate(
 	model *models.QueryParamsTagCreate,
 ) (int64, error) {
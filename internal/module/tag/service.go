@@ -1,12 +1,12 @@
 package tag
 
 import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
-	"bloggo/internal/module/audit"
-	auditmodels "bloggo/internal/module/audit/models"
 	"bloggo/internal/module/tag/models"
-	"bloggo/internal/module/webhook"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/pagination"
 	"bloggo/internal/utils/schemas/responses"
@@ -15,12 +15,14 @@ import (
 type TagService struct {
 	repository  TagRepository
 	permissions permissions.Store
+	outbox      *outbox.Outbox
 }
 
-func NewTagService(repository TagRepository, permissions permissions.Store) TagService {
+func NewTagService(repository TagRepository, permissions permissions.Store, outbox *outbox.Outbox) TagService {
 	return TagService{
 		repository,
 		permissions,
+		outbox,
 	}
 }
 
@@ -28,6 +30,7 @@ func (service *TagService) TagCreate(
 	model *models.RequestTagCreate,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) (*responses.ResponseCreated, error) {
 	// Check if user has permission to create tags
 	hasPermission := service.permissions.HasPermission(userRoleId, "tag:create")
@@ -36,17 +39,27 @@ func (service *TagService) TagCreate(
 	}
 
 	params := models.ToCreateTagParams(model)
-	id, err := service.repository.TagCreate(params)
+	var id int64
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.TagCreate(params)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TagCreated{
+			ActorID: userId,
+			Context: auditContext,
+			TagID:   id,
+			Name:    params.Name,
+			Slug:    params.Slug,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Log the action
-	audit.LogTagAction(&userId, id, auditmodels.ActionTagCreated)
-
-	// Trigger webhook
-	go func() { webhook.TriggerTagCreated(id, params.Slug, map[string]interface{}{"name": params.Name, "slug": params.Slug}) }()
-
 	return &responses.ResponseCreated{
 		Id: id,
 	}, nil
@@ -93,6 +106,7 @@ func (service *TagService) TagUpdate(
 	model *models.RequestTagUpdate,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to update tags
 	hasPermission := service.permissions.HasPermission(userRoleId, "tag:update")
@@ -100,22 +114,15 @@ func (service *TagService) TagUpdate(
 		return apierrors.ErrForbidden
 	}
 
-	// First get the tag ID to log it
+	// First get the tag as it was for the audit log
 	tag, err := service.repository.GetTagBySlug(slug)
 	if err != nil {
 		return err
 	}
 
 	params := models.ToUpdateTagParams(model)
-	err = service.repository.TagUpdate(slug, params)
-	if err != nil {
-		return err
-	}
-
-	// Log the action
-	audit.LogTagAction(&userId, tag.Id, auditmodels.ActionTagUpdated)
 
-	// Trigger webhook with updated slug
+	// Publish with the updated slug
 	newSlug := slug
 	var oldSlug *string
 	if params.Slug != nil {
@@ -124,17 +131,36 @@ func (service *TagService) TagUpdate(
 			oldSlug = &slug
 		}
 	}
-	go func() {
-		webhook.TriggerTagUpdated(tag.Id, newSlug, oldSlug, map[string]interface{}{"name": model.Name, "slug": newSlug})
-	}()
 
-	return nil
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.TagUpdate(slug, params); err != nil {
+			return err
+		}
+
+		updated, err := repository.GetTagBySlug(newSlug)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TagUpdated{
+			ActorID: userId,
+			Context: auditContext,
+			TagID:   tag.Id,
+			Slug:    newSlug,
+			OldSlug: oldSlug,
+			Name:    model.Name,
+			Before:  tagSnapshot(tag),
+			After:   tagSnapshot(updated),
+		})
+	})
 }
 
 func (service *TagService) TagDelete(
 	slug string,
 	userRoleId int64,
 	userId int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Check if user has permission to delete tags
 	hasPermission := service.permissions.HasPermission(userRoleId, "tag:delete")
@@ -142,22 +168,32 @@ func (service *TagService) TagDelete(
 		return apierrors.ErrForbidden
 	}
 
-	// First get the tag ID to log it
+	// First get the tag as it was for the audit log
 	tag, err := service.repository.GetTagBySlug(slug)
 	if err != nil {
 		return err
 	}
 
-	err = service.repository.TagDelete(slug)
-	if err != nil {
-		return err
-	}
-
-	// Log the action
-	audit.LogTagAction(&userId, tag.Id, auditmodels.ActionTagDeleted)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.TagDelete(slug); err != nil {
+			return err
+		}
 
-	// Trigger webhook
-	go func() { webhook.TriggerTagDeleted(tag.Id, tag.Slug) }()
+		return tx.Publish(events.TagDeleted{
+			ActorID: userId,
+			Context: auditContext,
+			TagID:   tag.Id,
+			Slug:    tag.Slug,
+			Before:  tagSnapshot(tag),
+		})
+	})
+}
 
-	return nil
+// The audited fields of a tag
+func tagSnapshot(tag *models.ResponseTagDetails) map[string]interface{} {
+	return map[string]interface{}{
+		"name": tag.Name,
+		"slug": tag.Slug,
+	}
 }
@@ -0,0 +1,222 @@
+package twofactor
+
+import (
+	"bloggo/internal/module/twofactor/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/handlers"
+	"encoding/json"
+	"net/http"
+)
+
+type TwoFactorHandler struct {
+	service TwoFactorService
+}
+
+func NewTwoFactorHandler(service TwoFactorService) TwoFactorHandler {
+	return TwoFactorHandler{
+		service,
+	}
+}
+
+func (handler *TwoFactorHandler) GetStatus(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	status, err := handler.service.GetStatus(userId, userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(status)
+}
+
+func (handler *TwoFactorHandler) BeginEnrollment(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	enrollment, err := handler.service.BeginEnrollment(userId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrConflict: {
+				Message: "Two-factor authentication is already enabled.",
+				Status:  http.StatusConflict,
+			},
+		})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(enrollment)
+}
+
+func (handler *TwoFactorHandler) ConfirmEnrollment(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestTwoFactorCode](writer, request)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := handler.service.ConfirmEnrollment(userId, body.Code, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrPreconditionRequired: {
+				Message: "Start an enrollment before confirming it.",
+				Status:  http.StatusPreconditionRequired,
+			},
+		})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(recoveryCodes)
+}
+
+func (handler *TwoFactorHandler) Disable(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestTwoFactorCode](writer, request)
+	if !ok {
+		return
+	}
+
+	if err := handler.service.Disable(userId, userRoleId, body.Code, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *TwoFactorHandler) RegenerateRecoveryCodes(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestTwoFactorCode](writer, request)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := handler.service.RegenerateRecoveryCodes(userId, body.Code, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(recoveryCodes)
+}
+
+func (handler *TwoFactorHandler) ResetUser(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	targetUserId, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	if err := handler.service.ResetUser(targetUserId, userRoleId, userId, auditcontext.FromRequest(request)); err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *TwoFactorHandler) GetRoleRequirements(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	requirements, err := handler.service.GetRoleRequirements(userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(requirements)
+}
+
+func (handler *TwoFactorHandler) SetRoleRequirement(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	roleId, ok := handlers.GetParam[int64](writer, request, "roleId")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestRoleRequirement](writer, request)
+	if !ok {
+		return
+	}
+
+	err := handler.service.SetRoleRequirement(roleId, *body.Required, userRoleId, userId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
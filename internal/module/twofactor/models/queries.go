@@ -0,0 +1,10 @@
+package models
+
+import "database/sql"
+
+// -- Stored Two-Factor Secret -- //
+type TwoFactorSecret struct {
+	Secret       string
+	LastUsedStep int64
+	EnabledAt    sql.NullString
+}
@@ -0,0 +1,11 @@
+package models
+
+// -- Verify A Code -- //
+type RequestTwoFactorCode struct {
+	Code string `json:"code" validate:"required,min=6,max=11"`
+}
+
+// -- Require Two-Factor For A Role -- //
+type RequestRoleRequirement struct {
+	Required *bool `json:"required" validate:"required"`
+}
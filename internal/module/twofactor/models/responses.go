@@ -0,0 +1,26 @@
+package models
+
+// -- Two-Factor Status Of Current User -- //
+type ResponseTwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RemainingRecoveryCodes int64 `json:"remainingRecoveryCodes"`
+}
+
+// -- Enrollment Details -- //
+type ResponseEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// -- Recovery Codes, Only Shown Once -- //
+type ResponseRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// -- Role Requirement -- //
+type ResponseRoleRequirement struct {
+	RoleId   int64  `json:"roleId"`
+	RoleName string `json:"roleName"`
+	Required bool   `json:"required"`
+}
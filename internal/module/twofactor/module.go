@@ -0,0 +1,50 @@
+package twofactor
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type TwoFactorModule struct {
+	Handler    TwoFactorHandler
+	Service    TwoFactorService
+	Repository TwoFactorRepository
+}
+
+func NewModule() TwoFactorModule {
+	database := db.Get()
+	permissionStore := permissions.Get()
+
+	repository := NewTwoFactorRepository(database)
+	service := NewTwoFactorService(repository, permissionStore, outbox.Get())
+	handler := NewTwoFactorHandler(service)
+
+	return TwoFactorModule{
+		Handler:    handler,
+		Service:    service,
+		Repository: repository,
+	}
+}
+
+func (module TwoFactorModule) RegisterModule(router *chi.Mux) {
+	config := config.Get()
+
+	router.With(middleware.AuthMiddleware(&config)).Route(
+		"/two-factor",
+		func(router chi.Router) {
+			router.Get("/", module.Handler.GetStatus)
+			router.Post("/enroll", module.Handler.BeginEnrollment)
+			router.Post("/confirm", module.Handler.ConfirmEnrollment)
+			router.Post("/disable", module.Handler.Disable)
+			router.Post("/recovery-codes", module.Handler.RegenerateRecoveryCodes)
+			router.Delete("/users/{id}", module.Handler.ResetUser)
+			router.Get("/roles", module.Handler.GetRoleRequirements)
+			router.Put("/roles/{roleId}", module.Handler.SetRoleRequirement)
+		},
+	)
+}
@@ -0,0 +1,63 @@
+package twofactor
+
+const (
+	QueryGetUserEmail = `
+	SELECT email
+	FROM users
+	WHERE id = ? AND deleted_at IS NULL;`
+	QueryGetSecret = `
+	SELECT secret, last_used_step, enabled_at
+	FROM user_two_factor
+	WHERE user_id = ?;`
+	QueryUpsertPendingSecret = `
+	INSERT INTO user_two_factor (user_id, secret, last_used_step, enabled_at)
+	VALUES (?, ?, 0, NULL)
+	ON CONFLICT(user_id) DO UPDATE SET
+		secret = excluded.secret,
+		last_used_step = 0,
+		enabled_at = NULL,
+		created_at = CURRENT_TIMESTAMP;`
+	QueryEnableSecret = `
+	UPDATE user_two_factor
+	SET
+		enabled_at = CURRENT_TIMESTAMP,
+		last_used_step = ?
+	WHERE user_id = ?;`
+	QueryUpdateLastUsedStep = `
+	UPDATE user_two_factor
+	SET last_used_step = ?
+	WHERE user_id = ? AND last_used_step < ?;`
+	QueryDeleteSecret = `
+	DELETE FROM user_two_factor
+	WHERE user_id = ?;`
+	QueryInsertRecoveryCode = `
+	INSERT INTO user_recovery_codes (user_id, code_hash)
+	VALUES (?, ?);`
+	QueryDeleteRecoveryCodes = `
+	DELETE FROM user_recovery_codes
+	WHERE user_id = ?;`
+	QueryUseRecoveryCode = `
+	UPDATE user_recovery_codes
+	SET used_at = CURRENT_TIMESTAMP
+	WHERE user_id = ? AND code_hash = ? AND used_at IS NULL;`
+	QueryCountRecoveryCodes = `
+	SELECT COUNT(*)
+	FROM user_recovery_codes
+	WHERE user_id = ? AND used_at IS NULL;`
+	QueryIsRoleRequired = `
+	SELECT COUNT(*)
+	FROM role_two_factor_requirements
+	WHERE role_id = ?;`
+	QueryGetRoleRequirements = `
+	SELECT r.id, r.name, rtf.role_id IS NOT NULL AS required
+	FROM roles r
+	LEFT JOIN role_two_factor_requirements rtf ON rtf.role_id = r.id
+	ORDER BY r.id ASC;`
+	QueryInsertRoleRequirement = `
+	INSERT INTO role_two_factor_requirements (role_id)
+	VALUES (?)
+	ON CONFLICT(role_id) DO NOTHING;`
+	QueryDeleteRoleRequirement = `
+	DELETE FROM role_two_factor_requirements
+	WHERE role_id = ?;`
+)
@@ -0,0 +1,223 @@
+package twofactor
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/module/twofactor/models"
+	"bloggo/internal/utils/cryptography"
+	"database/sql"
+)
+
+type TwoFactorRepository struct {
+	database db.Executor
+}
+
+func NewTwoFactorRepository(database *sql.DB) TwoFactorRepository {
+	return TwoFactorRepository{
+		database,
+	}
+}
+
+// WithTx returns the repository running in the transaction
+func (repository *TwoFactorRepository) WithTx(tx *sql.Tx) TwoFactorRepository {
+	return TwoFactorRepository{
+		tx,
+	}
+}
+
+func (repository *TwoFactorRepository) GetUserEmail(userId int64) (string, error) {
+	var email string
+	err := repository.database.QueryRow(QueryGetUserEmail, userId).Scan(&email)
+	return email, err
+}
+
+// Returns nil without error if the user never started an enrollment.
+func (repository *TwoFactorRepository) GetSecret(
+	userId int64,
+) (*models.TwoFactorSecret, error) {
+	var secret models.TwoFactorSecret
+	err := repository.database.QueryRow(QueryGetSecret, userId).Scan(
+		&secret.Secret,
+		&secret.LastUsedStep,
+		&secret.EnabledAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (repository *TwoFactorRepository) UpsertPendingSecret(
+	userId int64,
+	secret string,
+) error {
+	_, err := repository.database.Exec(QueryUpsertPendingSecret, userId, secret)
+	return err
+}
+
+// Enables the secret and replaces recovery codes in a single transaction.
+func (repository *TwoFactorRepository) EnableSecret(
+	userId int64,
+	step int64,
+	recoveryCodes []string,
+) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(QueryEnableSecret, step, userId); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userId, recoveryCodes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Marks the step as used. Returns false if it was already used.
+func (repository *TwoFactorRepository) UpdateLastUsedStep(
+	userId int64,
+	step int64,
+) (bool, error) {
+	result, err := repository.database.Exec(
+		QueryUpdateLastUsedStep,
+		step,
+		userId,
+		step,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (repository *TwoFactorRepository) DeleteSecret(userId int64) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(QueryDeleteSecret, userId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(QueryDeleteRecoveryCodes, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repository *TwoFactorRepository) ReplaceRecoveryCodes(
+	userId int64,
+	recoveryCodes []string,
+) error {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userId, recoveryCodes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Consumes a recovery code. Returns false if no unused code matches.
+func (repository *TwoFactorRepository) UseRecoveryCode(
+	userId int64,
+	codeHash string,
+) (bool, error) {
+	result, err := repository.database.Exec(QueryUseRecoveryCode, userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (repository *TwoFactorRepository) CountRecoveryCodes(userId int64) (int64, error) {
+	var count int64
+	err := repository.database.QueryRow(QueryCountRecoveryCodes, userId).Scan(&count)
+	return count, err
+}
+
+func (repository *TwoFactorRepository) IsRoleRequired(roleId int64) (bool, error) {
+	var count int64
+	err := repository.database.QueryRow(QueryIsRoleRequired, roleId).Scan(&count)
+	return count > 0, err
+}
+
+func (repository *TwoFactorRepository) GetRoleRequirements() (
+	[]models.ResponseRoleRequirement,
+	error,
+) {
+	rows, err := repository.database.Query(QueryGetRoleRequirements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := []models.ResponseRoleRequirement{}
+	for rows.Next() {
+		var requirement models.ResponseRoleRequirement
+		if err := rows.Scan(
+			&requirement.RoleId,
+			&requirement.RoleName,
+			&requirement.Required,
+		); err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requirements, nil
+}
+
+func (repository *TwoFactorRepository) SetRoleRequirement(
+	roleId int64,
+	required bool,
+) error {
+	query := QueryDeleteRoleRequirement
+	if required {
+		query = QueryInsertRoleRequirement
+	}
+	_, err := repository.database.Exec(query, roleId)
+	return err
+}
+
+func replaceRecoveryCodes(
+	tx db.Executor,
+	userId int64,
+	recoveryCodes []string,
+) error {
+	if _, err := tx.Exec(QueryDeleteRecoveryCodes, userId); err != nil {
+		return err
+	}
+
+	statement, err := tx.Prepare(QueryInsertRecoveryCode)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	for _, code := range recoveryCodes {
+		if _, err := statement.Exec(userId, cryptography.HashString(code)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package twofactor
+
+import (
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/module/twofactor/models"
+	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	"bloggo/internal/utils/cryptography"
+	"strings"
+)
+
+const (
+	// Issuer shown in authenticator apps
+	TOTPIssuer = "Bloggo"
+	// Number of one-time recovery codes generated per enrollment
+	RecoveryCodeCount = 10
+)
+
+type TwoFactorService struct {
+	repository  TwoFactorRepository
+	permissions permissions.Store
+	outbox      *outbox.Outbox
+}
+
+func NewTwoFactorService(
+	repository TwoFactorRepository,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
+) TwoFactorService {
+	return TwoFactorService{
+		repository,
+		permissions,
+		outbox,
+	}
+}
+
+func (service *TwoFactorService) GetStatus(
+	userId int64,
+	userRoleId int64,
+) (*models.ResponseTwoFactorStatus, error) {
+	enabled, required, err := service.LoginRequirement(userId, userRoleId)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := int64(0)
+	if enabled {
+		remaining, err = service.repository.CountRecoveryCodes(userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.ResponseTwoFactorStatus{
+		Enabled:                enabled,
+		Required:               required,
+		RemainingRecoveryCodes: remaining,
+	}, nil
+}
+
+// Reports whether the user has two-factor enabled and whether the role requires it.
+func (service *TwoFactorService) LoginRequirement(
+	userId int64,
+	roleId int64,
+) (enabled bool, required bool, err error) {
+	secret, err := service.repository.GetSecret(userId)
+	if err != nil {
+		return false, false, err
+	}
+
+	required, err = service.repository.IsRoleRequired(roleId)
+	if err != nil {
+		return false, false, err
+	}
+
+	return secret != nil && secret.EnabledAt.Valid, required, nil
+}
+
+// Creates a new pending secret. It is not active until confirmed with a code.
+func (service *TwoFactorService) BeginEnrollment(
+	userId int64,
+) (*models.ResponseEnrollment, error) {
+	existing, err := service.repository.GetSecret(userId)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.EnabledAt.Valid {
+		return nil, apierrors.ErrConflict
+	}
+
+	email, err := service.repository.GetUserEmail(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := cryptography.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.UpsertPendingSecret(userId, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseEnrollment{
+		Secret:          secret,
+		ProvisioningURI: cryptography.TOTPProvisioningURI(TOTPIssuer, email, secret),
+	}, nil
+}
+
+// Activates the pending secret and returns fresh recovery codes.
+func (service *TwoFactorService) ConfirmEnrollment(
+	userId int64,
+	code string,
+	auditContext auditcontext.Context,
+) (*models.ResponseRecoveryCodes, error) {
+	secret, err := service.repository.GetSecret(userId)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, apierrors.ErrPreconditionRequired
+	}
+	if secret.EnabledAt.Valid {
+		return nil, apierrors.ErrConflict
+	}
+
+	step, ok := cryptography.ValidateTOTP(secret.Secret, code, secret.LastUsedStep)
+	if !ok {
+		return nil, apierrors.ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, err := cryptography.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.EnableSecret(userId, step, recoveryCodes); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TwoFactorEnabled{
+			UserID:  userId,
+			Context: auditContext,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseRecoveryCodes{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// Verifies a TOTP code or consumes a recovery code of an enrolled user.
+func (service *TwoFactorService) Verify(userId int64, code string, auditContext auditcontext.Context) error {
+	secret, err := service.repository.GetSecret(userId)
+	if err != nil {
+		return err
+	}
+	if secret == nil || !secret.EnabledAt.Valid {
+		return apierrors.ErrPreconditionRequired
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if len(code) == cryptography.TOTPDigits {
+		step, ok := cryptography.ValidateTOTP(secret.Secret, code, secret.LastUsedStep)
+		if ok {
+			// A concurrent request may have used the same step already
+			updated, err := service.repository.UpdateLastUsedStep(userId, step)
+			if err != nil {
+				return err
+			}
+			if updated {
+				return nil
+			}
+		}
+	} else {
+		used, err := service.repository.UseRecoveryCode(
+			userId,
+			cryptography.HashString(code),
+		)
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	if err := service.outbox.Publish(events.TwoFactorFailed{
+		UserID:  userId,
+		Context: auditContext,
+	}); err != nil {
+		return err
+	}
+	return apierrors.ErrInvalidTwoFactorCode
+}
+
+// Disables two-factor for the user. A valid code is required.
+func (service *TwoFactorService) Disable(
+	userId int64,
+	userRoleId int64,
+	code string,
+	auditContext auditcontext.Context,
+) error {
+	required, err := service.repository.IsRoleRequired(userRoleId)
+	if err != nil {
+		return err
+	}
+	if required {
+		return apierrors.ErrTwoFactorRequired
+	}
+
+	if err := service.Verify(userId, code, auditContext); err != nil {
+		return err
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.DeleteSecret(userId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TwoFactorDisabled{
+			UserID:  userId,
+			Context: auditContext,
+		})
+	})
+}
+
+// Replaces remaining recovery codes with new ones. A valid code is required.
+func (service *TwoFactorService) RegenerateRecoveryCodes(
+	userId int64,
+	code string,
+	auditContext auditcontext.Context,
+) (*models.ResponseRecoveryCodes, error) {
+	if err := service.Verify(userId, code, auditContext); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := cryptography.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.ReplaceRecoveryCodes(userId, recoveryCodes); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TwoFactorRecoveryCodesRegenerated{
+			UserID:  userId,
+			Context: auditContext,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseRecoveryCodes{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// Removes two-factor from another user, e.g. after losing their device.
+func (service *TwoFactorService) ResetUser(
+	targetUserId int64,
+	userRoleId int64,
+	resetBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "twofactor:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.DeleteSecret(targetUserId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.TwoFactorReset{
+			ActorID: resetBy,
+			Context: auditContext,
+			UserID:  targetUserId,
+		})
+	})
+}
+
+func (service *TwoFactorService) GetRoleRequirements(
+	userRoleId int64,
+) ([]models.ResponseRoleRequirement, error) {
+	if !service.permissions.HasPermission(userRoleId, "twofactor:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return service.repository.GetRoleRequirements()
+}
+
+func (service *TwoFactorService) SetRoleRequirement(
+	roleId int64,
+	required bool,
+	userRoleId int64,
+	userId int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "twofactor:manage") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		previouslyRequired, err := repository.IsRoleRequired(roleId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.SetRoleRequirement(roleId, required); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.RoleUpdated{
+			ActorID: userId,
+			Context: auditContext,
+			RoleID:  roleId,
+			Before:  map[string]interface{}{"twoFactorRequired": previouslyRequired},
+			After:   map[string]interface{}{"twoFactorRequired": required},
+		})
+	})
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"bloggo/internal/module/user/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
 	"bloggo/internal/utils/pagination"
@@ -101,7 +102,7 @@ func (handler *UserHandler) UserCreate(
 		return
 	}
 
-	created, err := handler.service.UserCreate(body, userId)
+	created, err := handler.service.UserCreate(body, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -125,7 +126,7 @@ func (handler *UserHandler) UpdateSelfAvatar(
 	}
 	defer file.Close()
 
-	avatarPath, err := handler.service.UpdateAvatarById(userId, file, fileHeader, userId)
+	avatarPath, err := handler.service.UpdateAvatarById(userId, file, fileHeader, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -149,7 +150,7 @@ func (handler *UserHandler) DeleteUserAvatar(
 		return
 	}
 
-	err := handler.service.DeleteAvatarById(id, deleterId)
+	err := handler.service.DeleteAvatarById(id, deleterId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -167,7 +168,7 @@ func (handler *UserHandler) DeleteSelfAvatar(
 		return
 	}
 
-	err := handler.service.DeleteAvatarById(userId, userId)
+	err := handler.service.DeleteAvatarById(userId, userId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -195,7 +196,7 @@ func (handler *UserHandler) UpdateUserById(
 		return
 	}
 
-	err := handler.service.UpdateUserById(id, body, updaterId)
+	err := handler.service.UpdateUserById(id, body, updaterId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -224,7 +225,7 @@ func (handler *UserHandler) UpdateUserAvatar(
 	}
 	defer file.Close()
 
-	avatarPath, err := handler.service.UpdateAvatarById(id, file, fileHeader, updaterId)
+	avatarPath, err := handler.service.UpdateAvatarById(id, file, fileHeader, updaterId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -253,7 +254,7 @@ func (handler *UserHandler) AssignRole(
 		return
 	}
 
-	err := handler.service.AssignRole(id, body, assignerId)
+	err := handler.service.AssignRole(id, body, assignerId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -281,7 +282,7 @@ func (handler *UserHandler) ChangePassword(
 		return
 	}
 
-	err := handler.service.ChangePassword(id, body, changerId)
+	err := handler.service.ChangePassword(id, body, changerId, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -304,7 +305,141 @@ func (handler *UserHandler) DeleteUser(
 		return
 	}
 
-	err := handler.service.DeleteUser(id, deleterId)
+	err := handler.service.DeleteUser(id, deleterId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *UserHandler) UnlockUser(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	unlockerId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	unlockerRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	err := handler.service.UnlockUser(id, unlockerRoleId, unlockerId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *UserHandler) ListCategoryPermissions(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	userRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	grants, err := handler.service.ListCategoryPermissions(id, userRoleId)
+	if err != nil {
+		apierrors.MapErrors(err, writer, nil)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(grants)
+}
+
+func (handler *UserHandler) GrantCategoryPermission(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	granterId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	granterRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestUserCategoryPermission](writer, request)
+	if !ok {
+		return
+	}
+
+	err := handler.service.GrantCategoryPermission(id, body, granterRoleId, granterId, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
+			apierrors.ErrBadRequest: {
+				Message: "The permission or the category does not exist.",
+				Status:  http.StatusBadRequest,
+			},
+		})
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *UserHandler) RevokeCategoryPermission(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	revokerId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	revokerRoleId, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	permission, ok := handlers.GetParam[string](writer, request, "permission")
+	if !ok {
+		return
+	}
+
+	categoryId, ok := handlers.GetParam[int64](writer, request, "categoryId")
+	if !ok {
+		return
+	}
+
+	err := handler.service.RevokeCategoryPermission(
+		id,
+		permission,
+		categoryId,
+		revokerRoleId,
+		revokerId,
+		auditcontext.FromRequest(request),
+	)
 	if err != nil {
 		apierrors.MapErrors(err, writer, nil)
 		return
@@ -20,6 +20,12 @@ type RequestUserAssignRole struct {
 	RoleId int64 `json:"roleId" validate:"required"`
 }
 
+// -- Narrow A Permission To A Category -- //
+type RequestUserCategoryPermission struct {
+	Permission string `json:"permission" validate:"required,max=50"`
+	CategoryId int64  `json:"categoryId" validate:"required"`
+}
+
 // -- Change Password -- //
 type RequestUserChangePassword struct {
 	NewPassword string `json:"newPassword" validate:"required,min=12,max=100"`
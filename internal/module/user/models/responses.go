@@ -30,3 +30,11 @@ type ResponseUserDetails struct {
 type ResponseAvatarUpdate struct {
 	Avatar string `json:"avatar"`
 }
+
+// -- Category Scoped Permission -- //
+type ResponseUserCategoryPermission struct {
+	Permission   string `json:"permission"`
+	CategoryId   int64  `json:"categoryId"`
+	CategoryName string `json:"categoryName"`
+	CreatedAt    string `json:"createdAt"`
+}
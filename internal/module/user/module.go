@@ -4,6 +4,11 @@ import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
 	"bloggo/internal/infrastructure/bucket"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/infrastructure/tokens"
+	"bloggo/internal/infrastructure/tokenversions"
 	"bloggo/internal/middleware"
 	"bloggo/internal/utils/file/transformfile"
 	"bloggo/internal/utils/file/validatefile"
@@ -28,7 +33,17 @@ func NewModule() UserModule {
 	avatarResizer := transformfile.NewImageTransformer(512, 512)
 
 	repository := NewUserRepository(database)
-	service := NewUserService(repository, bucket, imageValidator, avatarResizer)
+	service := NewUserService(
+		repository,
+		bucket,
+		imageValidator,
+		avatarResizer,
+		tokens.GetStore(),
+		tokenversions.Get(),
+		loginguard.GetAccountStore(),
+		permissions.Get(),
+		outbox.Get(),
+	)
 	handler := NewUserHandler(service)
 
 	return UserModule{
@@ -52,6 +67,13 @@ func (module UserModule) RegisterModule(router *chi.Mux) {
 			router.Delete("/{id}/avatar", module.Handler.DeleteUserAvatar)
 			router.Patch("/{id}/password", module.Handler.ChangePassword)
 			router.Patch("/{id}/role", module.Handler.AssignRole)
+			router.Post("/{id}/unlock", module.Handler.UnlockUser)
+			router.Get("/{id}/category-permissions", module.Handler.ListCategoryPermissions)
+			router.Post("/{id}/category-permissions", module.Handler.GrantCategoryPermission)
+			router.Delete(
+				"/{id}/category-permissions/{permission}/{categoryId}",
+				module.Handler.RevokeCategoryPermission,
+			)
 			router.Delete("/{id}", module.Handler.DeleteUser)
 			router.Get("/me", module.Handler.GetSelf)
 			router.Patch("/me/avatar", module.Handler.UpdateSelfAvatar)
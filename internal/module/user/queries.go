@@ -60,4 +60,21 @@ const (
 	SELECT role_id
 	FROM users
 	WHERE id = ? AND deleted_at IS NULL;`
+	QueryUserListCategoryPermissions = `
+	SELECT p.name, c.id, c.name, ucp.created_at
+	FROM user_category_permissions ucp
+	JOIN permissions p ON p.id = ucp.permission_id
+	JOIN categories c ON c.id = ucp.category_id
+	WHERE ucp.user_id = ?
+	ORDER BY p.name ASC, c.name ASC;`
+	QueryUserGrantCategoryPermission = `
+	INSERT INTO user_category_permissions (user_id, permission_id, category_id)
+	SELECT ?, p.id, c.id
+	FROM permissions p, categories c
+	WHERE p.name = ? AND c.id = ? AND c.deleted_at IS NULL;`
+	QueryUserRevokeCategoryPermission = `
+	DELETE FROM user_category_permissions
+	WHERE user_id = ?
+		AND permission_id = (SELECT id FROM permissions WHERE name = ?)
+		AND category_id = ?;`
 )
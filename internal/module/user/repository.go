@@ -1,6 +1,7 @@
 package user
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/user/models"
 	"bloggo/internal/utils/filter"
 	"bloggo/internal/utils/handlers"
@@ -9,7 +10,7 @@ import (
 )
 
 type UserRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewUserRepository(database *sql.DB) UserRepository {
@@ -18,6 +19,13 @@ func NewUserRepository(database *sql.DB) UserRepository {
 	}
 }
 
+// WithTx returns the repository running in the transaction
+func (repository *UserRepository) WithTx(tx *sql.Tx) UserRepository {
+	return UserRepository{
+		tx,
+	}
+}
+
 func (repository *UserRepository) GetUsers(
 	paginate *pagination.PaginationOptions,
 	search *filter.SearchOptions,
@@ -207,3 +215,67 @@ func (repository *UserRepository) GetUserRoleById(userId int64) (int64, error) {
 	}
 	return roleId, nil
 }
+
+func (repository *UserRepository) ListCategoryPermissions(
+	userId int64,
+) ([]models.ResponseUserCategoryPermission, error) {
+	rows, err := repository.database.Query(QueryUserListCategoryPermissions, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.ResponseUserCategoryPermission{}
+	for rows.Next() {
+		var grant models.ResponseUserCategoryPermission
+		if err := rows.Scan(
+			&grant.Permission,
+			&grant.CategoryId,
+			&grant.CategoryName,
+			&grant.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+// Returns false if the permission or the category doesn't exist.
+func (repository *UserRepository) GrantCategoryPermission(
+	userId int64,
+	permission string,
+	categoryId int64,
+) (bool, error) {
+	result, err := repository.database.Exec(
+		QueryUserGrantCategoryPermission,
+		userId,
+		permission,
+		categoryId,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Returns false if the user had no such grant.
+func (repository *UserRepository) RevokeCategoryPermission(
+	userId int64,
+	permission string,
+	categoryId int64,
+) (bool, error) {
+	result, err := repository.database.Exec(
+		QueryUserRevokeCategoryPermission,
+		userId,
+		permission,
+		categoryId,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
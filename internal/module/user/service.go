@@ -2,11 +2,15 @@ package user
 
 import (
 	"bloggo/internal/infrastructure/bucket"
-	auditmodels "bloggo/internal/module/audit/models"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/loginguard"
+	"bloggo/internal/infrastructure/outbox"
+	"bloggo/internal/infrastructure/permissions"
+	"bloggo/internal/infrastructure/tokens"
+	"bloggo/internal/infrastructure/tokenversions"
 	"bloggo/internal/module/user/models"
-	"bloggo/internal/module/webhook"
 	"bloggo/internal/utils/apierrors"
-	"bloggo/internal/utils/audit"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/cryptography"
 	"bloggo/internal/utils/file/transformfile"
 	"bloggo/internal/utils/file/validatefile"
@@ -16,6 +20,7 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
+	"strings"
 )
 
 type UserService struct {
@@ -23,6 +28,11 @@ type UserService struct {
 	bucket         bucket.Bucket
 	imageValidator validatefile.FileValidator
 	avatarResizer  transformfile.FileTransformer
+	refreshStore   tokens.Store
+	tokenVersions  tokenversions.Store
+	accountGuard   loginguard.Store
+	permissions    permissions.Store
+	outbox         *outbox.Outbox
 }
 
 func NewUserService(
@@ -30,12 +40,22 @@ func NewUserService(
 	bucket bucket.Bucket,
 	imageValidator validatefile.FileValidator,
 	avatarResizer transformfile.FileTransformer,
+	refreshStore tokens.Store,
+	tokenVersions tokenversions.Store,
+	accountGuard loginguard.Store,
+	permissions permissions.Store,
+	outbox *outbox.Outbox,
 ) UserService {
 	return UserService{
 		repository,
 		bucket,
 		imageValidator,
 		avatarResizer,
+		refreshStore,
+		tokenVersions,
+		accountGuard,
+		permissions,
+		outbox,
 	}
 }
 
@@ -100,24 +120,34 @@ func (service *UserService) GetUserById(
 func (service *UserService) UserCreate(
 	model *models.RequestUserCreate,
 	createdBy int64,
+	auditContext auditcontext.Context,
 ) (*responses.ResponseCreated, error) {
 	processed, err := model.HashUserPassphrase()
 	if err != nil {
 		return nil, err
 	}
 
-	id, err := service.repository.UserCreate(processed)
+	var id int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.UserCreate(processed)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserCreated{
+			ActorID: createdBy,
+			Context: auditContext,
+			UserID:  id,
+			Name:    model.Name,
+			Email:   model.Email,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	audit.LogAction(&createdBy, auditmodels.EntityUser, id, auditmodels.ActionUserCreated)
-
-	// Trigger webhook
-	go func() {
-		webhook.TriggerAuthorCreated(id, map[string]interface{}{"name": model.Name, "email": model.Email})
-	}()
-
 	return &responses.ResponseCreated{
 		Id: id,
 	}, nil
@@ -128,6 +158,7 @@ func (service *UserService) UpdateAvatarById(
 	file multipart.File,
 	header *multipart.FileHeader,
 	updatedBy int64,
+	auditContext auditcontext.Context,
 ) (string, error) {
 	// Check if file is an image
 	if err := service.imageValidator.Validate(file, header); err != nil {
@@ -158,20 +189,34 @@ func (service *UserService) UpdateAvatarById(
 	}
 
 	// Update database
-	if err := service.repository.UpdateAvatarById(userId, imageId); err != nil {
+	avatarPath := fmt.Sprintf("/uploads/avatar/%s", imageId)
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		user, err := repository.GetUserById(userId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.UpdateAvatarById(userId, imageId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserUpdated{
+			ActorID: updatedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Changes: map[string]interface{}{"avatar": avatarPath},
+			Before:  map[string]interface{}{"avatar": avatarURL(user.Avatar)},
+			After:   map[string]interface{}{"avatar": avatarPath},
+		})
+	})
+	if err != nil {
 		if deleteErr := service.bucket.Delete(fileName); deleteErr != nil {
 			log.Printf("Failed to delete avatar after db error: %v", deleteErr)
 		}
 		return "", fmt.Errorf("failed to update avatar in database: %w", err)
 	}
 
-	// Log audit action
-	audit.LogAction(&updatedBy, auditmodels.EntityUser, userId, auditmodels.ActionUpdated)
-
-	// Trigger webhook
-	avatarPath := fmt.Sprintf("/uploads/avatar/%s", imageId)
-	go func() { webhook.TriggerAuthorUpdated(userId, map[string]interface{}{"avatar": avatarPath}) }()
-
 	// Return the avatar path without .webp suffix
 	return avatarPath, nil
 }
@@ -180,26 +225,46 @@ func (service *UserService) UpdateUserById(
 	userId int64,
 	model *models.RequestUserUpdate,
 	updatedBy int64,
+	auditContext auditcontext.Context,
 ) error {
-	err := service.repository.UpdateUserById(userId, model)
-	if err != nil {
-		return err
-	}
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		user, err := repository.GetUserById(userId)
+		if err != nil {
+			return err
+		}
 
-	audit.LogAction(&updatedBy, auditmodels.EntityUser, userId, auditmodels.ActionUserUpdated)
+		if err := repository.UpdateUserById(userId, model); err != nil {
+			return err
+		}
 
-	// Trigger webhook
-	go func() {
-		webhook.TriggerAuthorUpdated(userId, map[string]interface{}{"name": model.Name, "email": model.Email})
-	}()
+		before := map[string]interface{}{}
+		after := map[string]interface{}{}
+		if model.Name != nil {
+			before["name"] = user.Name
+			after["name"] = *model.Name
+		}
+		if model.Email != nil {
+			before["email"] = user.Email
+			after["email"] = *model.Email
+		}
 
-	return nil
+		return tx.Publish(events.UserUpdated{
+			ActorID: updatedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Changes: map[string]interface{}{"name": model.Name, "email": model.Email},
+			Before:  before,
+			After:   after,
+		})
+	})
 }
 
 func (service *UserService) AssignRole(
 	userId int64,
 	model *models.RequestUserAssignRole,
 	assignedBy int64,
+	auditContext auditcontext.Context,
 ) error {
 	// Prevent admins from lowering their own role
 	if userId == assignedBy {
@@ -216,25 +281,70 @@ func (service *UserService) AssignRole(
 		}
 	}
 
-	err := service.repository.AssignRole(userId, model.RoleId)
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		previousRoleId, err := repository.GetUserRoleById(userId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.AssignRole(userId, model.RoleId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserRoleAssigned{
+			ActorID: assignedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Before:  map[string]interface{}{"roleId": previousRoleId},
+			After:   map[string]interface{}{"roleId": model.RoleId},
+		})
+	})
 	if err != nil {
 		return err
 	}
 
-	audit.LogAction(&assignedBy, auditmodels.EntityUser, userId, auditmodels.ActionAssigned)
+	// Sessions must be recreated to pick up the new role
+	service.refreshStore.DeleteByUser(userId, "")
+	if _, err := service.tokenVersions.Bump(userId); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (service *UserService) DeleteUser(userId int64, deletedBy int64) error {
-	err := service.repository.DeleteUser(userId)
+func (service *UserService) DeleteUser(userId int64, deletedBy int64, auditContext auditcontext.Context) error {
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		user, err := repository.GetUserById(userId)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.DeleteUser(userId); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserDeleted{
+			ActorID: deletedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Before: map[string]interface{}{
+				"name":   user.Name,
+				"email":  user.Email,
+				"roleId": user.RoleId,
+			},
+		})
+	})
 	if err != nil {
 		return err
 	}
 
-	audit.LogAction(&deletedBy, auditmodels.EntityUser, userId, auditmodels.ActionUserDeleted)
-
-	// Trigger webhook
-	go func() { webhook.TriggerAuthorDeleted(userId) }()
+	// Deleted users cannot refresh or use their sessions anymore
+	service.refreshStore.DeleteByUser(userId, "")
+	if _, err := service.tokenVersions.Bump(userId); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -247,22 +357,154 @@ func (service *UserService) ChangePassword(
 	userId int64,
 	model *models.RequestUserChangePassword,
 	changedBy int64,
+	auditContext auditcontext.Context,
 ) error {
 	hashedPassword, err := model.HashNewPassword()
 	if err != nil {
 		return err
 	}
 
-	err = service.repository.UpdatePasswordById(userId, hashedPassword)
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		if err := repository.UpdatePasswordById(userId, hashedPassword); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.UserPassphraseChanged{
+			ActorID: changedBy,
+			Context: auditContext,
+			UserID:  userId,
+		})
+	})
 	if err != nil {
 		return err
 	}
 
-	audit.LogAction(&changedBy, auditmodels.EntityUser, userId, auditmodels.ActionUpdated)
+	// Log out everywhere, the old passphrase may have been compromised
+	service.refreshStore.DeleteByUser(userId, "")
+	if _, err := service.tokenVersions.Bump(userId); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (service *UserService) DeleteAvatarById(userId int64, deletedBy int64) error {
+// Clears failed login attempts of the user, lifting an account lockout
+func (service *UserService) UnlockUser(
+	userId int64,
+	userRoleId int64,
+	unlockedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "user:update") {
+		return apierrors.ErrForbidden
+	}
+
+	user, err := service.repository.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+
+	service.accountGuard.Reset(strings.ToLower(user.Email))
+
+	return service.outbox.Publish(events.UserUnlocked{
+		ActorID: unlockedBy,
+		Context: auditContext,
+		UserID:  userId,
+	})
+}
+
+func (service *UserService) ListCategoryPermissions(
+	userId int64,
+	userRoleId int64,
+) ([]models.ResponseUserCategoryPermission, error) {
+	if !service.permissions.HasPermission(userRoleId, "user:assign_role") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	if _, err := service.repository.GetUserById(userId); err != nil {
+		return nil, err
+	}
+
+	return service.repository.ListCategoryPermissions(userId)
+}
+
+// Narrows a permission of the user's role to the given category. Once a
+// permission has a category grant, it only applies to granted categories.
+func (service *UserService) GrantCategoryPermission(
+	userId int64,
+	model *models.RequestUserCategoryPermission,
+	userRoleId int64,
+	grantedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "user:assign_role") {
+		return apierrors.ErrForbidden
+	}
+
+	if _, err := service.repository.GetUserById(userId); err != nil {
+		return err
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		granted, err := repository.GrantCategoryPermission(
+			userId,
+			model.Permission,
+			model.CategoryId,
+		)
+		if err != nil {
+			return err
+		}
+		if !granted {
+			return apierrors.ErrBadRequest
+		}
+
+		return tx.Publish(events.UserCategoryPermissionGranted{
+			ActorID:    grantedBy,
+			Context:    auditContext,
+			UserID:     userId,
+			Permission: model.Permission,
+			CategoryID: model.CategoryId,
+		})
+	})
+}
+
+// Removes a category grant. Removing the last grant of a permission makes it
+// apply to every category again.
+func (service *UserService) RevokeCategoryPermission(
+	userId int64,
+	permission string,
+	categoryId int64,
+	userRoleId int64,
+	revokedBy int64,
+	auditContext auditcontext.Context,
+) error {
+	if !service.permissions.HasPermission(userRoleId, "user:assign_role") {
+		return apierrors.ErrForbidden
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		revoked, err := repository.RevokeCategoryPermission(userId, permission, categoryId)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return apierrors.ErrNotFound
+		}
+
+		return tx.Publish(events.UserCategoryPermissionRevoked{
+			ActorID:    revokedBy,
+			Context:    auditContext,
+			UserID:     userId,
+			Permission: permission,
+			CategoryID: categoryId,
+		})
+	})
+}
+
+func (service *UserService) DeleteAvatarById(userId int64, deletedBy int64, auditContext auditcontext.Context) error {
 	// Delete all avatar files for this user
 	if err := service.bucket.DeleteMatching(
 		fmt.Sprintf("%d_*.webp", userId),
@@ -272,14 +514,29 @@ func (service *UserService) DeleteAvatarById(userId int64, deletedBy int64) erro
 	}
 
 	// Update database to remove avatar reference
-	if err := service.repository.UpdateAvatarById(userId, ""); err != nil {
-		return fmt.Errorf("failed to update avatar in database: %w", err)
-	}
+	err := service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		user, err := repository.GetUserById(userId)
+		if err != nil {
+			return err
+		}
 
-	audit.LogAction(&deletedBy, auditmodels.EntityUser, userId, auditmodels.ActionUpdated)
+		if err := repository.UpdateAvatarById(userId, ""); err != nil {
+			return err
+		}
 
-	// Trigger webhook
-	go func() { webhook.TriggerAuthorUpdated(userId, map[string]interface{}{"avatar": nil}) }()
+		return tx.Publish(events.UserUpdated{
+			ActorID: deletedBy,
+			Context: auditContext,
+			UserID:  userId,
+			Changes: map[string]interface{}{"avatar": nil},
+			Before:  map[string]interface{}{"avatar": avatarURL(user.Avatar)},
+			After:   map[string]interface{}{"avatar": nil},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update avatar in database: %w", err)
+	}
 
 	return nil
 }
@@ -290,3 +547,12 @@ func (service *UserService) createUserRelatedUUID(
 	uuid := cryptography.GenerateUniqueId()
 	return fmt.Sprintf("%d_%s", userId, uuid)
 }
+
+// The public path of a stored avatar, nil when the user has none
+func avatarURL(avatar *string) *string {
+	if avatar == nil || *avatar == "" {
+		return nil
+	}
+	path := fmt.Sprintf("/uploads/avatar/%s", *avatar)
+	return &path
+}
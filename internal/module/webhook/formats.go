@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"bloggo/internal/config"
+	"bloggo/internal/module/webhook/models"
+	"bloggo/internal/utils/cryptography"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeCloudEvents = "application/cloudevents+json"
+)
+
+// Functions available to endpoint templates, json encodes a value so it can
+// be embedded in JSON bodies safely
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// cloudEvent is a CloudEvents 1.0 event in structured mode
+type cloudEvent struct {
+	SpecVersion     string                `json:"specversion"`
+	ID              string                `json:"id"`
+	Source          string                `json:"source"`
+	Type            string                `json:"type"`
+	Subject         string                `json:"subject,omitempty"`
+	Time            string                `json:"time"`
+	DataContentType string                `json:"datacontenttype"`
+	Data            models.WebhookPayload `json:"data"`
+}
+
+// chatMessage works with Slack incoming webhooks, which read text, and
+// Discord webhooks, which read content. Each ignores the other field.
+type chatMessage struct {
+	Text    string `json:"text"`
+	Content string `json:"content"`
+}
+
+// Renders the payload in the endpoint's format, returning the body and its
+// content type
+func renderPayload(endpoint models.WebhookEndpoint, payload models.WebhookPayload) ([]byte, string, error) {
+	switch endpoint.Format {
+	case models.FormatCloudEvents:
+		body, err := json.Marshal(cloudEvent{
+			SpecVersion:     "1.0",
+			ID:              cryptography.GenerateUniqueId(),
+			Source:          config.Get().PublicURL,
+			Type:            "bloggo." + payload.Event,
+			Subject:         payloadSubject(payload),
+			Time:            payload.Timestamp,
+			DataContentType: contentTypeJSON,
+			Data:            payload,
+		})
+		return body, contentTypeCloudEvents, err
+
+	case models.FormatChat:
+		text := chatText(payload)
+		body, err := json.Marshal(chatMessage{
+			Text:    text,
+			Content: text,
+		})
+		return body, contentTypeJSON, err
+
+	case models.FormatTemplate:
+		if endpoint.Template == nil {
+			return nil, "", fmt.Errorf("endpoint has no template")
+		}
+		parsed, err := parseTemplate(*endpoint.Template)
+		if err != nil {
+			return nil, "", err
+		}
+		var body bytes.Buffer
+		if err := parsed.Execute(&body, payload); err != nil {
+			return nil, "", err
+		}
+		return body.Bytes(), contentTypeJSON, nil
+	}
+
+	body, err := json.Marshal(payload)
+	return body, contentTypeJSON, err
+}
+
+// Entity and slug, or id when the entity has no slug
+func payloadSubject(payload models.WebhookPayload) string {
+	if payload.Slug != nil {
+		return payload.Entity + "/" + *payload.Slug
+	}
+	if payload.ID != nil {
+		return payload.Entity + "/" + strconv.FormatInt(*payload.ID, 10)
+	}
+	return ""
+}
+
+// e.g. post "hello-world" updated (post.updated), followed by the note of
+// workflow events
+func chatText(payload models.WebhookPayload) string {
+	subject := payload.Entity
+	if payload.Slug != nil {
+		subject += fmt.Sprintf(" %q", *payload.Slug)
+	} else if payload.ID != nil {
+		subject += fmt.Sprintf(" #%d", *payload.ID)
+	}
+	text := fmt.Sprintf("%s %s (%s)", subject, payload.Action, payload.Event)
+	if note, ok := payload.Data["note"].(*string); ok && note != nil && *note != "" {
+		text += ": " + *note
+	}
+	return text
+}
+
+// Headers recorded with a request, the rendered content type first so the
+// endpoint's own headers can still replace it
+func requestHeaders(endpoint models.WebhookEndpoint, contentType string) []models.WebhookHeader {
+	headers := []models.WebhookHeader{{Key: "Content-Type", Value: contentType}}
+	return append(headers, endpoint.Headers...)
+}
+
+// Content type the request was rendered with. Requests recorded before
+// formats existed were always JSON.
+func recordedContentType(record *models.WebhookRequest) string {
+	headers := []models.WebhookHeader{}
+	if record.WebhookHeaders != nil {
+		json.Unmarshal([]byte(*record.WebhookHeaders), &headers)
+	}
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, "Content-Type") {
+			return header.Value
+		}
+	}
+	return contentTypeJSON
+}
@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/webhook/models"
 	"sync"
@@ -19,15 +20,24 @@ func GetGlobalWebhookService() WebhookService {
 		database := db.Get()
 		permissionStore := permissions.Get()
 		repository := NewWebhookRepository(database)
-		globalWebhookService = NewWebhookService(repository, permissionStore)
+		globalWebhookService = NewWebhookService(repository, permissionStore, outbox.Get())
 	})
 	return globalWebhookService
 }
 
 // Helper functions for easy webhook triggering
 
+// Payloads carry when the event occurred rather than when it is relayed.
+// Events recorded before they had the time fall back to now.
+func timestamp(occurredAt time.Time) string {
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	return occurredAt.UTC().Format(time.RFC3339)
+}
+
 // TriggerPostCreated fires a webhook for post creation
-func TriggerPostCreated(postID int64, slug string, data map[string]interface{}) {
+func TriggerPostCreated(postID int64, slug string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "post.created",
@@ -35,14 +45,14 @@ func TriggerPostCreated(postID int64, slug string, data map[string]interface{})
 		ID:        &postID,
 		Slug:      &slug,
 		Action:    "created",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerPostUpdated fires a webhook for post update
-func TriggerPostUpdated(postID int64, slug string, oldSlug *string, data map[string]interface{}) {
+func TriggerPostUpdated(postID int64, slug string, oldSlug *string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "post.updated",
@@ -51,14 +61,14 @@ func TriggerPostUpdated(postID int64, slug string, oldSlug *string, data map[str
 		Slug:      &slug,
 		OldSlug:   oldSlug,
 		Action:    "updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerPostDeleted fires a webhook for post deletion
-func TriggerPostDeleted(postID int64, slug string) {
+func TriggerPostDeleted(postID int64, slug string, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "post.deleted",
@@ -66,14 +76,14 @@ func TriggerPostDeleted(postID int64, slug string) {
 		ID:        &postID,
 		Slug:      &slug,
 		Action:    "deleted",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      nil,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerCategoryCreated fires a webhook for category creation
-func TriggerCategoryCreated(categoryID int64, slug string, data map[string]interface{}) {
+func TriggerCategoryCreated(categoryID int64, slug string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "category.created",
@@ -81,14 +91,14 @@ func TriggerCategoryCreated(categoryID int64, slug string, data map[string]inter
 		ID:        &categoryID,
 		Slug:      &slug,
 		Action:    "created",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerCategoryUpdated fires a webhook for category update
-func TriggerCategoryUpdated(categoryID int64, slug string, oldSlug *string, data map[string]interface{}) {
+func TriggerCategoryUpdated(categoryID int64, slug string, oldSlug *string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "category.updated",
@@ -97,14 +107,14 @@ func TriggerCategoryUpdated(categoryID int64, slug string, oldSlug *string, data
 		Slug:      &slug,
 		OldSlug:   oldSlug,
 		Action:    "updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerCategoryDeleted fires a webhook for category deletion
-func TriggerCategoryDeleted(categoryID int64, slug string) {
+func TriggerCategoryDeleted(categoryID int64, slug string, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "category.deleted",
@@ -112,14 +122,14 @@ func TriggerCategoryDeleted(categoryID int64, slug string) {
 		ID:        &categoryID,
 		Slug:      &slug,
 		Action:    "deleted",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      nil,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerTagCreated fires a webhook for tag creation
-func TriggerTagCreated(tagID int64, slug string, data map[string]interface{}) {
+func TriggerTagCreated(tagID int64, slug string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "tag.created",
@@ -127,14 +137,14 @@ func TriggerTagCreated(tagID int64, slug string, data map[string]interface{}) {
 		ID:        &tagID,
 		Slug:      &slug,
 		Action:    "created",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerTagUpdated fires a webhook for tag update
-func TriggerTagUpdated(tagID int64, slug string, oldSlug *string, data map[string]interface{}) {
+func TriggerTagUpdated(tagID int64, slug string, oldSlug *string, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "tag.updated",
@@ -143,14 +153,14 @@ func TriggerTagUpdated(tagID int64, slug string, oldSlug *string, data map[strin
 		Slug:      &slug,
 		OldSlug:   oldSlug,
 		Action:    "updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerTagDeleted fires a webhook for tag deletion
-func TriggerTagDeleted(tagID int64, slug string) {
+func TriggerTagDeleted(tagID int64, slug string, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "tag.deleted",
@@ -158,14 +168,14 @@ func TriggerTagDeleted(tagID int64, slug string) {
 		ID:        &tagID,
 		Slug:      &slug,
 		Action:    "deleted",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      nil,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerAuthorCreated fires a webhook for author/user creation
-func TriggerAuthorCreated(authorID int64, data map[string]interface{}) {
+func TriggerAuthorCreated(authorID int64, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "author.created",
@@ -173,14 +183,14 @@ func TriggerAuthorCreated(authorID int64, data map[string]interface{}) {
 		ID:        &authorID,
 		Slug:      nil,
 		Action:    "created",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerAuthorUpdated fires a webhook for author/user update
-func TriggerAuthorUpdated(authorID int64, data map[string]interface{}) {
+func TriggerAuthorUpdated(authorID int64, data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "author.updated",
@@ -188,14 +198,14 @@ func TriggerAuthorUpdated(authorID int64, data map[string]interface{}) {
 		ID:        &authorID,
 		Slug:      nil,
 		Action:    "updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerAuthorDeleted fires a webhook for author/user deletion
-func TriggerAuthorDeleted(authorID int64) {
+func TriggerAuthorDeleted(authorID int64, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "author.deleted",
@@ -203,14 +213,14 @@ func TriggerAuthorDeleted(authorID int64) {
 		ID:        &authorID,
 		Slug:      nil,
 		Action:    "deleted",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
 		Data:      nil,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
 
 // TriggerKeyValueUpdated fires a webhook for key-value configuration update
-func TriggerKeyValueUpdated(data map[string]interface{}) {
+func TriggerKeyValueUpdated(data map[string]interface{}, occurredAt time.Time) error {
 	service := GetGlobalWebhookService()
 	payload := models.WebhookPayload{
 		Event:     "keyvalue.updated",
@@ -218,8 +228,135 @@ func TriggerKeyValueUpdated(data map[string]interface{}) {
 		ID:        nil,
 		Slug:      nil,
 		Action:    "updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// Workflow data names who acted and the note they left, if any
+func workflowData(actorID int64, note *string) map[string]interface{} {
+	return map[string]interface{}{
+		"actorId": actorID,
+		"note":    note,
+	}
+}
+
+// TriggerVersionSubmitted fires a webhook when a version is submitted for review
+func TriggerVersionSubmitted(postID int64, versionID int64, slug *string, actorID int64, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, nil)
+	data["postId"] = postID
+	payload := models.WebhookPayload{
+		Event:     "post_version.submitted",
+		Entity:    "post_version",
+		ID:        &versionID,
+		Slug:      slug,
+		Action:    "submitted",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerVersionApproved fires a webhook when a reviewer approves a version
+func TriggerVersionApproved(postID int64, versionID int64, slug *string, actorID int64, note *string, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, note)
+	data["postId"] = postID
+	payload := models.WebhookPayload{
+		Event:     "post_version.approved",
+		Entity:    "post_version",
+		ID:        &versionID,
+		Slug:      slug,
+		Action:    "approved",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerVersionRejected fires a webhook when a reviewer rejects a version
+func TriggerVersionRejected(postID int64, versionID int64, slug *string, actorID int64, note *string, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, note)
+	data["postId"] = postID
+	payload := models.WebhookPayload{
+		Event:     "post_version.rejected",
+		Entity:    "post_version",
+		ID:        &versionID,
+		Slug:      slug,
+		Action:    "rejected",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerRemovalRequestCreated fires a webhook when removal of a post is requested
+func TriggerRemovalRequestCreated(requestID int64, versionID int64, actorID int64, note *string, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, note)
+	data["postVersionId"] = versionID
+	payload := models.WebhookPayload{
+		Event:     "removal_request.created",
+		Entity:    "removal_request",
+		ID:        &requestID,
+		Slug:      nil,
+		Action:    "created",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerRemovalRequestApproved fires a webhook when a removal request is
+// approved and its post deleted
+func TriggerRemovalRequestApproved(requestID int64, versionID int64, postID int64, actorID int64, note *string, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, note)
+	data["postVersionId"] = versionID
+	data["postId"] = postID
+	payload := models.WebhookPayload{
+		Event:     "removal_request.approved",
+		Entity:    "removal_request",
+		ID:        &requestID,
+		Slug:      nil,
+		Action:    "approved",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerRemovalRequestRejected fires a webhook when a removal request is rejected
+func TriggerRemovalRequestRejected(requestID int64, versionID int64, actorID int64, note *string, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	data := workflowData(actorID, note)
+	data["postVersionId"] = versionID
+	payload := models.WebhookPayload{
+		Event:     "removal_request.rejected",
+		Entity:    "removal_request",
+		ID:        &requestID,
+		Slug:      nil,
+		Action:    "rejected",
+		Timestamp: timestamp(occurredAt),
+		Data:      data,
+	}
+	return service.FireWebhook(payload)
+}
+
+// TriggerAuthorLogin fires a webhook when a user signs in
+func TriggerAuthorLogin(authorID int64, data map[string]interface{}, occurredAt time.Time) error {
+	service := GetGlobalWebhookService()
+	payload := models.WebhookPayload{
+		Event:     "author.login",
+		Entity:    "author",
+		ID:        &authorID,
+		Slug:      nil,
+		Action:    "login",
+		Timestamp: timestamp(occurredAt),
 		Data:      data,
 	}
-	service.FireWebhook(payload)
+	return service.FireWebhook(payload)
 }
@@ -1,9 +1,9 @@
 package webhook
 
 import (
-	"bloggo/internal/module/audit"
 	"bloggo/internal/module/webhook/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
 	"bloggo/internal/utils/handlers"
 	"encoding/json"
 	"net/http"
@@ -22,8 +22,23 @@ func NewWebhookHandler(service WebhookService) WebhookHandler {
 	}
 }
 
-// GetConfig handles GET /api/webhook/config
-func (handler *WebhookHandler) GetConfig(
+var endpointErrorMapping = apierrors.HTTPErrorMapping{
+	apierrors.ErrForbidden: {
+		Message: "Only editors and admins can manage webhooks.",
+		Status:  http.StatusForbidden,
+	},
+	apierrors.ErrNotFound: {
+		Message: "Webhook endpoint not found.",
+		Status:  http.StatusNotFound,
+	},
+	apierrors.ErrBadRequest: {
+		Message: "Webhook template could not be parsed.",
+		Status:  http.StatusBadRequest,
+	},
+}
+
+// GetEndpoints handles GET /api/webhook/endpoints
+func (handler *WebhookHandler) GetEndpoints(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
@@ -32,23 +47,43 @@ func (handler *WebhookHandler) GetConfig(
 		return
 	}
 
-	config, err := handler.service.GetConfig(roleID)
+	endpoints, err := handler.service.GetEndpoints(roleID)
 	if err != nil {
-		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
-			apierrors.ErrForbidden: {
-				Message: "Only editors and admins can manage webhooks.",
-				Status:  http.StatusForbidden,
-			},
-		})
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
 		return
 	}
 
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(config)
+	json.NewEncoder(writer).Encode(endpoints)
 }
 
-// UpdateConfig handles PUT /api/webhook/config
-func (handler *WebhookHandler) UpdateConfig(
+// GetEndpointByID handles GET /api/webhook/endpoints/:id
+func (handler *WebhookHandler) GetEndpointByID(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	roleID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	endpoint, err := handler.service.GetEndpointByID(id, roleID)
+	if err != nil {
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(endpoint)
+}
+
+// CreateEndpoint handles POST /api/webhook/endpoints
+func (handler *WebhookHandler) CreateEndpoint(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
@@ -62,33 +97,60 @@ func (handler *WebhookHandler) UpdateConfig(
 		return
 	}
 
-	body, ok := handlers.BindAndValidate[models.RequestUpdateConfig](writer, request)
+	body, ok := handlers.BindAndValidate[*models.RequestEndpointUpsert](writer, request)
 	if !ok {
 		return
 	}
 
-	err := handler.service.UpdateConfig(body.URL, roleID)
+	created, err := handler.service.CreateEndpoint(body, roleID, userID, auditcontext.FromRequest(request))
 	if err != nil {
-		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
-			apierrors.ErrForbidden: {
-				Message: "Only editors and admins can manage webhooks.",
-				Status:  http.StatusForbidden,
-			},
-		})
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
 		return
 	}
 
-	// Log audit
-	audit.LogWebhookAction(&userID, "config_updated")
+	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(created)
+}
+
+// UpdateEndpoint handles PUT /api/webhook/endpoints/:id
+func (handler *WebhookHandler) UpdateEndpoint(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	roleID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	userID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestEndpointUpsert](writer, request)
+	if !ok {
+		return
+	}
+
+	err := handler.service.UpdateEndpoint(id, body, roleID, userID, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
+		return
+	}
 
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(models.ResponseMessage{
-		Message: "Webhook config updated successfully",
+		Message: "Webhook endpoint updated successfully",
 	})
 }
 
-// GetHeaders handles GET /api/webhook/headers
-func (handler *WebhookHandler) GetHeaders(
+// RotateSecret handles POST /api/webhook/endpoints/:id/secret
+func (handler *WebhookHandler) RotateSecret(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
@@ -97,23 +159,33 @@ func (handler *WebhookHandler) GetHeaders(
 		return
 	}
 
-	headers, err := handler.service.GetHeaders(roleID)
+	userID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int64](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestRotateSecret](writer, request)
+	if !ok {
+		return
+	}
+
+	rotated, err := handler.service.RotateSecret(id, body, roleID, userID, auditcontext.FromRequest(request))
 	if err != nil {
-		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
-			apierrors.ErrForbidden: {
-				Message: "Only editors and admins can manage webhooks.",
-				Status:  http.StatusForbidden,
-			},
-		})
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
 		return
 	}
 
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(headers)
+	json.NewEncoder(writer).Encode(rotated)
 }
 
-// BulkUpsertHeaders handles PUT /api/webhook/headers
-func (handler *WebhookHandler) BulkUpsertHeaders(
+// DeleteEndpoint handles DELETE /api/webhook/endpoints/:id
+func (handler *WebhookHandler) DeleteEndpoint(
 	writer http.ResponseWriter,
 	request *http.Request,
 ) {
@@ -127,29 +199,18 @@ func (handler *WebhookHandler) BulkUpsertHeaders(
 		return
 	}
 
-	body, ok := handlers.BindAndValidate[models.RequestBulkUpsertHeaders](writer, request)
+	id, ok := handlers.GetParam[int64](writer, request, "id")
 	if !ok {
 		return
 	}
 
-	err := handler.service.BulkUpsertHeaders(body.Items, roleID)
+	err := handler.service.DeleteEndpoint(id, roleID, userID, auditcontext.FromRequest(request))
 	if err != nil {
-		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
-			apierrors.ErrForbidden: {
-				Message: "Only editors and admins can manage webhooks.",
-				Status:  http.StatusForbidden,
-			},
-		})
+		apierrors.MapErrors(err, writer, endpointErrorMapping)
 		return
 	}
 
-	// Log audit
-	audit.LogWebhookAction(&userID, "headers_updated")
-
-	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(models.ResponseMessage{
-		Message: "Webhook headers updated successfully",
-	})
+	writer.WriteHeader(http.StatusNoContent)
 }
 
 // ManualFire handles POST /api/webhook/fire
@@ -167,7 +228,7 @@ func (handler *WebhookHandler) ManualFire(
 		return
 	}
 
-	err := handler.service.ManualFire(roleID)
+	err := handler.service.ManualFire(roleID, userID, auditcontext.FromRequest(request))
 	if err != nil {
 		apierrors.MapErrors(err, writer, apierrors.HTTPErrorMapping{
 			apierrors.ErrForbidden: {
@@ -178,9 +239,6 @@ func (handler *WebhookHandler) ManualFire(
 		return
 	}
 
-	// Log audit
-	audit.LogWebhookAction(&userID, "manual_fire")
-
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(models.ResponseMessage{
 		Message: "Webhook fired successfully",
@@ -279,3 +337,86 @@ func (handler *WebhookHandler) GetRequestByID(
 	writer.WriteHeader(http.StatusOK)
 	json.NewEncoder(writer).Encode(req)
 }
+
+var redeliveryErrorMapping = apierrors.HTTPErrorMapping{
+	apierrors.ErrForbidden: {
+		Message: "Only editors and admins can manage webhooks.",
+		Status:  http.StatusForbidden,
+	},
+	apierrors.ErrNotFound: {
+		Message: "Request not found.",
+		Status:  http.StatusNotFound,
+	},
+	apierrors.ErrConflict: {
+		Message: "Request is still queued for delivery.",
+		Status:  http.StatusConflict,
+	},
+	apierrors.ErrPreconditionFailed: {
+		Message: "The endpoint of this request was deleted.",
+		Status:  http.StatusPreconditionFailed,
+	},
+	apierrors.ErrBadRequest: {
+		Message: "Give a time range or an event pattern, with from before to.",
+		Status:  http.StatusBadRequest,
+	},
+}
+
+// RedeliverRequest handles POST /api/webhook/requests/:id/redeliver
+func (handler *WebhookHandler) RedeliverRequest(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	roleID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	userID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	id, ok := handlers.GetParam[int](writer, request, "id")
+	if !ok {
+		return
+	}
+
+	redelivered, err := handler.service.RedeliverRequest(id, roleID, userID, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, redeliveryErrorMapping)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(writer).Encode(redelivered)
+}
+
+// ReplayFailedRequests handles POST /api/webhook/requests/replay
+func (handler *WebhookHandler) ReplayFailedRequests(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	roleID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenRoleId)
+	if !ok {
+		return
+	}
+
+	userID, ok := handlers.GetContextValue[int64](writer, request, handlers.TokenUserId)
+	if !ok {
+		return
+	}
+
+	body, ok := handlers.BindAndValidate[*models.RequestReplayFailed](writer, request)
+	if !ok {
+		return
+	}
+
+	replayed, err := handler.service.ReplayFailedRequests(body, roleID, userID, auditcontext.FromRequest(request))
+	if err != nil {
+		apierrors.MapErrors(err, writer, redeliveryErrorMapping)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(writer).Encode(replayed)
+}
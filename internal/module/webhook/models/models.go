@@ -1,13 +1,37 @@
 package models
 
-// WebhookConfig represents the webhook configuration
-type WebhookConfig struct {
-	ID        int    `json:"id"`
-	URL       string `json:"url"`
-	UpdatedAt string `json:"updatedAt"`
+// WebhookEndpoint represents a URL receiving the events it subscribes to
+type WebhookEndpoint struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	Enabled   bool            `json:"enabled"`
+	Format    string          `json:"format"`
+	Template  *string         `json:"template"`
+	Events    []string        `json:"events"`
+	Headers   []WebhookHeader `json:"headers"`
+	CreatedAt string          `json:"createdAt"`
+	UpdatedAt string          `json:"updatedAt"`
+
+	// Secrets are only shown when created or rotated
+	Secret                  string  `json:"-"`
+	PreviousSecret          *string `json:"-"`
+	PreviousSecretExpiresAt *string `json:"previousSecretExpiresAt"`
 }
 
-// WebhookHeader represents a custom HTTP header
+// Body formats an endpoint can receive
+const (
+	// WebhookPayload as JSON
+	FormatJSON = "json"
+	// CloudEvents 1.0 structured mode, the WebhookPayload is the data
+	FormatCloudEvents = "cloudevents"
+	// Incoming webhook message for Slack and Discord
+	FormatChat = "chat"
+	// The endpoint's text/template executed with the WebhookPayload
+	FormatTemplate = "template"
+)
+
+// WebhookHeader represents a custom HTTP header of an endpoint
 type WebhookHeader struct {
 	ID        int    `json:"id"`
 	Key       string `json:"key"`
@@ -16,9 +40,20 @@ type WebhookHeader struct {
 	UpdatedAt string `json:"updatedAt"`
 }
 
-// WebhookRequest represents a webhook request log
+// Delivery states of a webhook request. Pending requests wait for their
+// next attempt, dead ones ran out of attempts.
+const (
+	RequestStatusPending   = "pending"
+	RequestStatusSending   = "sending"
+	RequestStatusDelivered = "delivered"
+	RequestStatusDead      = "dead"
+)
+
+// WebhookRequest represents a webhook request log and its place in the
+// delivery queue
 type WebhookRequest struct {
 	ID             int     `json:"id"`
+	EndpointID     *int64  `json:"endpointId"`
 	Event          string  `json:"event"`
 	Entity         string  `json:"entity"`
 	EntityID       *int64  `json:"entityId"`
@@ -28,8 +63,26 @@ type WebhookRequest struct {
 	ResponseBody   *string `json:"responseBody"`
 	AttemptCount   int     `json:"attemptCount"`
 	ErrorMessage   *string `json:"errorMessage"`
+	Status         string  `json:"status"`
+	NextAttemptAt  *string `json:"nextAttemptAt"`
 	WebhookURL     *string `json:"webhookUrl"`
 	WebhookHeaders *string `json:"webhookHeaders"`
+	// The first request of the delivery when this one redelivers it
+	RedeliveryOf *int64 `json:"redeliveryOf"`
+	CreatedAt    string `json:"createdAt"`
+
+	// Every request of the delivery, the original and its redeliveries.
+	// Only set when a single request is fetched.
+	History []WebhookRequestAttempt `json:"history,omitempty"`
+}
+
+// WebhookRequestAttempt summarizes one request of a delivery's history
+type WebhookRequestAttempt struct {
+	ID             int     `json:"id"`
+	Status         string  `json:"status"`
+	AttemptCount   int     `json:"attemptCount"`
+	ResponseStatus *int    `json:"responseStatus"`
+	ErrorMessage   *string `json:"errorMessage"`
 	CreatedAt      string  `json:"createdAt"`
 }
 
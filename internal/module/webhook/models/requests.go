@@ -1,8 +1,34 @@
 package models
 
-// RequestUpdateConfig represents the request to update webhook config
-type RequestUpdateConfig struct {
-	URL string `json:"url" validate:"required,url,max=2048"`
+import "time"
+
+// RequestEndpointUpsert represents the request to create or replace an
+// endpoint. Events are patterns such as post.created, post.* or *. The
+// format defaults to json, template is required for the template format.
+type RequestEndpointUpsert struct {
+	Name     string                `json:"name" validate:"required,max=100"`
+	URL      string                `json:"url" validate:"required,url,max=2048"`
+	Enabled  *bool                 `json:"enabled"`
+	Format   string                `json:"format" validate:"omitempty,oneof=json cloudevents chat template"`
+	Template string                `json:"template" validate:"required_if=Format template,max=10000"`
+	Events   []string              `json:"events" validate:"required,min=1,dive,max=100,eventPattern"`
+	Headers  []RequestHeaderUpsert `json:"headers" validate:"omitempty,dive"`
+}
+
+// RequestRotateSecret represents the request to replace an endpoint's
+// secret. The previous secret keeps signing deliveries for the grace period
+// in seconds, one day when omitted.
+type RequestRotateSecret struct {
+	GracePeriod *int `json:"gracePeriod" validate:"omitempty,min=0,max=604800"`
+}
+
+// RequestReplayFailed represents the request to redeliver dead deliveries
+// first sent within the time range and, when given, for events matching
+// the pattern
+type RequestReplayFailed struct {
+	From  *time.Time `json:"from"`
+	To    *time.Time `json:"to"`
+	Event string     `json:"event" validate:"omitempty,max=100,eventPattern"`
 }
 
 // RequestHeaderUpsert represents a single header upsert
@@ -11,11 +37,6 @@ type RequestHeaderUpsert struct {
 	Value string `json:"value" validate:"required"`
 }
 
-// RequestBulkUpsertHeaders represents bulk header updates
-type RequestBulkUpsertHeaders struct {
-	Items []RequestHeaderUpsert `json:"items" validate:"required,dive"`
-}
-
 // RequestGetWebhookRequests represents query params for getting webhook requests
 type RequestGetWebhookRequests struct {
 	Search string `json:"search"`
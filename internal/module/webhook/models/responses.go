@@ -1,9 +1,27 @@
 package models
 
-// ResponseConfig represents the webhook config response
-type ResponseConfig struct {
-	URL       string `json:"url"`
-	UpdatedAt string `json:"updatedAt"`
+// ResponseEndpointCreated returns the id of a new endpoint
+type ResponseEndpointCreated struct {
+	ID     int64  `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// ResponseSecretRotated returns the new secret of an endpoint
+type ResponseSecretRotated struct {
+	Secret                  string  `json:"secret"`
+	PreviousSecretExpiresAt *string `json:"previousSecretExpiresAt"`
+}
+
+// ResponseRedelivered returns the id of the queued redelivery
+type ResponseRedelivered struct {
+	ID int64 `json:"id"`
+}
+
+// ResponseReplayed reports how many dead deliveries were queued again.
+// Deliveries whose endpoint was deleted are skipped.
+type ResponseReplayed struct {
+	Replayed int `json:"replayed"`
+	Skipped  int `json:"skipped"`
 }
 
 // ResponseMessage is a generic message response
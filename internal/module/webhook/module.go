@@ -3,6 +3,7 @@ package webhook
 import (
 	"bloggo/internal/config"
 	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/middleware"
 
@@ -20,7 +21,7 @@ func NewModule() WebhookModule {
 	permissionStore := permissions.Get()
 
 	repository := NewWebhookRepository(database)
-	service := NewWebhookService(repository, permissionStore)
+	service := NewWebhookService(repository, permissionStore, outbox.Get())
 	handler := NewWebhookHandler(service)
 
 	return WebhookModule{
@@ -36,16 +37,19 @@ func (module WebhookModule) RegisterModule(router *chi.Mux) {
 	router.With(middleware.AuthMiddleware(&config)).Route(
 		"/webhook",
 		func(router chi.Router) {
-			router.Get("/config", module.Handler.GetConfig)
-			router.Put("/config", module.Handler.UpdateConfig)
-
-			router.Get("/headers", module.Handler.GetHeaders)
-			router.Put("/headers", module.Handler.BulkUpsertHeaders)
+			router.Get("/endpoints", module.Handler.GetEndpoints)
+			router.Post("/endpoints", module.Handler.CreateEndpoint)
+			router.Get("/endpoints/{id}", module.Handler.GetEndpointByID)
+			router.Put("/endpoints/{id}", module.Handler.UpdateEndpoint)
+			router.Delete("/endpoints/{id}", module.Handler.DeleteEndpoint)
+			router.Post("/endpoints/{id}/secret", module.Handler.RotateSecret)
 
 			router.Post("/fire", module.Handler.ManualFire)
 
 			router.Get("/requests", module.Handler.GetRequests)
+			router.Post("/requests/replay", module.Handler.ReplayFailedRequests)
 			router.Get("/requests/{id}", module.Handler.GetRequestByID)
+			router.Post("/requests/{id}/redeliver", module.Handler.RedeliverRequest)
 		},
 	)
 }
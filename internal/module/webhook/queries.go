@@ -1,69 +1,170 @@
 package webhook
 
 const (
-	// Config queries
-	QueryGetConfig = `
-	SELECT id, url, updated_at
-	FROM webhook_config
-	WHERE id = 1;`
-
-	QueryUpsertConfig = `
-	INSERT INTO webhook_config (id, url, updated_at)
-	VALUES (1, ?, CURRENT_TIMESTAMP)
-	ON CONFLICT(id) DO UPDATE SET
-		url = excluded.url,
-		updated_at = CURRENT_TIMESTAMP;`
+	// Endpoint queries
+	QueryGetAllEndpoints = `
+	SELECT id, name, url, enabled, format, template, created_at, updated_at,
+		secret, previous_secret, previous_secret_expires_at
+	FROM webhook_endpoints
+	ORDER BY name ASC, id ASC;`
+
+	QueryGetEndpointByID = `
+	SELECT id, name, url, enabled, format, template, created_at, updated_at,
+		secret, previous_secret, previous_secret_expires_at
+	FROM webhook_endpoints
+	WHERE id = ?;`
+
+	QueryInsertEndpoint = `
+	INSERT INTO webhook_endpoints (name, url, enabled, format, template, secret, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`
+
+	// The previous secret is dropped right away without an expiry
+	QueryRotateEndpointSecret = `
+	UPDATE webhook_endpoints
+	SET previous_secret = CASE WHEN ? IS NULL THEN NULL ELSE secret END,
+		previous_secret_expires_at = ?,
+		secret = ?,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?;`
+
+	QueryUpdateEndpoint = `
+	UPDATE webhook_endpoints
+	SET name = ?, url = ?, enabled = ?, format = ?, template = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?;`
+
+	QueryDeleteEndpoint = `
+	DELETE FROM webhook_endpoints
+	WHERE id = ?;`
+
+	// Endpoint event queries
+	QueryGetAllEndpointEvents = `
+	SELECT endpoint_id, pattern
+	FROM webhook_endpoint_events
+	ORDER BY pattern ASC;`
+
+	QueryGetEndpointEvents = `
+	SELECT endpoint_id, pattern
+	FROM webhook_endpoint_events
+	WHERE endpoint_id = ?
+	ORDER BY pattern ASC;`
+
+	QueryInsertEndpointEvent = `
+	INSERT OR IGNORE INTO webhook_endpoint_events (endpoint_id, pattern)
+	VALUES (?, ?);`
+
+	QueryDeleteEndpointEvents = `
+	DELETE FROM webhook_endpoint_events
+	WHERE endpoint_id = ?;`
 
-	// Header queries
-	QueryGetAllHeaders = `
-	SELECT id, key, value, created_at, updated_at
-	FROM webhook_headers
+	// Endpoint header queries
+	QueryGetAllEndpointHeaders = `
+	SELECT endpoint_id, id, key, value, created_at, updated_at
+	FROM webhook_endpoint_headers
 	ORDER BY key ASC;`
 
-	QueryUpsertHeader = `
-	INSERT INTO webhook_headers (key, value, created_at, updated_at)
-	VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	ON CONFLICT(key) DO UPDATE SET
+	QueryGetEndpointHeaders = `
+	SELECT endpoint_id, id, key, value, created_at, updated_at
+	FROM webhook_endpoint_headers
+	WHERE endpoint_id = ?
+	ORDER BY key ASC;`
+
+	QueryUpsertEndpointHeader = `
+	INSERT INTO webhook_endpoint_headers (endpoint_id, key, value, created_at, updated_at)
+	VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT(endpoint_id, key) DO UPDATE SET
 		value = excluded.value,
 		updated_at = CURRENT_TIMESTAMP;`
 
-	QueryDeleteAllHeaders = `
-	DELETE FROM webhook_headers;`
+	QueryDeleteEndpointHeaders = `
+	DELETE FROM webhook_endpoint_headers
+	WHERE endpoint_id = ?;`
 
 	// Request queries
 	QueryInsertRequest = `
 	INSERT INTO webhook_requests (
-		event, entity, entity_id, slug, request_body,
-		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers, created_at
+		endpoint_id, event, entity, entity_id, slug, request_body,
+		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers,
+		status, next_attempt_at, redelivery_of, created_at
 	)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);`
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);`
 
 	QueryUpdateRequest = `
 	UPDATE webhook_requests
-	SET response_status = ?, response_body = ?, attempt_count = ?, error_message = ?
+	SET response_status = ?, response_body = ?, attempt_count = ?, error_message = ?,
+		status = ?, next_attempt_at = ?
 	WHERE id = ?;`
 
+	// Queue queries
+	QueryClaimNextDueRequest = `
+	UPDATE webhook_requests
+	SET status = 'sending'
+	WHERE id = (
+		SELECT id FROM webhook_requests
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT 1
+	)
+	RETURNING id;`
+
+	QueryReleaseRequest = `
+	UPDATE webhook_requests
+	SET status = 'pending'
+	WHERE id = ? AND status = 'sending';`
+
+	QueryReleaseSendingRequests = `
+	UPDATE webhook_requests
+	SET status = 'pending'
+	WHERE status = 'sending';`
+
 	QueryGetAllRequests = `
-	SELECT id, event, entity, entity_id, slug, request_body,
-		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers, created_at
+	SELECT id, endpoint_id, event, entity, entity_id, slug, request_body,
+		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers,
+		status, next_attempt_at, redelivery_of, created_at
 	FROM webhook_requests
 	ORDER BY created_at DESC
 	LIMIT ? OFFSET ?;`
 
 	QueryGetRequestsBySearch = `
-	SELECT id, event, entity, entity_id, slug, request_body,
-		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers, created_at
+	SELECT id, endpoint_id, event, entity, entity_id, slug, request_body,
+		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers,
+		status, next_attempt_at, redelivery_of, created_at
 	FROM webhook_requests
 	WHERE event LIKE ? OR entity LIKE ?
 	ORDER BY created_at DESC
 	LIMIT ? OFFSET ?;`
 
 	QueryGetRequestByID = `
-	SELECT id, event, entity, entity_id, slug, request_body,
-		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers, created_at
+	SELECT id, endpoint_id, event, entity, entity_id, slug, request_body,
+		response_status, response_body, attempt_count, error_message, webhook_url, webhook_headers,
+		status, next_attempt_at, redelivery_of, created_at
 	FROM webhook_requests
 	WHERE id = ?;`
 
+	// A delivery's history is its first request and every redelivery
+	QueryGetRequestHistory = `
+	SELECT id, status, attempt_count, response_status, error_message, created_at
+	FROM webhook_requests
+	WHERE id = ? OR redelivery_of = ?
+	ORDER BY id ASC;`
+
+	// Dead requests that are the latest of their delivery, filtered by when
+	// the delivery was first sent
+	QueryGetReplayCandidates = `
+	SELECT r.id, r.endpoint_id, r.event, r.entity, r.entity_id, r.slug, r.request_body,
+		r.response_status, r.response_body, r.attempt_count, r.error_message, r.webhook_url, r.webhook_headers,
+		r.status, r.next_attempt_at, r.redelivery_of, r.created_at
+	FROM webhook_requests r
+	JOIN webhook_requests root ON root.id = COALESCE(r.redelivery_of, r.id)
+	WHERE r.status = 'dead'
+	AND (? IS NULL OR root.created_at >= ?)
+	AND (? IS NULL OR root.created_at <= ?)
+	AND NOT EXISTS (
+		SELECT 1 FROM webhook_requests later
+		WHERE COALESCE(later.redelivery_of, later.id) = COALESCE(r.redelivery_of, r.id)
+		AND later.id > r.id
+	)
+	ORDER BY r.id ASC;`
+
 	QueryCountRequests = `
 	SELECT COUNT(*) FROM webhook_requests;`
 
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/background"
+	"bloggo/internal/module/webhook/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	MaxRetryAttempts = 5
+	BaseDelaySeconds = 2
+	MaxDelaySeconds  = 60
+
+	// How often the queue is checked when no new delivery wakes it, this
+	// picks up retries as they become due
+	queuePollInterval = time.Second
+)
+
+// Signals the dispatcher that new deliveries were queued
+var queueWake = make(chan struct{}, 1)
+
+func wakeQueue() {
+	select {
+	case queueWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartDeliveryQueue sends queued webhook requests with the given number of
+// workers. Requests the previous run was sending when it stopped are queued
+// again, so nothing is lost across restarts. The workers stop once shutdown
+// begins and the rest stays queued.
+func StartDeliveryQueue(workers int) error {
+	service := GetGlobalWebhookService()
+	if err := service.repository.ReleaseSendingRequests(); err != nil {
+		return err
+	}
+
+	jobs := make(chan int64)
+	for range workers {
+		background.Go(func() {
+			for {
+				select {
+				case requestID := <-jobs:
+					service.attemptDelivery(requestID)
+				case <-background.Done():
+					return
+				}
+			}
+		})
+	}
+
+	background.Go(func() {
+		service.dispatchDueRequests(jobs)
+	})
+	return nil
+}
+
+// Hands due requests to the workers one at a time, so a request is only
+// claimed once a worker is free to send it
+func (service *WebhookService) dispatchDueRequests(jobs chan<- int64) {
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			requestID, err := service.repository.ClaimNextDueRequest(db.FormatTimestamp(time.Now()))
+			if err != nil {
+				log.Printf("Failed to read the webhook queue: %v", err)
+				break
+			}
+			if requestID == 0 {
+				break
+			}
+
+			select {
+			case jobs <- requestID:
+			case <-background.Done():
+				service.repository.ReleaseRequest(requestID)
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-queueWake:
+		case <-background.Done():
+			return
+		}
+	}
+}
+
+// Makes one attempt and either marks the request delivered, schedules the
+// next attempt with exponential backoff, or moves it to the dead letters
+// once attempts run out
+func (service *WebhookService) attemptDelivery(requestID int64) {
+	record, err := service.repository.GetRequestByID(int(requestID))
+	if err != nil || record == nil {
+		log.Printf("Failed to load webhook request %d: %v", requestID, err)
+		service.repository.ReleaseRequest(requestID)
+		return
+	}
+	attempt := record.AttemptCount + 1
+
+	// Deliveries can't be signed once their endpoint is gone
+	if record.EndpointID == nil || record.WebhookURL == nil {
+		service.bury(record, "Request has no endpoint")
+		return
+	}
+	endpoint, err := service.repository.GetEndpointByID(*record.EndpointID)
+	if err != nil {
+		log.Printf("Failed to load endpoint of webhook request %d: %v", requestID, err)
+		service.schedule(record, record.AttemptCount, record.ResponseStatus, record.ResponseBody, record.ErrorMessage)
+		return
+	}
+	if endpoint == nil {
+		service.bury(record, "Endpoint was deleted")
+		return
+	}
+	// Secrets are read at every attempt so retries after a rotation are
+	// signed with the current ones
+	secrets := activeSecrets(*endpoint)
+
+	headers := []models.WebhookHeader{}
+	if record.WebhookHeaders != nil {
+		json.Unmarshal([]byte(*record.WebhookHeaders), &headers)
+	}
+
+	status, body, err := service.sendHTTPRequest(*record.WebhookURL, headers, secrets, []byte(record.RequestBody), requestID)
+
+	// Success
+	if err == nil && status != nil && *status >= 200 && *status < 300 {
+		service.repository.UpdateRequest(requestID, status, body, attempt, nil, models.RequestStatusDelivered, nil)
+		return
+	}
+
+	var errMsg *string
+	if err != nil {
+		msg := err.Error()
+		errMsg = &msg
+	}
+
+	// If max attempts reached, stop
+	if attempt >= MaxRetryAttempts {
+		msg := fmt.Sprintf("Failed after %d attempts", attempt)
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		service.repository.UpdateRequest(requestID, status, body, attempt, &msg, models.RequestStatusDead, nil)
+		return
+	}
+
+	service.schedule(record, attempt, status, body, errMsg)
+}
+
+// Queues the next attempt with exponential backoff
+func (service *WebhookService) schedule(record *models.WebhookRequest, attempt int, status *int, body *string, errMsg *string) {
+	delay := time.Duration(math.Min(
+		float64(BaseDelaySeconds)*math.Pow(2, float64(max(attempt, 1)-1)),
+		float64(MaxDelaySeconds),
+	)) * time.Second
+	nextAttemptAt := db.FormatTimestamp(time.Now().Add(delay))
+	service.repository.UpdateRequest(int64(record.ID), status, body, attempt, errMsg, models.RequestStatusPending, &nextAttemptAt)
+}
+
+// Moves the request to the dead letters without another attempt
+func (service *WebhookService) bury(record *models.WebhookRequest, reason string) {
+	service.repository.UpdateRequest(int64(record.ID), record.ResponseStatus, record.ResponseBody, record.AttemptCount, &reason, models.RequestStatusDead, nil)
+}
@@ -1,12 +1,13 @@
 package webhook
 
 import (
+	"bloggo/internal/db"
 	"bloggo/internal/module/webhook/models"
 	"database/sql"
 )
 
 type WebhookRepository struct {
-	database *sql.DB
+	database db.Executor
 }
 
 func NewWebhookRepository(database *sql.DB) WebhookRepository {
@@ -15,46 +16,229 @@ func NewWebhookRepository(database *sql.DB) WebhookRepository {
 	}
 }
 
-// Config methods
-func (repository *WebhookRepository) GetConfig() (*models.WebhookConfig, error) {
-	var config models.WebhookConfig
-	err := repository.database.QueryRow(QueryGetConfig).Scan(
-		&config.ID,
-		&config.URL,
-		&config.UpdatedAt,
-	)
+// WithTx returns the repository running in the transaction
+func (repository *WebhookRepository) WithTx(tx *sql.Tx) WebhookRepository {
+	return WebhookRepository{
+		tx,
+	}
+}
+
+// Endpoint methods
+func (repository *WebhookRepository) GetAllEndpoints() ([]models.WebhookEndpoint, error) {
+	rows, err := repository.database.Query(QueryGetAllEndpoints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []models.WebhookEndpoint{}
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events, err := repository.getEndpointEvents(QueryGetAllEndpointEvents)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := repository.getEndpointHeaders(QueryGetAllEndpointHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range endpoints {
+		endpoints[i].Events = append([]string{}, events[endpoints[i].ID]...)
+		endpoints[i].Headers = append([]models.WebhookHeader{}, headers[endpoints[i].ID]...)
+	}
+	return endpoints, nil
+}
+
+func (repository *WebhookRepository) GetEndpointByID(id int64) (*models.WebhookEndpoint, error) {
+	endpoint, err := scanEndpoint(repository.database.QueryRow(QueryGetEndpointByID, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &config, nil
+
+	events, err := repository.getEndpointEvents(QueryGetEndpointEvents, id)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := repository.getEndpointHeaders(QueryGetEndpointHeaders, id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Events = append([]string{}, events[id]...)
+	endpoint.Headers = append([]models.WebhookHeader{}, headers[id]...)
+	return endpoint, nil
 }
 
-func (repository *WebhookRepository) UpsertConfig(url string) error {
-	statement, err := repository.database.Prepare(QueryUpsertConfig)
+func (repository *WebhookRepository) CreateEndpoint(model *models.RequestEndpointUpsert, enabled bool, secret string) (int64, error) {
+	tx, err := db.Begin(repository.database)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	_, err = statement.Exec(url)
-	return err
+	format, template := endpointFormat(model)
+	result, err := tx.Exec(QueryInsertEndpoint, model.Name, model.URL, enabled, format, template, secret)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertEndpointSubscriptions(tx, id, model); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
 }
 
-// Header methods
-func (repository *WebhookRepository) GetAllHeaders() ([]models.WebhookHeader, error) {
-	rows, err := repository.database.Query(QueryGetAllHeaders)
+// Replaces the endpoint with its events and headers, returns false when
+// the endpoint doesn't exist
+func (repository *WebhookRepository) UpdateEndpoint(id int64, model *models.RequestEndpointUpsert, enabled bool) (bool, error) {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	format, template := endpointFormat(model)
+	result, err := tx.Exec(QueryUpdateEndpoint, model.Name, model.URL, enabled, format, template, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if _, err := tx.Exec(QueryDeleteEndpointEvents, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(QueryDeleteEndpointHeaders, id); err != nil {
+		return false, err
+	}
+	if err := insertEndpointSubscriptions(tx, id, model); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Deletes the endpoint with its events and headers. Delivery records are
+// kept, they hold the URL and headers they were sent with.
+func (repository *WebhookRepository) DeleteEndpoint(id int64) (bool, error) {
+	tx, err := db.Begin(repository.database)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(QueryDeleteEndpointEvents, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(QueryDeleteEndpointHeaders, id); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(QueryDeleteEndpoint, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Replaces the secret, keeping the previous one until it expires. Returns
+// false when the endpoint doesn't exist.
+func (repository *WebhookRepository) RotateEndpointSecret(id int64, secret string, previousExpiresAt *string) (bool, error) {
+	result, err := repository.database.Exec(
+		QueryRotateEndpointSecret,
+		previousExpiresAt,
+		previousExpiresAt,
+		secret,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// The template is only kept for the template format
+func endpointFormat(model *models.RequestEndpointUpsert) (string, *string) {
+	if model.Format == "" {
+		return models.FormatJSON, nil
+	}
+	if model.Format != models.FormatTemplate {
+		return model.Format, nil
+	}
+	return model.Format, &model.Template
+}
+
+func insertEndpointSubscriptions(tx db.Executor, id int64, model *models.RequestEndpointUpsert) error {
+	for _, pattern := range model.Events {
+		if _, err := tx.Exec(QueryInsertEndpointEvent, id, pattern); err != nil {
+			return err
+		}
+	}
+	for _, header := range model.Headers {
+		if _, err := tx.Exec(QueryUpsertEndpointHeader, id, header.Key, header.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repository *WebhookRepository) getEndpointEvents(query string, args ...interface{}) (map[int64][]string, error) {
+	rows, err := repository.database.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	headers := []models.WebhookHeader{}
+	events := map[int64][]string{}
 	for rows.Next() {
+		var endpointID int64
+		var pattern string
+		if err := rows.Scan(&endpointID, &pattern); err != nil {
+			return nil, err
+		}
+		events[endpointID] = append(events[endpointID], pattern)
+	}
+	return events, rows.Err()
+}
+
+func (repository *WebhookRepository) getEndpointHeaders(query string, args ...interface{}) (map[int64][]models.WebhookHeader, error) {
+	rows, err := repository.database.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	headers := map[int64][]models.WebhookHeader{}
+	for rows.Next() {
+		var endpointID int64
 		var header models.WebhookHeader
 		err := rows.Scan(
+			&endpointID,
 			&header.ID,
 			&header.Key,
 			&header.Value,
@@ -64,55 +248,68 @@ func (repository *WebhookRepository) GetAllHeaders() ([]models.WebhookHeader, er
 		if err != nil {
 			return nil, err
 		}
-		headers = append(headers, header)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
+		headers[endpointID] = append(headers[endpointID], header)
 	}
+	return headers, rows.Err()
+}
 
-	return headers, nil
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (repository *WebhookRepository) BulkUpsertHeaders(items []models.RequestHeaderUpsert) error {
-	tx, err := repository.database.Begin()
+func scanEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	var secret sql.NullString
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.Name,
+		&endpoint.URL,
+		&endpoint.Enabled,
+		&endpoint.Format,
+		&endpoint.Template,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+		&secret,
+		&endpoint.PreviousSecret,
+		&endpoint.PreviousSecretExpiresAt,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer tx.Rollback()
+	endpoint.Secret = secret.String
+	return &endpoint, nil
+}
 
-	// Delete all existing headers first
-	_, err = tx.Exec(QueryDeleteAllHeaders)
-	if err != nil {
-		return err
-	}
+// Request methods
+func (repository *WebhookRepository) InsertRequest(req *models.WebhookRequest) (int64, error) {
+	return insertRequest(repository.database, req)
+}
 
-	// Insert new headers
-	statement, err := tx.Prepare(QueryUpsertHeader)
+// InsertRequests records the requests all together or none of them
+func (repository *WebhookRepository) InsertRequests(requests []*models.WebhookRequest) error {
+	tx, err := db.Begin(repository.database)
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	for _, item := range items {
-		_, err := statement.Exec(item.Key, item.Value)
-		if err != nil {
+	for _, req := range requests {
+		if _, err := insertRequest(tx, req); err != nil {
 			return err
 		}
 	}
-
 	return tx.Commit()
 }
 
-// Request methods
-func (repository *WebhookRepository) InsertRequest(req *models.WebhookRequest) (int64, error) {
-	statement, err := repository.database.Prepare(QueryInsertRequest)
+func insertRequest(executor db.Executor, req *models.WebhookRequest) (int64, error) {
+	statement, err := executor.Prepare(QueryInsertRequest)
 	if err != nil {
 		return 0, err
 	}
 	defer statement.Close()
 
 	result, err := statement.Exec(
+		req.EndpointID,
 		req.Event,
 		req.Entity,
 		req.EntityID,
@@ -124,6 +321,9 @@ func (repository *WebhookRepository) InsertRequest(req *models.WebhookRequest) (
 		req.ErrorMessage,
 		req.WebhookURL,
 		req.WebhookHeaders,
+		req.Status,
+		req.NextAttemptAt,
+		req.RedeliveryOf,
 	)
 	if err != nil {
 		return 0, err
@@ -132,14 +332,37 @@ func (repository *WebhookRepository) InsertRequest(req *models.WebhookRequest) (
 	return result.LastInsertId()
 }
 
-func (repository *WebhookRepository) UpdateRequest(id int64, responseStatus *int, responseBody *string, attemptCount int, errorMessage *string) error {
+func (repository *WebhookRepository) UpdateRequest(id int64, responseStatus *int, responseBody *string, attemptCount int, errorMessage *string, status string, nextAttemptAt *string) error {
 	statement, err := repository.database.Prepare(QueryUpdateRequest)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(responseStatus, responseBody, attemptCount, errorMessage, id)
+	_, err = statement.Exec(responseStatus, responseBody, attemptCount, errorMessage, status, nextAttemptAt, id)
+	return err
+}
+
+// Marks the oldest due request as sending and returns its id, 0 when no
+// request is due
+func (repository *WebhookRepository) ClaimNextDueRequest(now string) (int64, error) {
+	var id int64
+	err := repository.database.QueryRow(QueryClaimNextDueRequest, now).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Puts a claimed request back in the queue
+func (repository *WebhookRepository) ReleaseRequest(id int64) error {
+	_, err := repository.database.Exec(QueryReleaseRequest, id)
+	return err
+}
+
+// Puts requests claimed by an earlier run back in the queue
+func (repository *WebhookRepository) ReleaseSendingRequests() error {
+	_, err := repository.database.Exec(QueryReleaseSendingRequests)
 	return err
 }
 
@@ -168,6 +391,7 @@ func (repository *WebhookRepository) GetRequestByID(id int) (*models.WebhookRequ
 	var req models.WebhookRequest
 	err := repository.database.QueryRow(QueryGetRequestByID, id).Scan(
 		&req.ID,
+		&req.EndpointID,
 		&req.Event,
 		&req.Entity,
 		&req.EntityID,
@@ -179,6 +403,9 @@ func (repository *WebhookRepository) GetRequestByID(id int) (*models.WebhookRequ
 		&req.ErrorMessage,
 		&req.WebhookURL,
 		&req.WebhookHeaders,
+		&req.Status,
+		&req.NextAttemptAt,
+		&req.RedeliveryOf,
 		&req.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -190,6 +417,47 @@ func (repository *WebhookRepository) GetRequestByID(id int) (*models.WebhookRequ
 	return &req, nil
 }
 
+func (repository *WebhookRepository) GetRequestHistory(rootID int64) ([]models.WebhookRequestAttempt, error) {
+	rows, err := repository.database.Query(QueryGetRequestHistory, rootID, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.WebhookRequestAttempt{}
+	for rows.Next() {
+		var attempt models.WebhookRequestAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.Status,
+			&attempt.AttemptCount,
+			&attempt.ResponseStatus,
+			&attempt.ErrorMessage,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (repository *WebhookRepository) GetReplayCandidates(from, to *string) ([]models.WebhookRequest, error) {
+	rows, err := repository.database.Query(QueryGetReplayCandidates, from, from, to, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repository.scanRequests(rows)
+}
+
 func (repository *WebhookRepository) CountRequests() (int, error) {
 	var count int
 	err := repository.database.QueryRow(QueryCountRequests).Scan(&count)
@@ -210,6 +478,7 @@ func (repository *WebhookRepository) scanRequests(rows *sql.Rows) ([]models.Webh
 		var req models.WebhookRequest
 		err := rows.Scan(
 			&req.ID,
+			&req.EndpointID,
 			&req.Event,
 			&req.Entity,
 			&req.EntityID,
@@ -221,6 +490,9 @@ func (repository *WebhookRepository) scanRequests(rows *sql.Rows) ([]models.Webh
 			&req.ErrorMessage,
 			&req.WebhookURL,
 			&req.WebhookHeaders,
+			&req.Status,
+			&req.NextAttemptAt,
+			&req.RedeliveryOf,
 			&req.CreatedAt,
 		)
 		if err != nil {
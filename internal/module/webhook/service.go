@@ -1,80 +1,323 @@
 package webhook
 
 import (
+	"bloggo/internal/db"
+	"bloggo/internal/infrastructure/events"
+	"bloggo/internal/infrastructure/outbox"
 	"bloggo/internal/infrastructure/permissions"
 	"bloggo/internal/module/webhook/models"
 	"bloggo/internal/utils/apierrors"
+	"bloggo/internal/utils/auditcontext"
+	signature "bloggo/pkg/webhook"
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
-	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	MaxRetryAttempts = 5
-	BaseDelaySeconds = 2
-	MaxDelaySeconds  = 60
+	// How long a rotated secret keeps signing deliveries by default
+	DefaultSecretGracePeriod = 24 * time.Hour
+	secretPrefix             = "whsec_"
 )
 
 type WebhookService struct {
 	repository      WebhookRepository
 	permissionStore permissions.Store
+	outbox          *outbox.Outbox
 }
 
-func NewWebhookService(repository WebhookRepository, permissionStore permissions.Store) WebhookService {
+func NewWebhookService(repository WebhookRepository, permissionStore permissions.Store, outbox *outbox.Outbox) WebhookService {
 	return WebhookService{
 		repository,
 		permissionStore,
+		outbox,
 	}
 }
 
-// Config methods
-func (service *WebhookService) GetConfig(roleID int64) (*models.ResponseConfig, error) {
+// withTx returns the service queueing its requests in the transaction
+func (service *WebhookService) withTx(tx *outbox.Tx) WebhookService {
+	return WebhookService{
+		service.repository.WithTx(tx.Tx),
+		service.permissionStore,
+		service.outbox,
+	}
+}
+
+// Endpoint methods
+func (service *WebhookService) GetEndpoints(roleID int64) ([]models.WebhookEndpoint, error) {
+	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	return service.repository.GetAllEndpoints()
+}
+
+func (service *WebhookService) GetEndpointByID(id int64, roleID int64) (*models.WebhookEndpoint, error) {
+	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+
+	endpoint, err := service.repository.GetEndpointByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, apierrors.ErrNotFound
+	}
+	return endpoint, nil
+}
+
+// New endpoints are enabled unless the request says otherwise. The secret
+// is only returned here and when rotated.
+func (service *WebhookService) CreateEndpoint(model *models.RequestEndpointUpsert, roleID int64, userID int64, auditContext auditcontext.Context) (*models.ResponseEndpointCreated, error) {
 	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
 		return nil, apierrors.ErrForbidden
 	}
 
-	config, err := service.repository.GetConfig()
+	if err := validateTemplate(model); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
 	if err != nil {
 		return nil, err
 	}
 
-	if config == nil {
-		return &models.ResponseConfig{URL: "", UpdatedAt: ""}, nil
+	var id int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		var err error
+		id, err = repository.CreateEndpoint(model, model.Enabled == nil || *model.Enabled, secret)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.WebhookEndpointCreated{
+			ActorID:    userID,
+			Context:    auditContext,
+			EndpointID: id,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.ResponseConfig{
-		URL:       config.URL,
-		UpdatedAt: config.UpdatedAt,
+	return &models.ResponseEndpointCreated{
+		ID:     id,
+		Secret: secret,
 	}, nil
 }
 
-func (service *WebhookService) UpdateConfig(url string, roleID int64) error {
+// Replaces the endpoint's secret. Deliveries are signed with both secrets
+// until the grace period ends, so receivers can switch without missing
+// events.
+func (service *WebhookService) RotateSecret(id int64, model *models.RequestRotateSecret, roleID int64, userID int64, auditContext auditcontext.Context) (*models.ResponseSecretRotated, error) {
 	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
-		return apierrors.ErrForbidden
+		return nil, apierrors.ErrForbidden
 	}
 
-	return service.repository.UpsertConfig(url)
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	gracePeriod := DefaultSecretGracePeriod
+	if model.GracePeriod != nil {
+		gracePeriod = time.Duration(*model.GracePeriod) * time.Second
+	}
+
+	var previousExpiresAt *string
+	if gracePeriod > 0 {
+		formatted := db.FormatTimestamp(time.Now().Add(gracePeriod))
+		previousExpiresAt = &formatted
+	}
+
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		found, err := repository.RotateEndpointSecret(id, secret, previousExpiresAt)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return apierrors.ErrNotFound
+		}
+
+		return tx.Publish(events.WebhookSecretRotated{
+			ActorID:    userID,
+			Context:    auditContext,
+			EndpointID: id,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseSecretRotated{
+		Secret:                  secret,
+		PreviousSecretExpiresAt: previousExpiresAt,
+	}, nil
+}
+
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(bytes), nil
 }
 
-// Header methods
-func (service *WebhookService) GetHeaders(roleID int64) ([]models.WebhookHeader, error) {
+// Secrets signing deliveries, the previous one only until it expires
+func activeSecrets(endpoint models.WebhookEndpoint) []string {
+	secrets := []string{endpoint.Secret}
+	if endpoint.PreviousSecret == nil || endpoint.PreviousSecretExpiresAt == nil {
+		return secrets
+	}
+
+	expiresAt, err := db.ParseTimestamp(*endpoint.PreviousSecretExpiresAt)
+	if err == nil && time.Now().Before(expiresAt) {
+		secrets = append(secrets, *endpoint.PreviousSecret)
+	}
+	return secrets
+}
+
+func (service *WebhookService) UpdateEndpoint(id int64, model *models.RequestEndpointUpsert, roleID int64, userID int64, auditContext auditcontext.Context) error {
 	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
-		return nil, apierrors.ErrForbidden
+		return apierrors.ErrForbidden
 	}
 
-	return service.repository.GetAllHeaders()
+	if err := validateTemplate(model); err != nil {
+		return err
+	}
+
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		before, err := repository.GetEndpointByID(id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return apierrors.ErrNotFound
+		}
+
+		found, err := repository.UpdateEndpoint(id, model, model.Enabled == nil || *model.Enabled)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return apierrors.ErrNotFound
+		}
+
+		after, err := repository.GetEndpointByID(id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.WebhookEndpointUpdated{
+			ActorID:    userID,
+			Context:    auditContext,
+			EndpointID: id,
+			Before:     endpointSnapshot(before),
+			After:      endpointSnapshot(after),
+		})
+	})
 }
 
-func (service *WebhookService) BulkUpsertHeaders(items []models.RequestHeaderUpsert, roleID int64) error {
+// Templates are parsed when saved so a typo doesn't wait for the next
+// event to surface
+func validateTemplate(model *models.RequestEndpointUpsert) error {
+	if model.Format != models.FormatTemplate {
+		return nil
+	}
+	if _, err := parseTemplate(model.Template); err != nil {
+		return apierrors.ErrBadRequest
+	}
+	return nil
+}
+
+func (service *WebhookService) DeleteEndpoint(id int64, roleID int64, userID int64, auditContext auditcontext.Context) error {
 	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
 		return apierrors.ErrForbidden
 	}
 
-	return service.repository.BulkUpsertHeaders(items)
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		repository := service.repository.WithTx(tx.Tx)
+		endpoint, err := repository.GetEndpointByID(id)
+		if err != nil {
+			return err
+		}
+		if endpoint == nil {
+			return apierrors.ErrNotFound
+		}
+
+		found, err := repository.DeleteEndpoint(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return apierrors.ErrNotFound
+		}
+
+		return tx.Publish(events.WebhookEndpointDeleted{
+			ActorID:    userID,
+			Context:    auditContext,
+			EndpointID: id,
+			Before:     endpointSnapshot(endpoint),
+		})
+	})
+}
+
+// The audited fields of an endpoint. Secrets and header values are left
+// out, they may hold credentials of the receiver.
+func endpointSnapshot(endpoint *models.WebhookEndpoint) map[string]interface{} {
+	headers := []string{}
+	for _, header := range endpoint.Headers {
+		headers = append(headers, header.Key)
+	}
+
+	return map[string]interface{}{
+		"name":     endpoint.Name,
+		"url":      endpoint.URL,
+		"enabled":  endpoint.Enabled,
+		"format":   endpoint.Format,
+		"template": endpoint.Template,
+		"events":   endpoint.Events,
+		"headers":  headers,
+	}
+}
+
+// Reports whether the event matches one of the patterns. Patterns compare
+// dot separated names, where * matches any single name, and a lone *
+// matches every event.
+func matchesEvent(patterns []string, event string) bool {
+	eventParts := strings.Split(event, ".")
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+
+		patternParts := strings.Split(pattern, ".")
+		if len(patternParts) != len(eventParts) {
+			continue
+		}
+		matched := true
+		for i, part := range patternParts {
+			if part != "*" && part != eventParts[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
 }
 
 // Request methods
@@ -113,109 +356,247 @@ func (service *WebhookService) GetRequestByID(id int, roleID int64) (*models.Web
 		return nil, apierrors.ErrForbidden
 	}
 
-	return service.repository.GetRequestByID(id)
+	req, err := service.repository.GetRequestByID(id)
+	if err != nil || req == nil {
+		return req, err
+	}
+
+	rootID := int64(req.ID)
+	if req.RedeliveryOf != nil {
+		rootID = *req.RedeliveryOf
+	}
+	req.History, err = service.repository.GetRequestHistory(rootID)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
-// Fire webhook
-func (service *WebhookService) FireWebhook(payload models.WebhookPayload) {
-	// Get config
-	config, err := service.repository.GetConfig()
-	if err != nil || config == nil || config.URL == "" {
-		// No config, silently skip
-		return
+// Queues the stored body of a finished request again, to the endpoint's
+// current URL and headers
+func (service *WebhookService) RedeliverRequest(id int, roleID int64, userID int64, auditContext auditcontext.Context) (*models.ResponseRedelivered, error) {
+	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
+		return nil, apierrors.ErrForbidden
 	}
 
-	// Get headers
-	headers, err := service.repository.GetAllHeaders()
+	original, err := service.repository.GetRequestByID(id)
 	if err != nil {
-		headers = []models.WebhookHeader{}
+		return nil, err
+	}
+	if original == nil {
+		return nil, apierrors.ErrNotFound
+	}
+	// Still in the queue, it would be sent twice
+	if original.Status == models.RequestStatusPending || original.Status == models.RequestStatusSending {
+		return nil, apierrors.ErrConflict
 	}
 
-	// Serialize payload
-	payloadBytes, err := json.Marshal(payload)
+	var redeliveryID int64
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		service := service.withTx(tx)
+		var err error
+		redeliveryID, err = service.redeliver(original)
+		if err != nil {
+			return err
+		}
+
+		return tx.Publish(events.WebhookRequestRedelivered{
+			ActorID:   userID,
+			Context:   auditContext,
+			RequestID: redeliveryID,
+		})
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
+	wakeQueue()
 
-	// Serialize headers for storage
-	headersJSON, err := json.Marshal(headers)
-	if err != nil {
-		headersJSON = []byte("[]")
+	return &models.ResponseRedelivered{
+		ID: redeliveryID,
+	}, nil
+}
+
+// Queues dead deliveries again, once per delivery however many times it
+// was redelivered before
+func (service *WebhookService) ReplayFailedRequests(model *models.RequestReplayFailed, roleID int64, userID int64, auditContext auditcontext.Context) (*models.ResponseReplayed, error) {
+	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
+		return nil, apierrors.ErrForbidden
+	}
+	if model.From == nil && model.To == nil && model.Event == "" {
+		return nil, apierrors.ErrBadRequest
+	}
+	if model.From != nil && model.To != nil && model.To.Before(*model.From) {
+		return nil, apierrors.ErrBadRequest
+	}
+
+	var from, to *string
+	if model.From != nil {
+		formatted := db.FormatTimestamp(*model.From)
+		from = &formatted
+	}
+	if model.To != nil {
+		formatted := db.FormatTimestamp(*model.To)
+		to = &formatted
 	}
-	headersStr := string(headersJSON)
 
-	// Create initial request record
-	requestRecord := &models.WebhookRequest{
-		Event:          payload.Event,
-		Entity:         payload.Entity,
-		EntityID:       payload.ID,
-		Slug:           payload.Slug,
-		RequestBody:    string(payloadBytes),
-		AttemptCount:   0,
-		WebhookURL:     &config.URL,
-		WebhookHeaders: &headersStr,
+	candidates, err := service.repository.GetReplayCandidates(from, to)
+	if err != nil {
+		return nil, err
 	}
 
-	requestID, err := service.repository.InsertRequest(requestRecord)
+	result := &models.ResponseReplayed{}
+	err = service.outbox.Transaction(func(tx *outbox.Tx) error {
+		service := service.withTx(tx)
+		for i := range candidates {
+			if model.Event != "" && !matchesEvent([]string{model.Event}, candidates[i].Event) {
+				continue
+			}
+
+			_, err := service.redeliver(&candidates[i])
+			if errors.Is(err, apierrors.ErrPreconditionFailed) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			result.Replayed++
+		}
+
+		return tx.Publish(events.WebhookRequestsReplayed{
+			ActorID: userID,
+			Context: auditContext,
+		})
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	// Fire in background with retry
-	go service.sendWithRetry(config.URL, headers, payloadBytes, requestID)
+	if result.Replayed > 0 {
+		wakeQueue()
+	}
+	return result, nil
 }
 
-func (service *WebhookService) sendWithRetry(url string, headers []models.WebhookHeader, payload []byte, requestID int64) {
-	var lastErr error
-	var lastStatus *int
-	var lastBody *string
+func (service *WebhookService) redeliver(original *models.WebhookRequest) (int64, error) {
+	if original.EndpointID == nil {
+		return 0, apierrors.ErrPreconditionFailed
+	}
+	endpoint, err := service.repository.GetEndpointByID(*original.EndpointID)
+	if err != nil {
+		return 0, err
+	}
+	if endpoint == nil {
+		return 0, apierrors.ErrPreconditionFailed
+	}
 
-	for attempt := 1; attempt <= MaxRetryAttempts; attempt++ {
-		// Send request
-		status, body, err := service.sendHTTPRequest(url, headers, payload)
+	// Redeliveries of redeliveries still point at the first request, so
+	// the history stays in one place
+	rootID := int64(original.ID)
+	if original.RedeliveryOf != nil {
+		rootID = *original.RedeliveryOf
+	}
 
-		lastStatus = status
-		lastBody = body
-		lastErr = err
+	// The body was rendered when the event fired, so it keeps the content
+	// type it was rendered with even if the endpoint's format changed since
+	return service.queueRequest(*endpoint, &models.WebhookRequest{
+		Event:        original.Event,
+		Entity:       original.Entity,
+		EntityID:     original.EntityID,
+		Slug:         original.Slug,
+		RequestBody:  original.RequestBody,
+		RedeliveryOf: &rootID,
+	}, recordedContentType(original))
+}
 
-		// Success
-		if err == nil && status != nil && *status >= 200 && *status < 300 {
-			service.repository.UpdateRequest(requestID, status, body, attempt, nil)
-			return
+// Fire webhook to every enabled endpoint subscribed to the event. Each
+// delivery is rendered in its endpoint's format and sent by the delivery
+// queue. The requests are queued together, so an event that failed to queue
+// can be fired again without duplicates.
+func (service *WebhookService) FireWebhook(payload models.WebhookPayload) error {
+	endpoints, err := service.repository.GetAllEndpoints()
+	if err != nil {
+		return err
+	}
+
+	records := []*models.WebhookRequest{}
+	queued := false
+	for _, endpoint := range endpoints {
+		if !endpoint.Enabled || !matchesEvent(endpoint.Events, payload.Event) {
+			continue
 		}
 
-		// Update with current attempt
-		var errMsg *string
+		record := &models.WebhookRequest{
+			Event:    payload.Event,
+			Entity:   payload.Entity,
+			EntityID: payload.ID,
+			Slug:     payload.Slug,
+		}
+		body, contentType, err := renderPayload(endpoint, payload)
 		if err != nil {
-			msg := err.Error()
-			errMsg = &msg
+			// Recorded as dead with the plain payload, so a template that
+			// fails on this event shows up in the request log
+			body, _ = json.Marshal(payload)
+			contentType = contentTypeJSON
+			msg := "Failed to render payload: " + err.Error()
+			record.ErrorMessage = &msg
 		}
-		service.repository.UpdateRequest(requestID, status, body, attempt, errMsg)
+		record.RequestBody = string(body)
 
-		// If max attempts reached, stop
-		if attempt >= MaxRetryAttempts {
-			break
+		prepareRequest(endpoint, record, contentType)
+		if record.Status == models.RequestStatusPending {
+			queued = true
 		}
+		records = append(records, record)
+	}
 
-		// Calculate exponential backoff delay
-		delay := time.Duration(math.Min(
-			float64(BaseDelaySeconds)*math.Pow(2, float64(attempt-1)),
-			float64(MaxDelaySeconds),
-		)) * time.Second
+	if len(records) == 0 {
+		return nil
+	}
+	if err := service.repository.InsertRequests(records); err != nil {
+		return err
+	}
 
-		time.Sleep(delay)
+	if queued {
+		wakeQueue()
 	}
+	return nil
+}
 
-	// Final update with last error
-	var finalErrMsg *string
-	if lastErr != nil {
-		msg := fmt.Sprintf("Failed after %d attempts: %s", MaxRetryAttempts, lastErr.Error())
-		finalErrMsg = &msg
+// Records the request for the endpoint, due right away
+func (service *WebhookService) queueRequest(endpoint models.WebhookEndpoint, record *models.WebhookRequest, contentType string) (int64, error) {
+	prepareRequest(endpoint, record, contentType)
+	return service.repository.InsertRequest(record)
+}
+
+// Fills in the endpoint of the request. Attempts send the URL and headers
+// recorded here. Requests that already carry an error go straight to the
+// dead letters.
+func prepareRequest(endpoint models.WebhookEndpoint, record *models.WebhookRequest, contentType string) {
+	// Serialize headers for storage
+	headersJSON, err := json.Marshal(requestHeaders(endpoint, contentType))
+	if err != nil {
+		headersJSON = []byte("[]")
+	}
+	headersStr := string(headersJSON)
+
+	record.EndpointID = &endpoint.ID
+	record.WebhookURL = &endpoint.URL
+	record.WebhookHeaders = &headersStr
+	record.AttemptCount = 0
+	if record.ErrorMessage != nil {
+		record.Status = models.RequestStatusDead
+	} else {
+		now := db.FormatTimestamp(time.Now())
+		record.Status = models.RequestStatusPending
+		record.NextAttemptAt = &now
 	}
-	service.repository.UpdateRequest(requestID, lastStatus, lastBody, MaxRetryAttempts, finalErrMsg)
 }
 
-func (service *WebhookService) sendHTTPRequest(url string, headers []models.WebhookHeader, payload []byte) (*int, *string, error) {
+// Every attempt is signed with a fresh timestamp, so receivers can reject
+// old deliveries replayed by someone else. Custom headers can't replace the
+// signature.
+func (service *WebhookService) sendHTTPRequest(url string, headers []models.WebhookHeader, secrets []string, payload []byte, requestID int64) (*int, *string, error) {
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
@@ -229,6 +610,8 @@ func (service *WebhookService) sendHTTPRequest(url string, headers []models.Webh
 	for _, header := range headers {
 		req.Header.Set(header.Key, header.Value)
 	}
+	req.Header.Set(signature.DeliveryHeader, strconv.FormatInt(requestID, 10))
+	req.Header.Set(signature.SignatureHeader, signature.SignatureHeaderValue(time.Now(), payload, secrets...))
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -244,7 +627,7 @@ func (service *WebhookService) sendHTTPRequest(url string, headers []models.Webh
 }
 
 // Manual fire
-func (service *WebhookService) ManualFire(roleID int64) error {
+func (service *WebhookService) ManualFire(roleID int64, userID int64, auditContext auditcontext.Context) error {
 	if !service.permissionStore.HasPermission(roleID, "webhook:manage") {
 		return apierrors.ErrForbidden
 	}
@@ -259,6 +642,15 @@ func (service *WebhookService) ManualFire(roleID int64) error {
 		Data:      nil,
 	}
 
-	service.FireWebhook(payload)
-	return nil
+	return service.outbox.Transaction(func(tx *outbox.Tx) error {
+		service := service.withTx(tx)
+		if err := service.FireWebhook(payload); err != nil {
+			return err
+		}
+
+		return tx.Publish(events.WebhookFired{
+			ActorID: userID,
+			Context: auditContext,
+		})
+	})
 }
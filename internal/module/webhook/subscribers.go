@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"bloggo/internal/infrastructure/events"
+)
+
+// SubscribeEvents fires webhooks for domain events. Deliveries that could
+// not be queued are fired again by the outbox relay.
+func SubscribeEvents(bus *events.Bus) {
+	// Posts
+	events.Subscribe(bus, "webhook", func(event events.PostDeleted) error {
+		if event.Slug == nil {
+			return nil
+		}
+		return TriggerPostDeleted(event.PostID, *event.Slug, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.PostPublished) error {
+		data := map[string]interface{}{
+			"versionId": event.VersionID,
+			"slug":      event.Slug,
+		}
+		if event.OldCategory != nil {
+			data["oldCategory"] = *event.OldCategory
+		}
+		if event.NewCategory != nil {
+			data["newCategory"] = *event.NewCategory
+		}
+		return TriggerPostUpdated(event.PostID, event.Slug, event.OldSlug, data, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.PostTagsChanged) error {
+		// Receivers only know published posts
+		if event.PublishedSlug == nil {
+			return nil
+		}
+		return TriggerPostUpdated(event.PostID, *event.PublishedSlug, nil, map[string]interface{}{
+			"addedTags":   event.AddedTags,
+			"removedTags": event.RemovedTags,
+		}, event.OccurredAt)
+	})
+
+	// Review workflow
+	events.Subscribe(bus, "webhook", func(event events.VersionSubmitted) error {
+		return TriggerVersionSubmitted(event.PostID, event.VersionID, event.Slug, event.ActorID, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.VersionApproved) error {
+		return TriggerVersionApproved(event.PostID, event.VersionID, event.Slug, event.ActorID, event.Note, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.VersionRejected) error {
+		return TriggerVersionRejected(event.PostID, event.VersionID, event.Slug, event.ActorID, event.Note, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.RemovalRequestCreated) error {
+		return TriggerRemovalRequestCreated(event.RequestID, event.PostVersionID, event.ActorID, event.Note, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.RemovalRequestApproved) error {
+		return TriggerRemovalRequestApproved(event.RequestID, event.PostVersionID, event.PostID, event.ActorID, event.Note, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.RemovalRequestRejected) error {
+		return TriggerRemovalRequestRejected(event.RequestID, event.PostVersionID, event.ActorID, event.Note, event.OccurredAt)
+	})
+
+	// Categories
+	events.Subscribe(bus, "webhook", func(event events.CategoryCreated) error {
+		return TriggerCategoryCreated(event.CategoryID, event.Slug, map[string]interface{}{
+			"name":        event.Name,
+			"slug":        event.Slug,
+			"spot":        event.Spot,
+			"description": event.Description,
+		}, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.CategoryUpdated) error {
+		return TriggerCategoryUpdated(event.CategoryID, event.Slug, event.OldSlug, map[string]interface{}{
+			"name":        event.Name,
+			"slug":        event.Slug,
+			"spot":        event.Spot,
+			"description": event.Description,
+		}, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.CategoryDeleted) error {
+		return TriggerCategoryDeleted(event.CategoryID, event.Slug, event.OccurredAt)
+	})
+
+	// Tags
+	events.Subscribe(bus, "webhook", func(event events.TagCreated) error {
+		return TriggerTagCreated(event.TagID, event.Slug, map[string]interface{}{"name": event.Name, "slug": event.Slug}, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.TagUpdated) error {
+		return TriggerTagUpdated(event.TagID, event.Slug, event.OldSlug, map[string]interface{}{"name": event.Name, "slug": event.Slug}, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.TagDeleted) error {
+		return TriggerTagDeleted(event.TagID, event.Slug, event.OccurredAt)
+	})
+
+	// Authors
+	events.Subscribe(bus, "webhook", func(event events.UserCreated) error {
+		return TriggerAuthorCreated(event.UserID, map[string]interface{}{"name": event.Name, "email": event.Email}, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.UserUpdated) error {
+		return TriggerAuthorUpdated(event.UserID, event.Changes, event.OccurredAt)
+	})
+	events.Subscribe(bus, "webhook", func(event events.UserDeleted) error {
+		return TriggerAuthorDeleted(event.UserID, event.OccurredAt)
+	})
+	// The client's address and user agent stay out, endpoints are third parties
+	events.Subscribe(bus, "webhook", func(event events.UserLoggedIn) error {
+		return TriggerAuthorLogin(event.UserID, map[string]interface{}{
+			"actorId": event.UserID,
+			"name":    event.Name,
+			"role":    event.Role,
+		}, event.OccurredAt)
+	})
+
+	// Settings
+	events.Subscribe(bus, "webhook", func(event events.KeyValuesUpdated) error {
+		return TriggerKeyValueUpdated(event.Values, event.OccurredAt)
+	})
+}
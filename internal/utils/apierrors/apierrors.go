@@ -157,6 +157,8 @@ var (
 	ErrEncryptionError           = errors.New("encyption failed")
 	ErrCategoryHasPublishedBlogs = errors.New("category has published blogs")
 	ErrCannotLowerOwnRole        = errors.New("admins cannot lower their own role")
+	ErrTwoFactorRequired         = errors.New("two-factor authentication is required for this role")
+	ErrInvalidTwoFactorCode      = errors.New("invalid two-factor authentication code")
 	ErrAIFeaturesDisabled        = errors.New("generative ai features are currently unavailable, please contact your administrator to enable this feature")
 )
 
@@ -179,6 +181,8 @@ var DefaultErrorMapping = &HTTPErrorMapping{
 	ErrCannotLowerOwnRole:        {ErrCannotLowerOwnRole.Error(), http.StatusForbidden},
 	ErrCategoryHasPublishedBlogs: {ErrCategoryHasPublishedBlogs.Error(), http.StatusConflict},
 	ErrAIFeaturesDisabled:        {ErrAIFeaturesDisabled.Error(), http.StatusPreconditionFailed},
+	ErrTwoFactorRequired:         {ErrTwoFactorRequired.Error(), http.StatusForbidden},
+	ErrInvalidTwoFactorCode:      {ErrInvalidTwoFactorCode.Error(), http.StatusUnauthorized},
 	// Standard SQL errors
 	sql.ErrNoRows: {ErrNotFound.Error(), http.StatusNotFound},
 }
@@ -1,37 +1,65 @@
 package audit
 
 import (
+	"bloggo/internal/config"
+	"bloggo/internal/infrastructure/auditchain"
+	"bloggo/internal/utils/auditcontext"
 	"database/sql"
+	"encoding/json"
 	"log"
 )
 
 type AuditLogger struct {
-	db *sql.DB
+	chain *auditchain.Chain
 }
 
 func NewAuditLogger(db *sql.DB) *AuditLogger {
 	return &AuditLogger{
-		db: db,
+		chain: auditchain.New(db, config.Get().AuditCheckpointSecret),
 	}
 }
 
-func (a *AuditLogger) LogAction(userID *int64, entity string, entityID int64, action string) {
-	query := `INSERT INTO audit_logs (user_id, entity_type, entity_id, action) VALUES (?, ?, ?, ?)`
+func (a *AuditLogger) LogAction(ctx auditcontext.Context, userID *int64, entity string, entityID int64, action string, metadata map[string]interface{}) {
+	var metadataJSON *string
+	if metadata != nil {
+		if jsonBytes, err := json.Marshal(metadata); err == nil {
+			jsonStr := string(jsonBytes)
+			metadataJSON = &jsonStr
+		}
+	}
 
-	_, err := a.db.Exec(query, userID, entity, entityID, action)
+	err := a.chain.Append(auditchain.Record{
+		UserID:     userID,
+		EntityType: entity,
+		EntityID:   entityID,
+		Action:     action,
+		Metadata:   metadataJSON,
+		IPAddress:  nullIfEmpty(ctx.IP),
+		UserAgent:  nullIfEmpty(ctx.UserAgent),
+		RequestID:  nullIfEmpty(ctx.RequestID),
+	})
 	if err != nil {
 		log.Printf("Failed to log audit action: %v", err)
 	}
 }
 
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
 var GlobalAuditLogger *AuditLogger
 
 func InitializeAuditLogger(db *sql.DB) {
 	GlobalAuditLogger = NewAuditLogger(db)
 }
 
-func LogAction(userID *int64, entity string, entityID int64, action string) {
+// LogAction records the action with where the request came from, metadata
+// may be nil
+func LogAction(ctx auditcontext.Context, userID *int64, entity string, entityID int64, action string, metadata map[string]interface{}) {
 	if GlobalAuditLogger != nil {
-		GlobalAuditLogger.LogAction(userID, entity, entityID, action)
+		GlobalAuditLogger.LogAction(ctx, userID, entity, entityID, action, metadata)
 	}
 }
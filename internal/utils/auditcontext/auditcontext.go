@@ -0,0 +1,38 @@
+// Package auditcontext carries where a request came from, so audit entries
+// written on its behalf record the client address, user agent and request id.
+package auditcontext
+
+import (
+	"bloggo/internal/utils/handlers"
+	"context"
+	"net/http"
+)
+
+// Context of an audited action. It is empty for actions the system takes on
+// its own. OutboxID is set when the entry is written for an outbox event, so
+// the event delivered again is not logged twice.
+type Context struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	OutboxID  int64  `json:"-"`
+}
+
+type contextKey struct{}
+
+// With stores the audit context in the request context
+func With(ctx context.Context, auditContext Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, auditContext)
+}
+
+// FromRequest returns the audit context the middleware stored, or one built
+// from the request without a request id when the middleware didn't run
+func FromRequest(request *http.Request) Context {
+	if auditContext, ok := request.Context().Value(contextKey{}).(Context); ok {
+		return auditContext
+	}
+	return Context{
+		IP:        handlers.GetClientIP(request),
+		UserAgent: request.UserAgent(),
+	}
+}
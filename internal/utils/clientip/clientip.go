@@ -0,0 +1,122 @@
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Forwarding headers a proxy can be configured to set
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// Resolver finds the address of the client behind trusted reverse proxies.
+// Only the one forwarding header the proxies set is read, and only when
+// the connection comes from a trusted proxy. It's walked from the nearest
+// hop outwards until an untrusted address is found, so clients can't spoof
+// their address by sending the header themselves, nor by sending another
+// forwarding header the proxy passes through untouched.
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// NewResolver parses the trusted proxy CIDRs. Invalid entries are skipped,
+// the configuration is validated on load.
+func NewResolver(trustedProxies []string, header string) *Resolver {
+	resolver := &Resolver{header: header}
+	for _, cidr := range trustedProxies {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			resolver.trusted = append(resolver.trusted, network)
+		}
+	}
+	return resolver
+}
+
+func (resolver *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range resolver.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address of the request
+func (resolver *Resolver) Resolve(request *http.Request) string {
+	remote := parseIP(request.RemoteAddr)
+	if remote == nil {
+		return request.RemoteAddr
+	}
+	if !resolver.isTrusted(remote) {
+		return remote.String()
+	}
+
+	values := request.Header.Values(resolver.header)
+	var hops []string
+	switch resolver.header {
+	case HeaderForwarded:
+		hops = forwardedFor(values)
+	default:
+		hops = splitList(values)
+	}
+
+	return resolver.walk(remote, hops).String()
+}
+
+// Walks the hops from the right and returns the first untrusted address.
+// An unparsable hop, such as an obfuscated identifier, ends the walk at
+// the last address known to be real.
+func (resolver *Resolver) walk(remote net.IP, hops []string) net.IP {
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !resolver.isTrusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// Extracts the for= parameters of RFC 7239 Forwarded headers
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				hops = append(hops, strings.Trim(value, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// Parses an address with or without a port, including bracketed IPv6
+func parseIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	return net.ParseIP(value)
+}
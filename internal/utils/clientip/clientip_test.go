@@ -0,0 +1,138 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "fd00::/8"}
+
+	tests := []struct {
+		name    string
+		header  string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:   "direct client without a proxy",
+			header: HeaderXForwardedFor,
+			remote: "203.0.113.7:4000",
+			want:   "203.0.113.7",
+		},
+		{
+			name:    "untrusted peer spoofing the header",
+			header:  HeaderXForwardedFor,
+			remote:  "203.0.113.7:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "trusted proxy",
+			header:  HeaderXForwardedFor,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "trusted proxy without the header",
+			header:  HeaderXForwardedFor,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "chain of trusted proxies",
+			header:  HeaderXForwardedFor,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1, 10.0.0.5, 10.0.0.3"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "client prepending a spoofed hop",
+			header:  HeaderXForwardedFor,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:   "hops split across header lines",
+			header: HeaderXForwardedFor,
+			remote: "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {
+				"1.1.1.1",
+				"198.51.100.1, 10.0.0.3",
+			}},
+			want: "198.51.100.1",
+		},
+		{
+			name:    "unparsable hop stops at the last real address",
+			header:  HeaderXForwardedFor,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1, unknown, 10.0.0.3"}},
+			want:    "10.0.0.3",
+		},
+		{
+			name:   "other forwarding headers are ignored",
+			header: HeaderXForwardedFor,
+			remote: "10.0.0.2:4000",
+			headers: map[string][]string{
+				"X-Real-IP": {"1.1.1.1"},
+				"Forwarded": {"for=1.1.1.1"},
+			},
+			want: "10.0.0.2",
+		},
+		{
+			name:    "X-Real-IP",
+			header:  HeaderXRealIP,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Real-IP": {"198.51.100.1"}, "X-Forwarded-For": {"1.1.1.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "Forwarded with ports and quoted IPv6",
+			header:  HeaderForwarded,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"Forwarded": {`for="[2001:db8::1]:4711";proto=https, for=10.0.0.3:80`}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "Forwarded with an obfuscated hop",
+			header:  HeaderForwarded,
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"Forwarded": {"for=198.51.100.1, for=_hidden"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "trusted IPv6 proxy",
+			header:  HeaderXForwardedFor,
+			remote:  "[fd00::2]:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:    "198.51.100.1",
+		},
+		{
+			name:   "unparsable remote address",
+			header: HeaderXForwardedFor,
+			remote: "pipe",
+			want:   "pipe",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.RemoteAddr = test.remote
+			for key, values := range test.headers {
+				for _, value := range values {
+					request.Header.Add(key, value)
+				}
+			}
+
+			resolver := NewResolver(trusted, test.header)
+			if got := resolver.Resolve(request); got != test.want {
+				t.Errorf("Resolve() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package cryptography
 
 import (
-	"encoding/base64"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,11 +13,15 @@ func GenerateUniqueId() string {
 }
 
 // Creates a JWT with the given expiry and type.
+// The token version (ver) lets issued tokens be invalidated before expiry.
+// The key id (kid) header tells which keyring key verifies the token.
 func GenerateJWT(
 	subject string,
 	userId int64,
 	roleId int64,
-	secret string,
+	tokenVersion int64,
+	keyId string,
+	key []byte,
 	duration int,
 ) (string, error) {
 
@@ -26,14 +29,12 @@ func GenerateJWT(
 		"sub": subject,
 		"uid": userId,
 		"rid": roleId,
+		"ver": tokenVersion,
 		"exp": time.Now().Add(time.Duration(duration) * time.Second).Unix(),
 		"iat": time.Now().Unix(),
 		"iss": "bloggo",
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	key, err := base64.RawURLEncoding.DecodeString(secret)
-	if err != nil {
-		return "", err
-	}
+	token.Header["kid"] = keyId
 	return token.SignedString(key)
 }
@@ -0,0 +1,72 @@
+package cryptography
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA1 secret of RFC 6238 appendix B
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes, the last 6 digits are the 6 digit code
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	for _, test := range tests {
+		step := TOTPStep(time.Unix(test.unix, 0))
+		if code := generateTOTPCode(key, step); code != test.code {
+			t.Errorf("code at %d = %s, want %s", test.unix, code, test.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	current := TOTPStep(time.Now())
+	codeAt := func(step int64) string {
+		return generateTOTPCode(key, step)
+	}
+
+	tests := []struct {
+		name         string
+		secret       string
+		code         string
+		lastUsedStep int64
+		valid        bool
+	}{
+		{"current step", rfcSecret, codeAt(current), 0, true},
+		{"previous step within skew", rfcSecret, codeAt(current - 1), 0, true},
+		{"next step within skew", rfcSecret, codeAt(current + 1), 0, true},
+		{"step outside skew", rfcSecret, codeAt(current - 3), 0, false},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", codeAt(current), 0, true},
+		{"replay of the last used step", rfcSecret, codeAt(current), current, false},
+		{"step before the last used one", rfcSecret, codeAt(current - 1), current, false},
+		{"step after the last used one", rfcSecret, codeAt(current + 1), current, true},
+		{"wrong code", rfcSecret, "000000", 0, false},
+		{"too short", rfcSecret, codeAt(current)[:5], 0, false},
+		{"invalid secret", "not base32!", codeAt(current), 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, valid := ValidateTOTP(test.secret, test.code, test.lastUsedStep)
+			if valid != test.valid {
+				t.Fatalf("valid = %v, want %v", valid, test.valid)
+			}
+			if valid && step <= test.lastUsedStep {
+				t.Errorf("step %d is not after the last used step %d", step, test.lastUsedStep)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bloggo/internal/utils/apierrors"
+	"net"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -12,8 +13,28 @@ type JWTContext string
 const (
 	TokenUserId JWTContext = "userId"
 	TokenRoleId JWTContext = "userRole"
+	APIKeyId    JWTContext = "apiKeyId"
+	ClientIP    JWTContext = "clientIp"
+
+	// Permissions granted to the personal access token of the request,
+	// unset for session tokens
+	TokenPermissions JWTContext = "tokenPermissions"
 )
 
+// GetClientIP returns the client address resolved by the ClientIP
+// middleware, or the connection address when it didn't run.
+func GetClientIP(request *http.Request) string {
+	if ip, ok := request.Context().Value(ClientIP).(string); ok {
+		return ip
+	}
+
+	ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return ip
+}
+
 // GetContextValue retrieves a value from the request context and converts it to the specified type.
 func GetContextValue[T any](
 	writer http.ResponseWriter,
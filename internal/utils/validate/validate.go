@@ -5,6 +5,7 @@ import (
 	"mime/multipart"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -38,9 +39,10 @@ func GetValidator() *validator.Validate {
 }
 
 var customValidator = map[string]func(validator.FieldLevel) bool{
-	"port":     PortValidator,
-	"safePath": SafePathValidator,
-	"file":     FileValidator,
+	"port":         PortValidator,
+	"safePath":     SafePathValidator,
+	"file":         FileValidator,
+	"eventPattern": EventPatternValidator,
 }
 
 // Checks if a number is a valid port number (80, 443 or in range 1025-65535)
@@ -72,3 +74,11 @@ func FileValidator(fieldLevel validator.FieldLevel) bool {
 
 	return false
 }
+
+var eventPatternRegexp = regexp.MustCompile(`^(\*|[a-z_]+)(\.(\*|[a-z_]+))*$`)
+
+// Checks if an event pattern is dot separated names, where any name can be
+// a * wildcard, e.g. post.* or *.deleted
+func EventPatternValidator(fieldLevel validator.FieldLevel) bool {
+	return eventPatternRegexp.MatchString(fieldLevel.Field().String())
+}
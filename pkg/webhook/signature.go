@@ -0,0 +1,132 @@
+// Package webhook verifies the signature Bloggo sends with every webhook
+// delivery, so receivers can check a payload came from Bloggo and reject
+// replays of old deliveries.
+//
+// Each delivery carries a header such as
+//
+//	X-Bloggo-Signature: t=1700000000,v1=5257a869...,v1=9a2b0c1d...
+//
+// where t is the unix time of the attempt and each v1 is the hex encoded
+// HMAC-SHA256 of "<t>.<raw body>" with one of the endpoint's active secrets.
+// While a secret is being rotated both the new and the previous secret sign
+// the delivery, so receivers can switch at their own pace.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Header holding the timestamp and signatures
+	SignatureHeader = "X-Bloggo-Signature"
+	// Header holding the delivery id, the same for every retry of a delivery
+	DeliveryHeader = "X-Bloggo-Delivery"
+	// How far the signed timestamp may be from the receiver's clock
+	DefaultTolerance = 5 * time.Minute
+
+	signatureVersion = "v1"
+)
+
+var (
+	ErrMissingSignature    = errors.New("webhook: missing signature header")
+	ErrInvalidHeader       = errors.New("webhook: invalid signature header")
+	ErrTimestampOutOfRange = errors.New("webhook: timestamp outside of tolerance")
+	ErrSignatureMismatch   = errors.New("webhook: no signature matches the secret")
+)
+
+// Sign returns the hex encoded signature of the body at the timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaderValue builds the signature header with one signature per
+// secret
+func SignatureHeaderValue(timestamp time.Time, body []byte, secrets ...string) string {
+	parts := []string{"t=" + strconv.FormatInt(timestamp.Unix(), 10)}
+	for _, secret := range secrets {
+		parts = append(parts, signatureVersion+"="+Sign(secret, timestamp, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks that the header signs the body with one of the secrets and
+// that its timestamp is within the tolerance, DefaultTolerance when zero.
+// Receivers rotating their own copy of the secret can pass both.
+func Verify(header string, body []byte, tolerance time.Duration, secrets ...string) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	var timestamp int64
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			timestamp = parsed
+		case signatureVersion:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidHeader
+	}
+
+	signedAt := time.Unix(timestamp, 0)
+	if age := time.Since(signedAt); age > tolerance || age < -tolerance {
+		return ErrTimestampOutOfRange
+	}
+
+	for _, secret := range secrets {
+		expected, _ := hex.DecodeString(Sign(secret, signedAt, body))
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return nil
+			}
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// VerifyRequest reads the body of a delivery and verifies it. The body is
+// returned and also put back on the request for later handlers.
+func VerifyRequest(request *http.Request, tolerance time.Duration, secrets ...string) ([]byte, error) {
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(request.Header.Get(SignatureHeader), body, tolerance, secrets...); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,160 @@
+package webhook_test
+
+import (
+	"bloggo/pkg/webhook"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"post.published"}`)
+	now := time.Now()
+	const secret = "whsec_current"
+	const previous = "whsec_previous"
+
+	tests := []struct {
+		name      string
+		header    string
+		body      []byte
+		tolerance time.Duration
+		secrets   []string
+		want      error
+	}{
+		{
+			name:    "valid",
+			header:  webhook.SignatureHeaderValue(now, body, secret),
+			secrets: []string{secret},
+		},
+		{
+			name:    "signed with both secrets during a rotation",
+			header:  webhook.SignatureHeaderValue(now, body, secret, previous),
+			secrets: []string{previous},
+		},
+		{
+			name:    "receiver holding both secrets",
+			header:  webhook.SignatureHeaderValue(now, body, previous),
+			secrets: []string{secret, previous},
+		},
+		{
+			name:    "wrong secret",
+			header:  webhook.SignatureHeaderValue(now, body, secret),
+			secrets: []string{"whsec_other"},
+			want:    webhook.ErrSignatureMismatch,
+		},
+		{
+			name:    "tampered body",
+			header:  webhook.SignatureHeaderValue(now, body, secret),
+			body:    []byte(`{"event":"post.deleted"}`),
+			secrets: []string{secret},
+			want:    webhook.ErrSignatureMismatch,
+		},
+		{
+			name:    "within the default tolerance",
+			header:  webhook.SignatureHeaderValue(now.Add(-4*time.Minute), body, secret),
+			secrets: []string{secret},
+		},
+		{
+			name:    "older than the default tolerance",
+			header:  webhook.SignatureHeaderValue(now.Add(-6*time.Minute), body, secret),
+			secrets: []string{secret},
+			want:    webhook.ErrTimestampOutOfRange,
+		},
+		{
+			name:    "too far in the future",
+			header:  webhook.SignatureHeaderValue(now.Add(6*time.Minute), body, secret),
+			secrets: []string{secret},
+			want:    webhook.ErrTimestampOutOfRange,
+		},
+		{
+			name:      "older than a custom tolerance",
+			header:    webhook.SignatureHeaderValue(now.Add(-2*time.Minute), body, secret),
+			tolerance: time.Minute,
+			secrets:   []string{secret},
+			want:      webhook.ErrTimestampOutOfRange,
+		},
+		{
+			name:      "within a custom tolerance",
+			header:    webhook.SignatureHeaderValue(now.Add(-8*time.Minute), body, secret),
+			tolerance: 10 * time.Minute,
+			secrets:   []string{secret},
+		},
+		{
+			name:    "unknown versions are ignored",
+			header:  webhook.SignatureHeaderValue(now, body, secret) + ",v0=abcd",
+			secrets: []string{secret},
+		},
+		{
+			name:    "missing header",
+			header:  "",
+			secrets: []string{secret},
+			want:    webhook.ErrMissingSignature,
+		},
+		{
+			name:    "part without a value",
+			header:  webhook.SignatureHeaderValue(now, body, secret) + ",v1",
+			secrets: []string{secret},
+			want:    webhook.ErrInvalidHeader,
+		},
+		{
+			name:    "timestamp is not a number",
+			header:  "t=yesterday,v1=" + webhook.Sign(secret, now, body),
+			secrets: []string{secret},
+			want:    webhook.ErrInvalidHeader,
+		},
+		{
+			name:    "signature is not hex",
+			header:  "t=1700000000,v1=zz",
+			secrets: []string{secret},
+			want:    webhook.ErrInvalidHeader,
+		},
+		{
+			name:    "no timestamp",
+			header:  "v1=" + webhook.Sign(secret, now, body),
+			secrets: []string{secret},
+			want:    webhook.ErrInvalidHeader,
+		},
+		{
+			name:    "no signature",
+			header:  "t=1700000000",
+			secrets: []string{secret},
+			want:    webhook.ErrInvalidHeader,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := body
+			if test.body != nil {
+				payload = test.body
+			}
+
+			err := webhook.Verify(test.header, payload, test.tolerance, test.secrets...)
+			if !errors.Is(err, test.want) {
+				t.Errorf("Verify() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"event":"post.published"}`
+	request := httptest.NewRequest("POST", "/hooks", strings.NewReader(body))
+	request.Header.Set(webhook.SignatureHeader, webhook.SignatureHeaderValue(time.Now(), []byte(body), "whsec_current"))
+
+	read, err := webhook.VerifyRequest(request, 0, "whsec_current")
+	if err != nil {
+		t.Fatalf("VerifyRequest() = %v", err)
+	}
+	if string(read) != body {
+		t.Errorf("body = %s, want %s", read, body)
+	}
+
+	// The body stays readable for later handlers
+	again, _ := webhook.VerifyRequest(request, 0, "whsec_current")
+	if string(again) != body {
+		t.Errorf("body read again = %s, want %s", again, body)
+	}
+}